@@ -33,5 +33,7 @@ if ! command -v supascan &>/dev/null; then
   exit 1
 fi
 
-# Run supascan validate (it calls sudo goss internally for privileged checks)
+# Run supascan validate. Specs are checked in-process by the native engine,
+# which does not escalate through sudo, so this script must run as root
+# (the playbook task uses become: yes).
 exec supascan validate --verbose "$BASELINES_DIR"
@@ -47,7 +47,7 @@ let
       "-X main.version=1.0.0"
     ];
 
-    # supascan needs goss at runtime for validation
+    # goss is kept on PATH for the optional `validate --engine goss` backend
     nativeBuildInputs = [ pkgs.makeWrapper ];
 
     postInstall = ''
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/supascan/internal/diff"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+var (
+	// diff flags
+	diffFormat      string
+	diffTypes       []string
+	diffIgnoreUnset bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-baseline> <new-baseline>",
+	Short: "Compare two baselines",
+	Long: `Compare two baselines and report resources that were added, removed or changed.
+
+Each baseline can be a monolithic baseline.yml or a directory of split spec
+files. Resources are compared by type (file, package, service, kernel-param, ...)
+and changed resources list each attribute that differs.
+
+Examples:
+  # Compare two split baseline directories
+  supascan diff audit-specs/baselines/ami-build audit-specs/baselines/prod-deployed
+
+  # Compare only packages and services
+  supascan diff --type package --type service old.yml new.yml
+
+  # Ignore attributes the old baseline does not set
+  supascan diff --ignore-unset ami-build/ prod-deployed/
+
+  # Markdown output for a pull request comment
+  supascan diff --format markdown old.yml new.yml
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFormat, "format", diff.FormatText, "Output format: text, json or markdown")
+	diffCmd.Flags().StringArrayVar(&diffTypes, "type", nil, "Resource types to compare (can be specified multiple times)")
+	diffCmd.Flags().BoolVar(&diffIgnoreUnset, "ignore-unset", false, "Ignore attributes that the old baseline does not set")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	oldPath, newPath := args[0], args[1]
+
+	if err := validateResourceTypes(diffTypes); err != nil {
+		return err
+	}
+
+	oldBaseline, err := spec.Load(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", oldPath, err)
+	}
+	newBaseline, err := spec.Load(newPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", newPath, err)
+	}
+
+	report := diff.Compare(oldBaseline, newBaseline, diff.Options{
+		IgnoreUnset: diffIgnoreUnset,
+		Types:       diffTypes,
+	})
+	report.Old = oldPath
+	report.New = newPath
+
+	return diff.Write(os.Stdout, report, diffFormat)
+}
+
+// validateResourceTypes rejects resource types that baselines do not contain
+func validateResourceTypes(types []string) error {
+	for _, t := range types {
+		known := false
+		for _, rt := range spec.ResourceTypes {
+			if t == rt {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown resource type: %s", t)
+		}
+	}
+	return nil
+}
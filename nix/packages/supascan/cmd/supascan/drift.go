@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/diff"
+	"github.com/supabase/supascan/internal/logger"
+	"github.com/supabase/supascan/internal/scanners"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+var (
+	// drift flags
+	driftConfigFile     string
+	driftFormat         string
+	driftTypes          []string
+	driftIncludeDynamic bool
+	driftShallowDirs    []string
+	driftShallowDepth   int
+	driftStrict         bool
+	driftVerbose        bool
+	driftDebug          bool
+	driftLogFormat      string
+	driftWorkers        int
+	driftWalkWorkers    int
+	driftHashPaths      []string
+	driftHashWorkers    int
+	driftSymlinkTargets bool
+	driftCapabilities   bool
+	driftDirectories    []string
+	driftAllowedSetuid  []string
+	driftUnits          []string
+	driftUnitProperties []string
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift <baseline>",
+	Short: "Scan the system and report drift from a baseline",
+	Long: `Scan the current system and compare it against a baseline in memory.
+
+This runs the same scanners as genspec but reports only the differences,
+without writing an intermediate spec. Unlike validate, which only checks the
+resources a spec lists, drift also reports resources that exist on the host
+but not in the baseline, such as a newly installed package, a new setuid
+binary or a newly enabled service.
+
+The baseline can be a monolithic baseline.yml or a directory of split spec
+files. Attributes the baseline does not set are not compared. The systemd
+units and unit properties the baseline lists are scanned in addition to the
+configured ones. Only the resource types the scan covers are compared: ports
+and processes need --include-dynamic, excluded kernel parameters are left
+out, and command resources are never compared.
+
+Exits with status 1 if any drift is found.
+
+Examples:
+  # Report drift from a split baseline directory
+  sudo supascan drift audit-specs/baselines/prod-deployed
+
+  # Only look for new or changed packages and services
+  sudo supascan drift --type package --type service baseline.yml
+
+  # Markdown report using the same exclusions the baseline was generated with
+  sudo supascan drift --config config.yaml --format markdown baseline.yml
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDrift,
+}
+
+func init() {
+	driftCmd.Flags().StringVar(&driftConfigFile, "config", "", "Load exclusions from config file")
+	driftCmd.Flags().StringVar(&driftFormat, "format", diff.FormatText, "Output format: text, json or markdown")
+	driftCmd.Flags().StringArrayVar(&driftTypes, "type", nil, "Resource types to compare (can be specified multiple times)")
+	driftCmd.Flags().BoolVar(&driftIncludeDynamic, "include-dynamic", false, "Include dynamic kernel parameters and scanners")
+	driftCmd.Flags().StringArrayVar(&driftShallowDirs, "shallow-dirs", nil, "Directories to scan with limited depth (can be specified multiple times)")
+	driftCmd.Flags().IntVar(&driftShallowDepth, "shallow-depth", 1, "How deep to scan in shallow dirs (1=top level only, 2=include immediate subdirs)")
+	driftCmd.Flags().BoolVar(&driftStrict, "strict", false, "Fail on any access errors (default: skip and warn)")
+	driftCmd.Flags().BoolVar(&driftVerbose, "verbose", false, "Enable structured logging to stderr")
+	driftCmd.Flags().BoolVar(&driftDebug, "debug", false, "Enable debug logging (implies --verbose)")
+	driftCmd.Flags().StringVar(&driftLogFormat, "log-format", "logfmt", "Log format: logfmt or json")
+	driftCmd.Flags().IntVar(&driftWorkers, "workers", runtime.NumCPU(), "Number of scanners to run concurrently")
+	driftCmd.Flags().IntVar(&driftWalkWorkers, "walk-workers", runtime.NumCPU(), "Number of directories the file scanner reads concurrently")
+	driftCmd.Flags().StringArrayVar(&driftHashPaths, "hash", nil, "Hash files matching a path glob to compare their sha256 (can be specified multiple times)")
+	driftCmd.Flags().IntVar(&driftHashWorkers, "hash-workers", runtime.NumCPU(), "Number of files hashed concurrently")
+	driftCmd.Flags().BoolVar(&driftSymlinkTargets, "symlink-targets", false, "Compare whether the target of each symlink exists")
+	driftCmd.Flags().BoolVar(&driftCapabilities, "capabilities", false, "Compare file capabilities (security.capability xattrs)")
+	driftCmd.Flags().StringArrayVar(&driftDirectories, "directories", nil, "Compare directories matching a path glob, '/*' for all (can be specified multiple times)")
+	driftCmd.Flags().StringArrayVar(&driftAllowedSetuid, "allow-setuid", nil, "Do not report setuid/setgid binaries matching a path glob as findings (can be specified multiple times)")
+	driftCmd.Flags().StringArrayVar(&driftUnits, "unit", nil, "Compare the properties and drop-ins of a systemd unit (can be specified multiple times)")
+	driftCmd.Flags().StringArrayVar(&driftUnitProperties, "unit-property", nil, "Compare a systemd unit property (can be specified multiple times)")
+
+	rootCmd.AddCommand(driftCmd)
+}
+
+func runDrift(cmd *cobra.Command, args []string) error {
+	baselinePath := args[0]
+
+	if err := validateResourceTypes(driftTypes); err != nil {
+		return err
+	}
+
+	// Setup structured logging
+	if driftDebug {
+		driftVerbose = true
+	}
+	scanLogger := logger.Setup(driftVerbose, driftDebug, driftLogFormat)
+
+	baseline, err := spec.Load(baselinePath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", baselinePath, err)
+	}
+
+	cfg, err := config.Load(driftConfigFile, config.CLIOptions{
+		IncludeDynamic:  driftIncludeDynamic,
+		ShallowDirs:     driftShallowDirs,
+		ShallowDepth:    driftShallowDepth,
+		ShallowDepthSet: cmd.Flags().Changed("shallow-depth"),
+		HashPaths:       driftHashPaths,
+		SymlinkTargets:  driftSymlinkTargets,
+		Capabilities:    driftCapabilities,
+		Directories:     driftDirectories,
+		AllowedSetuid:   driftAllowedSetuid,
+		Units:           driftUnits,
+		UnitProperties:  driftUnitProperties,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Read the units and properties the baseline lists, so they are not
+	// reported as removed
+	for name, unit := range baseline.SystemdUnits {
+		cfg.Units = append(cfg.Units, name)
+		for property := range unit.Properties {
+			cfg.UnitProperties = append(cfg.UnitProperties, property)
+		}
+	}
+
+	// Scan into memory instead of a spec file
+	live := spec.NewBaseline()
+	recorder := newTypeRecorder(live)
+	result, err := scanners.RunAll(context.Background(), scanners.ScanOptions{
+		Writer:         recorder,
+		Config:         cfg,
+		IncludeDynamic: driftIncludeDynamic,
+		Strict:         driftStrict,
+		Logger:         scanLogger,
+		Workers:        driftWorkers,
+		WalkWorkers:    driftWalkWorkers,
+		HashWorkers:    driftHashWorkers,
+	})
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	for _, warning := range result.Warnings {
+		scanLogger.Warn(warning)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	// Kernel parameters the config excludes (such as the dynamic ones
+	// without --include-dynamic) are not scanned, so they are not compared
+	for key := range baseline.KernelParams {
+		if cfg.IsKernelParamExcluded(key) {
+			delete(baseline.KernelParams, key)
+		}
+	}
+
+	types := recorder.scannedTypes(driftTypes)
+	if len(driftTypes) > len(types) {
+		scanLogger.Warn("Some resource types were not scanned and are not compared",
+			"requested", driftTypes, "compared", types)
+	}
+	if len(types) == 0 {
+		return fmt.Errorf("none of the requested resource types were scanned")
+	}
+
+	report := diff.Compare(baseline, live, diff.Options{
+		IgnoreUnset:          true,
+		AbsentMatchesMissing: true,
+		Types:                types,
+	})
+	report.Old = baselinePath
+	report.New = fmt.Sprintf("live system (%s)", hostname)
+
+	if err := diff.Write(os.Stdout, report, driftFormat); err != nil {
+		return err
+	}
+
+	scanLogger.Info("Drift check completed",
+		"baseline", baselinePath,
+		"changes", len(report.Changes),
+		"scanners_run", result.ScannersRun,
+		"warnings", len(result.Warnings),
+		"duration", result.Duration,
+	)
+
+	if report.HasChanges() {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("drift detected: %d resource(s) differ from the baseline", len(report.Changes))
+	}
+
+	return nil
+}
+
+// typeRecorder forwards scan results to a baseline and records the resource
+// types the scanners started
+type typeRecorder struct {
+	*spec.Baseline
+
+	mu      sync.Mutex
+	started map[string]bool
+}
+
+func newTypeRecorder(b *spec.Baseline) *typeRecorder {
+	return &typeRecorder{Baseline: b, started: make(map[string]bool)}
+}
+
+// StartResource records the resource type before forwarding it
+func (r *typeRecorder) StartResource(resourceType string) error {
+	r.mu.Lock()
+	r.started[resourceType] = true
+	r.mu.Unlock()
+	return r.Baseline.StartResource(resourceType)
+}
+
+// scannedTypes returns the resource types among requested (default: all)
+// that the scan produced. Scanners that did not run, such as the dynamic
+// ones without --include-dynamic, leave their types out, and so does the
+// command scanner, which starts its section but never fills it: command
+// resources are hand-written checks.
+func (r *typeRecorder) scannedTypes(requested []string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(requested) == 0 {
+		requested = spec.ResourceTypes
+	}
+
+	var types []string
+	for _, t := range requested {
+		if r.started[t] && t != "command" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
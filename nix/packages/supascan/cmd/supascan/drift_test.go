@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/supabase/supascan/internal/scanners"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+func TestTypeRecorder_ScannedTypes(t *testing.T) {
+	recorder := newTypeRecorder(spec.NewBaseline())
+
+	// The command scanner starts its section without filling it
+	_, err := (&scanners.CommandScanner{}).Scan(context.Background(), scanners.ScanOptions{
+		Writer: recorder,
+		Logger: log.New(io.Discard),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if err := recorder.StartResource("package"); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Add(spec.PackageSpec{Name: "curl", Installed: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := recorder.scannedTypes(nil); !reflect.DeepEqual(got, []string{"package"}) {
+		t.Errorf("scannedTypes(nil) = %v, want [package]", got)
+	}
+	if got := recorder.scannedTypes([]string{"port", "package"}); !reflect.DeepEqual(got, []string{"package"}) {
+		t.Errorf("scannedTypes([port package]) = %v, want [package]", got)
+	}
+	if got := recorder.scannedTypes([]string{"port"}); len(got) != 0 {
+		t.Errorf("scannedTypes([port]) = %v, want none", got)
+	}
+	if _, ok := recorder.Packages["curl"]; !ok {
+		t.Error("Expected the package to be forwarded to the baseline")
+	}
+}
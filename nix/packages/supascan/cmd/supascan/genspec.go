@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"runtime"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/image"
 	"github.com/supabase/supascan/internal/logger"
 	"github.com/supabase/supascan/internal/scanners"
 	"github.com/supabase/supascan/internal/spec"
@@ -27,6 +30,18 @@ var (
 	verbose        bool
 	debug          bool
 	logFormat      string
+	scanWorkers    int
+	rootDir        string
+	imagePath      string
+	walkWorkers    int
+	hashPaths      []string
+	hashWorkers    int
+	symlinkTargets bool
+	capabilities   bool
+	directories    []string
+	allowedSetuid  []string
+	units          []string
+	unitProperties []string
 )
 
 var genspecCmd = &cobra.Command{
@@ -38,6 +53,49 @@ This command scans the system and generates a YAML or JSON specification file
 containing packages, files, kernel parameters, services, users, groups, mounts,
 and optionally listening ports and running processes.
 
+With --root, an offline root filesystem (a mounted, unbooted image volume or a
+chroot) is scanned instead of the live system. Files are reported relative to
+that root, users, groups and file owners come from its /etc/passwd and
+/etc/group, and packages from its dpkg status file. Scanners that read the
+running system (services, kernel parameters, mounts, commands, ports and
+processes) are skipped.
+
+With --hash (or hashPaths in the config file), the content of matching files
+is hashed into a sha256 attribute, so a replaced configuration file or binary
+fails validation even if its mode and owner are unchanged. Files larger than
+hashMaxSize (64 MiB by default) are recorded without a hash.
+
+Symlinks are recorded with filetype symlink and their linked-to target. With
+--symlink-targets, whether each target exists is recorded as well, so a
+dangling or repointed link is caught.
+
+File modes include the setuid, setgid and sticky bits (e.g. "4755"). With
+--capabilities, file capabilities such as cap_net_raw=ep are recorded as well.
+
+Directories are recorded (filetype directory, with mode, owner and group) when
+they match --directories or directories in the config file, e.g.
+--directories '/data/*' for a world-writable or wrongly owned data directory.
+
+Risky file states are recorded in a separate finding section: world-writable
+files outside sticky directories such as /tmp, files whose owner or group has
+no /etc/passwd or /etc/group entry, and setuid or setgid binaries not on the
+allow-list (extended with --allow-setuid or allowedSetuid in the config file).
+validate treats finding.yml as critical, so any new finding fails it.
+
+Every systemd unit (services, timers, sockets, paths, mounts, ...) is recorded
+with its type, enablement, active state, drop-in files and, for timers, the
+schedule. Units matching excludedUnits in the config file, such as devices
+and login sessions, are skipped. The effective properties of selected units
+(ExecStart, User, ProtectSystem, NoNewPrivileges, ...) are also recorded from
+"systemctl show". Add units with --unit or units in the config file, and
+properties with --unit-property or unitProperties.
+
+With --image, a container image saved with "docker save" or as an OCI image
+layout tarball is scanned the same way. Its layers, including whiteouts, are
+applied into an in-memory filesystem, so neither extracting the image nor a
+container runtime is needed. The tarball itself must be uncompressed; gzip
+layers within it are supported.
+
 Examples:
   # Generate default machine-baseline.yaml
   supascan genspec
@@ -56,6 +114,25 @@ Examples:
 
   # Scan shallow dirs including immediate subdirectories (depth 2)
   supascan genspec --shallow-dirs /nix/store --shallow-depth 2
+
+  # Build a baseline from an image volume mounted at /mnt/image
+  supascan genspec --root /mnt/image image-baseline.yaml
+
+  # Also record the permissions of every directory
+  supascan genspec --directories '/*'
+
+  # Also record the properties of a custom unit
+  supascan genspec --unit wal-g.service --unit-property TimeoutStopSec
+
+  # Accept a locally built setuid helper
+  supascan genspec --allow-setuid /usr/local/bin/pg-helper
+
+  # Record content hashes of PostgreSQL config and local binaries
+  supascan genspec --hash '/etc/postgresql/*' --hash '/usr/local/bin/*'
+
+  # Build a baseline from a saved container image
+  docker save supabase/postgres:15 -o postgres.tar
+  supascan genspec --image postgres.tar image-baseline.yaml
 `,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runGenspec,
@@ -73,6 +150,20 @@ func init() {
 	genspecCmd.Flags().BoolVar(&verbose, "verbose", false, "Enable structured logging to stderr")
 	genspecCmd.Flags().BoolVar(&debug, "debug", false, "Enable debug logging (implies --verbose)")
 	genspecCmd.Flags().StringVar(&logFormat, "log-format", "logfmt", "Log format: logfmt or json")
+	genspecCmd.Flags().StringVar(&rootDir, "root", "", "Scan an offline root filesystem (mounted image or chroot) instead of the live system")
+	genspecCmd.Flags().StringVar(&imagePath, "image", "", "Scan a docker save or OCI image layout tarball instead of the live system")
+	genspecCmd.Flags().IntVar(&scanWorkers, "workers", runtime.NumCPU(), "Number of scanners to run concurrently")
+	genspecCmd.Flags().IntVar(&walkWorkers, "walk-workers", runtime.NumCPU(), "Number of directories the file scanner reads concurrently")
+	genspecCmd.Flags().StringArrayVar(&hashPaths, "hash", nil, "Record the sha256 of files matching a path glob (can be specified multiple times)")
+	genspecCmd.Flags().IntVar(&hashWorkers, "hash-workers", runtime.NumCPU(), "Number of files hashed concurrently")
+	genspecCmd.Flags().BoolVar(&symlinkTargets, "symlink-targets", false, "Record whether the target of each symlink exists")
+	genspecCmd.Flags().BoolVar(&capabilities, "capabilities", false, "Record file capabilities (security.capability xattrs)")
+	genspecCmd.Flags().StringArrayVar(&directories, "directories", nil, "Record directories matching a path glob, '/*' for all (can be specified multiple times)")
+	genspecCmd.Flags().StringArrayVar(&allowedSetuid, "allow-setuid", nil, "Do not report setuid/setgid binaries matching a path glob as findings (can be specified multiple times)")
+	genspecCmd.Flags().StringArrayVar(&units, "unit", nil, "Record the properties and drop-ins of a systemd unit (can be specified multiple times)")
+	genspecCmd.Flags().StringArrayVar(&unitProperties, "unit-property", nil, "Record a systemd unit property (can be specified multiple times)")
+
+	genspecCmd.MarkFlagsMutuallyExclusive("root", "image")
 
 	rootCmd.AddCommand(genspecCmd)
 }
@@ -98,6 +189,13 @@ func runGenspec(cmd *cobra.Command, args []string) error {
 		ShallowDirs:      shallowDirs,
 		ShallowDepth:     shallowDepth,
 		ShallowDepthSet:  cmd.Flags().Changed("shallow-depth"),
+		HashPaths:        hashPaths,
+		SymlinkTargets:   symlinkTargets,
+		Capabilities:     capabilities,
+		Directories:      directories,
+		AllowedSetuid:    allowedSetuid,
+		Units:            units,
+		UnitProperties:   unitProperties,
 	}
 	cfg, err := config.Load(configFile, cliOpts)
 	if err != nil {
@@ -115,6 +213,27 @@ func runGenspec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid output format: %s (must be yaml or json)", outputFormat)
 	}
 
+	// Offline root filesystem, checked before the output file is created
+	var rootFS fs.FS
+	if rootDir != "" {
+		info, err := os.Stat(rootDir)
+		if err != nil {
+			return fmt.Errorf("root filesystem not found: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("root filesystem is not a directory: %s", rootDir)
+		}
+		rootFS = scanners.DirFS(rootDir)
+	}
+	if imagePath != "" {
+		scanLogger.Info("Reading image", "path", imagePath)
+		imageFS, err := image.Open(imagePath, image.Options{Digest: cfg.ShouldHash})
+		if err != nil {
+			return fmt.Errorf("failed to read image: %w", err)
+		}
+		rootFS = imageFS
+	}
+
 	// Create writer
 	writer, err := spec.NewWriterWithFormat(outputFile, format)
 	if err != nil {
@@ -132,6 +251,12 @@ func runGenspec(cmd *cobra.Command, args []string) error {
 	// Write header with metadata
 	timestamp := time.Now().Format(time.RFC3339)
 	header := fmt.Sprintf("Generated by supascan %s on %s at %s", version, hostname, timestamp)
+	if rootDir != "" {
+		header = fmt.Sprintf("Generated by supascan %s from root %s on %s at %s", version, rootDir, hostname, timestamp)
+	}
+	if imagePath != "" {
+		header = fmt.Sprintf("Generated by supascan %s from image %s on %s at %s", version, imagePath, hostname, timestamp)
+	}
 	if err := writer.WriteHeader(header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
@@ -143,6 +268,10 @@ func runGenspec(cmd *cobra.Command, args []string) error {
 		IncludeDynamic: includeDynamic,
 		Strict:         strict,
 		Logger:         scanLogger,
+		Workers:        scanWorkers,
+		WalkWorkers:    walkWorkers,
+		HashWorkers:    hashWorkers,
+		RootFS:         rootFS,
 	}
 
 	// Run all scanners
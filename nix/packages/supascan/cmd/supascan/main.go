@@ -22,6 +22,8 @@ Commands:
   genspec   Generate a baseline specification from the current system
   validate  Validate the system against baseline specifications
   split     Split a baseline file into separate section files
+  diff      Compare two baselines
+  drift     Scan the system and report drift from a baseline
 
 Examples:
   # Generate a baseline spec
@@ -33,6 +35,12 @@ Examples:
   # Split a baseline into sections
   supascan split baseline.yml
 
+  # Compare two baselines
+  supascan diff old-baseline.yml new-baseline/
+
+  # Report drift of the live system from a baseline
+  supascan drift /path/to/baselines
+
   # Generate with verbose output
   supascan genspec --verbose --format yaml baseline.yml
 `,
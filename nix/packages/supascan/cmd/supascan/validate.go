@@ -5,16 +5,31 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/logger"
 	"github.com/supabase/supascan/internal/validator"
 )
 
 var (
 	// validate flags
-	gossPath        string
-	validateFormat  string
-	validateVerbose bool
+	validateEngine   string
+	validateManifest string
+	gossPath         string
+	validateFormat   string
+	validateVerbose  bool
+	validateJobs     int
+	validateReports  []string
+	validateDebug    bool
+	validateLogFmt   string
+
+	// Exclusions and allow-list findings are read with
+	validateConfigFile    string
+	validateAllowedSetuid []string
+	validateShallowDirs   []string
+	validateShallowDepth  int
 )
 
 var validateCmd = &cobra.Command{
@@ -22,16 +37,47 @@ var validateCmd = &cobra.Command{
 	Short: "Validate the system against baseline specifications",
 	Long: `Validate the system against multiple baseline specification files.
 
-This command runs goss validation against each spec file in a baselines directory,
-categorizing results as critical (must pass) or advisory (informational).
+This command checks each spec file in a baselines directory against the live
+system, categorizing results as critical (must pass) or advisory (informational).
+
+Specs are evaluated in-process by default, reading live state with the same
+scanners genspec uses. The native engine does not escalate through sudo, so
+run validate as root. goss sections it does not implement, such as http or
+dns, are skipped. Use --engine goss to run each spec through goss instead;
+sections goss does not know, such as cron-job or finding, are then skipped.
 
 The validation will fail if any critical spec fails, but advisory failures
-are reported without failing the overall validation.
+are reported without failing the overall validation. Each failed check is
+listed with its resource, property, and expected and actual values.
+
+Specs are classified by a manifest, baselines.yaml in the baselines directory
+(or the file given with --manifest). Each rule maps a glob pattern on the spec
+file name to a severity; the first matching rule wins:
+
+  rules:
+    - pattern: "service*.yml"
+      severity: critical
+    - pattern: "files-*.yml"
+      severity: advisory
+    - pattern: "scratch-*.yml"
+      severity: ignore
+
+Spec files no rule matches are reported as unclassified and not run.
+
+finding.yml is compared against the findings on the paths genspec walked,
+and systemd-unit.yml against the units genspec did not exclude. Pass
+validate the same --config, --allow-setuid, --shallow-dirs and
+--shallow-depth as genspec, or allowed setuid binaries, excluded paths and
+excluded units show up as new findings and units.
+
+Without a manifest, these defaults apply:
 
 Critical specs (must pass):
-  - service.yml, user.yml, group.yml, mount.yml, package.yml
+  - service.yml, systemd-unit.yml, cron-job.yml, user.yml, group.yml
+  - shadow.yml, sudoers-rule.yml, sudoers-default.yml, mount.yml, package.yml
+  - sshd-config.yml, postgres-config.yml, postgres-hba.yml, postgres-ident.yml
   - files-security.yml, files-ssl.yml
-  - files-postgres-config.yml, files-postgres-data.yml
+  - files-postgres-config.yml, files-postgres-data.yml, finding.yml
 
 Advisory specs (informational):
   - kernel-param.yml, files-*.yml (non-critical paths)
@@ -40,20 +86,48 @@ Examples:
   # Validate using baselines directory
   supascan validate /path/to/baselines
 
-  # Verbose output with documentation format
-  supascan validate --verbose --format documentation /path/to/baselines
+  # Verbose output showing every check, not only failures
+  supascan validate --verbose /path/to/baselines
+
+  # Validate up to 4 spec files at a time
+  supascan validate --jobs 4 /path/to/baselines
+
+  # Machine-readable results
+  supascan validate --format json /path/to/baselines
+
+  # Classify specs with a manifest kept outside the baselines directory
+  supascan validate --manifest ./baselines.yaml /path/to/baselines
+
+  # Read findings with the exclusions and allow-list genspec used
+  supascan validate --config config.yaml --allow-setuid /usr/local/bin/pg-helper /path/to/baselines
+
+  # Write JUnit and SARIF reports for CI
+  supascan validate --report junit=validate.xml --report sarif=validate.sarif /path/to/baselines
+
+  # Validate with goss instead of the native engine
+  supascan validate --engine goss /path/to/baselines
 
   # Use custom goss path
-  supascan validate --goss /usr/local/bin/goss /path/to/baselines
+  supascan validate --engine goss --goss /usr/local/bin/goss /path/to/baselines
 `,
 	Args: cobra.ExactArgs(1),
 	RunE: runValidate,
 }
 
 func init() {
-	validateCmd.Flags().StringVar(&gossPath, "goss", "goss", "Path to goss binary")
+	validateCmd.Flags().StringVar(&validateEngine, "engine", validator.EngineNative, "Validation engine: native or goss")
+	validateCmd.Flags().StringVar(&validateManifest, "manifest", "", "Manifest classifying spec files (default: <baselines-dir>/baselines.yaml)")
+	validateCmd.Flags().StringVar(&gossPath, "goss", "goss", "Path to goss binary (with --engine goss)")
 	validateCmd.Flags().StringVar(&validateFormat, "format", "tap", "Output format: tap, documentation, json")
-	validateCmd.Flags().BoolVar(&validateVerbose, "verbose", false, "Show detailed output for each spec")
+	validateCmd.Flags().StringArrayVar(&validateReports, "report", nil, "Also write a report as format=path (junit or sarif, repeatable)")
+	validateCmd.Flags().IntVar(&validateJobs, "jobs", 1, "Number of spec files to validate in parallel")
+	validateCmd.Flags().BoolVar(&validateVerbose, "verbose", false, "Show passed checks as well as failures")
+	validateCmd.Flags().BoolVar(&validateDebug, "debug", false, "Enable debug logging to stderr (warnings are always shown)")
+	validateCmd.Flags().StringVar(&validateLogFmt, "log-format", "logfmt", "Log format: logfmt or json")
+	validateCmd.Flags().StringVar(&validateConfigFile, "config", "", "Load the exclusions genspec used from config file, for findings and systemd units")
+	validateCmd.Flags().StringArrayVar(&validateAllowedSetuid, "allow-setuid", nil, "Setuid/setgid binaries genspec allowed, as path globs (can be specified multiple times)")
+	validateCmd.Flags().StringArrayVar(&validateShallowDirs, "shallow-dirs", nil, "Shallow directories genspec used, for findings (can be specified multiple times)")
+	validateCmd.Flags().IntVar(&validateShallowDepth, "shallow-depth", 1, "Shallow depth genspec used, for findings")
 
 	rootCmd.AddCommand(validateCmd)
 }
@@ -72,12 +146,47 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
+	if validateJobs < 1 {
+		return fmt.Errorf("invalid --jobs value: %d (must be at least 1)", validateJobs)
+	}
+
+	switch validateFormat {
+	case "documentation", "tap", "json":
+	default:
+		return fmt.Errorf("invalid output format: %s (must be documentation, tap or json)", validateFormat)
+	}
+
+	var reports []validator.ReportSpec
+	for _, r := range validateReports {
+		report, err := validator.ParseReportSpec(r)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+
+	// Findings are only comparable with the exclusions genspec used
+	cfg, err := config.Load(validateConfigFile, config.CLIOptions{
+		ShallowDirs:     validateShallowDirs,
+		ShallowDepth:    validateShallowDepth,
+		ShallowDepthSet: cmd.Flags().Changed("shallow-depth"),
+		AllowedSetuid:   validateAllowedSetuid,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
 	// Create validator
 	v := validator.New(validator.Options{
+		Logger:       validateLogger(validateDebug, validateLogFmt),
 		BaselinesDir: absPath,
+		Manifest:     validateManifest,
+		Engine:       validateEngine,
 		GossPath:     gossPath,
 		Format:       validateFormat,
 		Verbose:      validateVerbose,
+		Jobs:         validateJobs,
+		Config:       cfg,
 	})
 
 	// Run validation
@@ -89,6 +198,12 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	// Print results
 	v.PrintResults(result)
 
+	for _, report := range reports {
+		if err := validator.WriteReport(report, result, version); err != nil {
+			return err
+		}
+	}
+
 	// Return error if critical checks failed
 	if result.CriticalFailed > 0 {
 		return fmt.Errorf("validation failed: %d critical spec(s) failed", result.CriticalFailed)
@@ -96,3 +211,14 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// validateLogger logs to stderr. Unlike genspec and drift, warnings are
+// shown without --verbose (which shows passed checks here), since they
+// report specs that were skipped or not run.
+func validateLogger(debug bool, format string) *log.Logger {
+	l := logger.Setup(true, debug, format)
+	if !debug {
+		l.SetLevel(log.WarnLevel)
+	}
+	return l
+}
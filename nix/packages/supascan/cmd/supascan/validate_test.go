@@ -1,6 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/supabase/supascan/internal/validator"
@@ -70,3 +74,28 @@ func TestCriticalAndAdvisoryDoNotOverlap(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateLogger_ShowsWarnings(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"service.yml": "service: {}\n",
+		"stray.yml":   "file: {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	var stderr bytes.Buffer
+	l := validateLogger(false, "logfmt")
+	l.SetOutput(&stderr)
+
+	v := validator.New(validator.Options{BaselinesDir: dir, Format: "json", Logger: l})
+	if _, err := v.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !strings.Contains(stderr.String(), "not matched by any manifest rule") || !strings.Contains(stderr.String(), "stray.yml") {
+		t.Errorf("Expected the unclassified spec warning, got %q", stderr.String())
+	}
+}
@@ -1,5 +1,9 @@
 package config
 
+// DefaultHashMaxSize is the largest file hashed when Config.HashMaxSize is
+// not set (64 MiB), so a stray database file or image does not stall a scan
+const DefaultHashMaxSize = 64 << 20
+
 // DefaultExclusions contains the hardcoded default exclusions for the scanner.
 // These represent paths, kernel parameters, and scanners that should be excluded
 // by default to avoid noise and false positives in security audits.
@@ -92,6 +96,81 @@ var DefaultExclusions = Config{
 		"net.netfilter.nf_conntrack_expect_max", // Derived from buckets
 	},
 
+	AllowedSetuid: []string{
+		// Setuid and setgid binaries shipped by Ubuntu server packages
+		"/usr/bin/chage",
+		"/usr/bin/chfn",
+		"/usr/bin/chsh",
+		"/usr/bin/crontab",
+		"/usr/bin/expiry",
+		"/usr/bin/fusermount3",
+		"/usr/bin/gpasswd",
+		"/usr/bin/mount",
+		"/usr/bin/newgrp",
+		"/usr/bin/passwd",
+		"/usr/bin/ssh-agent",
+		"/usr/bin/su",
+		"/usr/bin/sudo",
+		"/usr/bin/umount",
+		"/usr/bin/wall",
+		"/usr/lib/dbus-1.0/dbus-daemon-launch-helper",
+		"/usr/lib/openssh/ssh-keysign",
+		"/usr/sbin/pam_extrausers_chkpwd",
+		"/usr/sbin/unix_chkpwd",
+	},
+
+	Units: []string{
+		// Supabase services and the daemons guarding them
+		"postgresql.service",
+		"pgbouncer.service",
+		"postgrest.service",
+		"gotrue.service",
+		"kong.service",
+		"nginx.service",
+		"envoy.service",
+		"adminapi.service",
+		"vector.service",
+		"ssh.service",
+		"fail2ban.service",
+	},
+
+	ExcludedUnits: []string{
+		// Units that come and go with hardware, logins and containers
+		"*.device",
+		"*.scope",
+		"user@*.service",
+		"user-runtime-dir@*.service",
+		"user-*.slice",
+		"run-*.mount",
+		"var-lib-docker-*.mount",
+	},
+
+	UnitProperties: []string{
+		// What runs, and as whom
+		"ExecStart",
+		"ExecStartPre",
+		"ExecStartPost",
+		"ExecReload",
+		"User",
+		"Group",
+		"WorkingDirectory",
+		"EnvironmentFiles", // Environment itself may hold secrets
+		"Restart",
+
+		// Sandboxing and privileges
+		"NoNewPrivileges",
+		"ProtectSystem",
+		"ProtectHome",
+		"PrivateTmp",
+		"PrivateDevices",
+		"ReadWritePaths",
+		"ReadOnlyPaths",
+		"CapabilityBoundingSet",
+		"AmbientCapabilities",
+		"UMask",
+		"LimitNOFILE",
+	},
+
 	DisabledScanners: []string{
 		// Scanners disabled by default for performance/noise reasons
 		"port",    // Network port scanning (slow, often noisy)
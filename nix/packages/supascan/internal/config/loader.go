@@ -34,6 +34,45 @@ type Config struct {
 	// Scanner types to disable (e.g., "port", "process")
 	DisabledScanners []string `yaml:"disabledScanners,omitempty"`
 
+	// HashPaths selects files whose content is hashed into the sha256
+	// attribute (glob patterns, matched like Paths). Hashing is off when empty.
+	HashPaths []string `yaml:"hashPaths,omitempty"`
+
+	// HashMaxSize is the size in bytes above which matching files are recorded
+	// without a hash (0 = DefaultHashMaxSize)
+	HashMaxSize int64 `yaml:"hashMaxSize,omitempty"`
+
+	// Directories selects the directories recorded as file resources with
+	// their mode, owner and group (glob patterns, matched like Paths; "/*"
+	// selects every directory). Only shallow dir roots are recorded when
+	// empty.
+	Directories []string `yaml:"directories,omitempty"`
+
+	// SymlinkTargets records whether the target of each symlink exists
+	// (the target-exists attribute)
+	SymlinkTargets bool `yaml:"symlinkTargets,omitempty"`
+
+	// Capabilities records the file capabilities (security.capability
+	// xattr) of regular files
+	Capabilities bool `yaml:"capabilities,omitempty"`
+
+	// AllowedSetuid lists the setuid and setgid binaries the findings
+	// scanner accepts (glob patterns, matched like Paths)
+	AllowedSetuid []string `yaml:"allowedSetuid,omitempty"`
+
+	// Units lists the systemd units (full names such as
+	// "postgresql.service") whose effective properties are recorded. Units
+	// that are not installed are skipped.
+	Units []string `yaml:"units,omitempty"`
+
+	// ExcludedUnits lists systemd units not recorded at all (glob patterns
+	// such as "*.device" or "session-*.scope")
+	ExcludedUnits []string `yaml:"excludedUnits,omitempty"`
+
+	// UnitProperties lists the "systemctl show" properties recorded for
+	// each unit (e.g. ExecStart, User, ProtectSystem)
+	UnitProperties []string `yaml:"unitProperties,omitempty"`
+
 	// OverridePaths allows CLI to remove default path exclusions
 	OverridePaths []string `yaml:"-"`
 
@@ -62,6 +101,27 @@ type CLIOptions struct {
 
 	// ShallowDepthSet indicates whether ShallowDepth was explicitly set via CLI
 	ShallowDepthSet bool
+
+	// HashPaths adds glob patterns of files to hash (from CLI)
+	HashPaths []string
+
+	// Directories adds glob patterns of directories to record (from CLI)
+	Directories []string
+
+	// SymlinkTargets enables recording whether symlink targets exist
+	SymlinkTargets bool
+
+	// Capabilities enables recording file capabilities
+	Capabilities bool
+
+	// AllowedSetuid adds glob patterns of accepted setuid and setgid binaries (from CLI)
+	AllowedSetuid []string
+
+	// Units adds systemd units whose properties are recorded (from CLI)
+	Units []string
+
+	// UnitProperties adds systemd unit properties to record (from CLI)
+	UnitProperties []string
 }
 
 // Load reads configuration from defaults, optional config file, and CLI options.
@@ -117,6 +177,36 @@ func Load(configPath string, opts CLIOptions) (*Config, error) {
 		cfg.ShallowDirs = append(cfg.ShallowDirs, opts.ShallowDirs...)
 	}
 
+	// Add CLI hash patterns to config
+	if len(opts.HashPaths) > 0 {
+		cfg.HashPaths = append(cfg.HashPaths, opts.HashPaths...)
+	}
+
+	// Add CLI directory patterns to config
+	if len(opts.Directories) > 0 {
+		cfg.Directories = append(cfg.Directories, opts.Directories...)
+	}
+
+	// Add CLI setuid allow-list entries to config
+	if len(opts.AllowedSetuid) > 0 {
+		cfg.AllowedSetuid = append(cfg.AllowedSetuid, opts.AllowedSetuid...)
+	}
+
+	// Add CLI systemd units and properties to config
+	if len(opts.Units) > 0 {
+		cfg.Units = append(cfg.Units, opts.Units...)
+	}
+	if len(opts.UnitProperties) > 0 {
+		cfg.UnitProperties = append(cfg.UnitProperties, opts.UnitProperties...)
+	}
+
+	if opts.SymlinkTargets {
+		cfg.SymlinkTargets = true
+	}
+	if opts.Capabilities {
+		cfg.Capabilities = true
+	}
+
 	// Set shallow depth from CLI (overrides config file and defaults)
 	// ShallowDepthSet allows explicit 0 to be distinguished from "not set"
 	if opts.ShallowDepthSet {
@@ -154,11 +244,22 @@ func merge(base, file Config) Config {
 	result.ShallowDirs = append(result.ShallowDirs, file.ShallowDirs...)
 	result.KernelParams = append(result.KernelParams, file.KernelParams...)
 	result.DisabledScanners = append(result.DisabledScanners, file.DisabledScanners...)
+	result.HashPaths = append(result.HashPaths, file.HashPaths...)
+	result.Directories = append(result.Directories, file.Directories...)
+	result.AllowedSetuid = append(result.AllowedSetuid, file.AllowedSetuid...)
+	result.Units = append(result.Units, file.Units...)
+	result.ExcludedUnits = append(result.ExcludedUnits, file.ExcludedUnits...)
+	result.UnitProperties = append(result.UnitProperties, file.UnitProperties...)
 
 	// ShallowDepth from file overrides base if set
 	if file.ShallowDepth > 0 {
 		result.ShallowDepth = file.ShallowDepth
 	}
+	result.SymlinkTargets = result.SymlinkTargets || file.SymlinkTargets
+	result.Capabilities = result.Capabilities || file.Capabilities
+	if file.HashMaxSize > 0 {
+		result.HashMaxSize = file.HashMaxSize
+	}
 
 	return result
 }
@@ -188,54 +289,95 @@ func removeItems(slice []string, itemsToRemove []string) []string {
 // - */.bash_history matches .bash_history file anywhere
 func (c *Config) IsPathExcluded(path string) bool {
 	for _, pattern := range c.Paths {
-		// Handle patterns that match anywhere in the path (starting with *)
-		if strings.HasPrefix(pattern, "*") {
-			// Pattern like *.pyc - match file extension
-			if strings.HasPrefix(pattern, "*.") {
-				suffix := strings.TrimPrefix(pattern, "*")
-				if strings.HasSuffix(path, suffix) {
-					return true
-				}
-				continue
-			}
-			// Pattern like */__pycache__/* or */.cache/* - match directory component anywhere
-			// Pattern like */.bash_history - match file anywhere
-			if strings.HasPrefix(pattern, "*/") {
-				// Get the part after */
-				rest := strings.TrimPrefix(pattern, "*/")
-
-				if strings.HasSuffix(pattern, "/*") {
-					// Directory pattern: */.cache/* should match /.cache/ anywhere
-					dirName := strings.TrimSuffix(rest, "/*")
-					if strings.Contains(path, "/"+dirName+"/") {
-						return true
-					}
-				} else {
-					// File pattern: */.bash_history should match /.bash_history at end
-					if strings.HasSuffix(path, "/"+rest) {
-						return true
-					}
-				}
-				continue
-			}
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldHash checks if the content of a file should be hashed: its path must
+// match one of HashPaths (with the same pattern rules as IsPathExcluded) and
+// its size must not exceed HashMaxSize.
+func (c *Config) ShouldHash(path string, size int64) bool {
+	maxSize := c.HashMaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultHashMaxSize
+	}
+	if size > maxSize {
+		return false
+	}
+	for _, pattern := range c.HashPaths {
+		if matchPath(pattern, path) {
+			return true
 		}
+	}
+	return false
+}
 
-		matched, err := filepath.Match(pattern, path)
-		if err != nil {
-			// Invalid pattern, skip
-			continue
+// ShouldRecordDirectory checks if a directory should be recorded as a file
+// resource: its path must match one of Directories, with the same pattern
+// rules as IsPathExcluded.
+func (c *Config) ShouldRecordDirectory(path string) bool {
+	for _, pattern := range c.Directories {
+		if matchPath(pattern, path) {
+			return true
 		}
-		if matched {
+	}
+	return false
+}
+
+// IsSetuidAllowed checks if a setuid or setgid binary is on the allow-list,
+// with the same pattern rules as IsPathExcluded
+func (c *Config) IsSetuidAllowed(path string) bool {
+	for _, pattern := range c.AllowedSetuid {
+		if matchPath(pattern, path) {
 			return true
 		}
+	}
+	return false
+}
 
-		// Also check if path is under a directory pattern
-		// e.g., /proc/* should match /proc/cpuinfo
-		if strings.HasSuffix(pattern, "/*") {
-			dir := strings.TrimSuffix(pattern, "/*")
-			if strings.HasPrefix(path, dir+"/") {
-				return true
+// matchPath checks if a path matches a single path pattern
+func matchPath(pattern, path string) bool {
+	// Handle patterns that match anywhere in the path (starting with *)
+	if strings.HasPrefix(pattern, "*") {
+		// Pattern like *.pyc - match file extension
+		if strings.HasPrefix(pattern, "*.") {
+			suffix := strings.TrimPrefix(pattern, "*")
+			return strings.HasSuffix(path, suffix)
+		}
+		// Pattern like */__pycache__/* or */.cache/* - match directory component anywhere
+		// Pattern like */.bash_history - match file anywhere
+		if strings.HasPrefix(pattern, "*/") {
+			// Get the part after */
+			rest := strings.TrimPrefix(pattern, "*/")
+
+			if strings.HasSuffix(pattern, "/*") {
+				// Directory pattern: */.cache/* should match /.cache/ anywhere
+				dirName := strings.TrimSuffix(rest, "/*")
+				return strings.Contains(path, "/"+dirName+"/")
 			}
+			// File pattern: */.bash_history should match /.bash_history at end
+			return strings.HasSuffix(path, "/"+rest)
+		}
+	}
+
+	matched, err := filepath.Match(pattern, path)
+	if err != nil {
+		// Invalid pattern, skip
+		return false
+	}
+	if matched {
+		return true
+	}
+
+	// Also check if path is under a directory pattern
+	// e.g., /proc/* should match /proc/cpuinfo
+	if strings.HasSuffix(pattern, "/*") {
+		dir := strings.TrimSuffix(pattern, "/*")
+		if strings.HasPrefix(path, dir+"/") {
+			return true
 		}
 	}
 	return false
@@ -264,6 +406,17 @@ func (c *Config) IsKernelParamExcluded(param string) bool {
 	return false
 }
 
+// IsUnitExcluded checks if a systemd unit matches any of the ExcludedUnits
+// glob patterns
+func (c *Config) IsUnitExcluded(unit string) bool {
+	for _, pattern := range c.ExcludedUnits {
+		if matched, err := filepath.Match(pattern, unit); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
 // IsScannerDisabled checks if a given scanner type is disabled in the configuration.
 func (c *Config) IsScannerDisabled(scannerType string) bool {
 	for _, disabled := range c.DisabledScanners {
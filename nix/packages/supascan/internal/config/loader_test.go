@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -295,6 +296,159 @@ func TestIsPathExcluded_CacheAndHistory(t *testing.T) {
 	}
 }
 
+func TestShouldHash(t *testing.T) {
+	cfg := &Config{
+		HashPaths: []string{
+			"/etc/postgresql/*",
+			"/usr/local/bin/*",
+			"*.conf",
+		},
+		HashMaxSize: 1024,
+	}
+
+	tests := []struct {
+		path string
+		size int64
+		want bool
+	}{
+		{"/etc/postgresql/pg_hba.conf", 100, true},
+		{"/usr/local/bin/wal-g", 1024, true},
+		{"/usr/local/bin/wal-g", 1025, false},
+		{"/etc/ssh/sshd_config", 100, false},
+		{"/etc/nginx/nginx.conf", 100, true},
+	}
+	for _, tt := range tests {
+		if got := cfg.ShouldHash(tt.path, tt.size); got != tt.want {
+			t.Errorf("ShouldHash(%s, %d) = %v, want %v", tt.path, tt.size, got, tt.want)
+		}
+	}
+
+	// Hashing is off without patterns, and the size bound has a default
+	if (&Config{}).ShouldHash("/etc/passwd", 1) {
+		t.Error("Expected no hashing without HashPaths")
+	}
+	unbounded := &Config{HashPaths: []string{"/data/*"}}
+	if unbounded.ShouldHash("/data/big", DefaultHashMaxSize+1) {
+		t.Error("Expected DefaultHashMaxSize to apply when HashMaxSize is unset")
+	}
+}
+
+func TestIsSetuidAllowed(t *testing.T) {
+	cfg, err := Load("", CLIOptions{AllowedSetuid: []string{"/usr/local/bin/*"}})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"/usr/bin/sudo":            true, // Default allow-list
+		"/usr/local/bin/pg-helper": true, // Added from CLI
+		"/usr/bin/pkexec":          false,
+		"/home/ubuntu/sudo":        false,
+	}
+	for path, want := range tests {
+		if got := cfg.IsSetuidAllowed(path); got != want {
+			t.Errorf("IsSetuidAllowed(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestLoad_Units(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(configFile, []byte("units:\n  - wal-g.service\nunitProperties:\n  - TimeoutStopSec\n"), 0644)
+
+	cfg, err := Load(configFile, CLIOptions{Units: []string{"custom.service"}, UnitProperties: []string{"Nice"}})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Units and properties from the file and CLI are added to the defaults
+	for _, unit := range []string{"postgresql.service", "wal-g.service", "custom.service"} {
+		if !slices.Contains(cfg.Units, unit) {
+			t.Errorf("Expected unit %s in %v", unit, cfg.Units)
+		}
+	}
+	for _, property := range []string{"ExecStart", "TimeoutStopSec", "Nice"} {
+		if !slices.Contains(cfg.UnitProperties, property) {
+			t.Errorf("Expected property %s in %v", property, cfg.UnitProperties)
+		}
+	}
+}
+
+func TestIsUnitExcluded(t *testing.T) {
+	cfg, err := Load("", CLIOptions{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"dev-sda1.device":          true,
+		"session-4.scope":          true,
+		"user@1000.service":        true,
+		"run-user-1000.mount":      true,
+		"postgresql.service":       false,
+		"logrotate.timer":          false,
+		"systemd-journald.socket":  false,
+		"var-lib-postgresql.mount": false,
+	}
+	for unit, want := range tests {
+		if got := cfg.IsUnitExcluded(unit); got != want {
+			t.Errorf("IsUnitExcluded(%s) = %v, want %v", unit, got, want)
+		}
+	}
+}
+
+func TestShouldRecordDirectory(t *testing.T) {
+	cfg := &Config{Directories: []string{"/data/*", "/etc/ssl/private", "*/.ssh"}}
+
+	tests := map[string]bool{
+		"/data":             false,
+		"/data/pgdata":      true,
+		"/data/pgdata/base": true,
+		"/etc/ssl/private":  true,
+		"/etc/ssl":          false,
+		"/home/ubuntu/.ssh": true,
+	}
+	for path, want := range tests {
+		if got := cfg.ShouldRecordDirectory(path); got != want {
+			t.Errorf("ShouldRecordDirectory(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	all := &Config{Directories: []string{"/*"}}
+	for _, path := range []string{"/", "/etc", "/var/lib/postgresql"} {
+		if !all.ShouldRecordDirectory(path) {
+			t.Errorf("Expected /* to select %s", path)
+		}
+	}
+}
+
+func TestLoad_HashPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	configContent := `
+hashPaths:
+  - /etc/postgresql/*
+hashMaxSize: 4096
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	cfg, err := Load(configPath, CLIOptions{HashPaths: []string{"/usr/local/bin/*"}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []string{"/etc/postgresql/*", "/usr/local/bin/*"}
+	if len(cfg.HashPaths) != len(want) || cfg.HashPaths[0] != want[0] || cfg.HashPaths[1] != want[1] {
+		t.Errorf("HashPaths = %v, want %v", cfg.HashPaths, want)
+	}
+	if cfg.HashMaxSize != 4096 {
+		t.Errorf("HashMaxSize = %d, want 4096", cfg.HashMaxSize)
+	}
+}
+
 // Helper function to check if a slice contains a string
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -0,0 +1,248 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// ChangeKind describes how a resource differs between two baselines
+type ChangeKind string
+
+const (
+	// Added resources exist only in the new baseline
+	Added ChangeKind = "added"
+	// Removed resources exist only in the old baseline
+	Removed ChangeKind = "removed"
+	// Changed resources exist in both baselines with different attributes
+	Changed ChangeKind = "changed"
+)
+
+// Options controls how baselines are compared
+type Options struct {
+	// IgnoreUnset skips attributes the old baseline leaves unset, so a
+	// partial baseline (e.g. one listing only file modes) is not reported
+	// as changed for every attribute it does not mention
+	IgnoreUnset bool
+
+	// AbsentMatchesMissing treats resources the old baseline expects to be
+	// absent (exists: false, installed: false) as unchanged when the new
+	// baseline does not contain them
+	AbsentMatchesMissing bool
+
+	// Types limits the comparison to these resource types (default: all)
+	Types []string
+}
+
+// AttributeChange is a single differing attribute of a resource
+type AttributeChange struct {
+	Attribute string `json:"attribute"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+}
+
+// ResourceChange is a resource that differs between two baselines
+type ResourceChange struct {
+	Type       string            `json:"type"`
+	Key        string            `json:"key"`
+	Kind       ChangeKind        `json:"kind"`
+	Attributes []AttributeChange `json:"attributes,omitempty"` // Only set for changed resources
+}
+
+// TypeSummary counts the changes for one resource type
+type TypeSummary struct {
+	Type    string `json:"type"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Changed int    `json:"changed"`
+}
+
+// Report holds the result of comparing two baselines
+type Report struct {
+	Old     string           `json:"old"`
+	New     string           `json:"new"`
+	Summary []TypeSummary    `json:"summary"`
+	Changes []ResourceChange `json:"changes"`
+}
+
+// HasChanges reports whether the baselines differ
+func (r *Report) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+// Compare reports the resources that were added, removed or changed going
+// from the old baseline to the new one
+func Compare(old, new *spec.Baseline, opts Options) *Report {
+	report := &Report{Changes: []ResourceChange{}}
+
+	types := opts.Types
+	if len(types) == 0 {
+		types = spec.ResourceTypes
+	}
+
+	for _, resourceType := range types {
+		oldResources := old.Resources(resourceType)
+		newResources := new.Resources(resourceType)
+		summary := TypeSummary{Type: resourceType}
+
+		for _, key := range unionKeys(oldResources, newResources) {
+			oldSpec, inOld := oldResources[key]
+			newSpec, inNew := newResources[key]
+
+			switch {
+			case !inOld:
+				summary.Added++
+				report.Changes = append(report.Changes, ResourceChange{Type: resourceType, Key: key, Kind: Added})
+			case !inNew:
+				if opts.AbsentMatchesMissing && expectsAbsent(oldSpec) {
+					continue
+				}
+				summary.Removed++
+				report.Changes = append(report.Changes, ResourceChange{Type: resourceType, Key: key, Kind: Removed})
+			default:
+				attrs := compareAttributes(Attributes(oldSpec), Attributes(newSpec), opts.IgnoreUnset)
+				if len(attrs) > 0 {
+					summary.Changed++
+					report.Changes = append(report.Changes, ResourceChange{
+						Type:       resourceType,
+						Key:        key,
+						Kind:       Changed,
+						Attributes: attrs,
+					})
+				}
+			}
+		}
+
+		if summary.Added+summary.Removed+summary.Changed > 0 {
+			report.Summary = append(report.Summary, summary)
+		}
+	}
+
+	return report
+}
+
+// expectsAbsent reports whether a spec asserts that its resource does not exist
+func expectsAbsent(s interface{}) bool {
+	attrs := Attributes(s)
+	for _, attr := range []string{"exists", "installed"} {
+		if attrs[attr] == "false" {
+			return true
+		}
+	}
+	return false
+}
+
+// compareAttributes returns the attributes that differ, in sorted order
+func compareAttributes(old, new map[string]string, ignoreUnset bool) []AttributeChange {
+	var changes []AttributeChange
+
+	for _, attr := range unionKeys(old, new) {
+		oldValue, inOld := old[attr]
+		newValue := new[attr]
+
+		if !inOld && ignoreUnset {
+			continue
+		}
+		if oldValue != newValue {
+			changes = append(changes, AttributeChange{Attribute: attr, Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes
+}
+
+// Attributes flattens a spec into its attributes, keyed by the same names
+// used in spec files. Attributes that a spec file would omit (omitempty
+// fields with zero values) are left out, so an unset attribute can be told
+// apart from one explicitly set to false. Map entries become one attribute
+// each.
+func Attributes(s interface{}) map[string]string {
+	attrs := make(map[string]string)
+
+	v := reflect.ValueOf(s)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return attrs
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("yaml")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		value := v.Field(i)
+		if strings.Contains(options, "omitempty") && value.IsZero() {
+			continue
+		}
+		// A list left out of a spec is unset, unlike an empty one
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			continue
+		}
+		// Maps are compared per entry, e.g. "properties.ExecStart"
+		if value.Kind() == reflect.Map {
+			iter := value.MapRange()
+			for iter.Next() {
+				attrs[name+"."+fmt.Sprint(iter.Key().Interface())] = formatValue(iter.Value())
+			}
+			continue
+		}
+
+		attrs[name] = formatValue(value)
+	}
+
+	return attrs
+}
+
+// formatValue renders an attribute value. Lists are sorted because the
+// scanners do not guarantee their order.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items[i] = formatValue(v.Index(i))
+		}
+		sort.Strings(items)
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.Struct, reflect.Map:
+		return fmt.Sprintf("%v", v.Interface())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// unionKeys returns the sorted union of the keys of two maps
+func unionKeys[T any](a, b map[string]T) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		seen[k] = true
+	}
+	for k := range b {
+		seen[k] = true
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
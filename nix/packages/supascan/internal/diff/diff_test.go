@@ -0,0 +1,213 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+func testBaselines() (*spec.Baseline, *spec.Baseline) {
+	yes, no := true, false
+
+	old := spec.NewBaseline()
+	old.Add(spec.FileSpec{Path: "/etc/passwd", Exists: true, Mode: "0644", Owner: "root", Filetype: "file"})
+	old.Add(spec.FileSpec{Path: "/etc/shadow", Exists: true, Mode: "0640"})
+	old.Add(spec.FileSpec{Path: "/etc/removed.conf", Exists: true})
+	old.Add(spec.PackageSpec{Name: "bash", Installed: true, Versions: []string{"5.1"}})
+	old.Add(spec.ServiceSpec{Name: "cron", Enabled: &yes, Running: &yes})
+
+	new := spec.NewBaseline()
+	new.Add(spec.FileSpec{Path: "/etc/passwd", Exists: true, Mode: "0644", Owner: "root", Filetype: "file"})
+	new.Add(spec.FileSpec{Path: "/etc/shadow", Exists: true, Mode: "0600", Owner: "root"})
+	new.Add(spec.FileSpec{Path: "/etc/added.conf", Exists: true})
+	new.Add(spec.PackageSpec{Name: "bash", Installed: true, Versions: []string{"5.2"}})
+	new.Add(spec.ServiceSpec{Name: "cron", Enabled: &no, Running: &yes})
+
+	return old, new
+}
+
+func TestCompare(t *testing.T) {
+	old, new := testBaselines()
+
+	report := Compare(old, new, Options{})
+
+	if len(report.Changes) != 5 {
+		t.Fatalf("Expected 5 changes, got %d: %+v", len(report.Changes), report.Changes)
+	}
+
+	// Changes are ordered by type, then key
+	want := []struct {
+		typ  string
+		key  string
+		kind ChangeKind
+	}{
+		{"file", "/etc/added.conf", Added},
+		{"file", "/etc/removed.conf", Removed},
+		{"file", "/etc/shadow", Changed},
+		{"package", "bash", Changed},
+		{"service", "cron", Changed},
+	}
+	for i, w := range want {
+		c := report.Changes[i]
+		if c.Type != w.typ || c.Key != w.key || c.Kind != w.kind {
+			t.Errorf("Change %d = %s %s %s, want %s %s %s", i, c.Type, c.Key, c.Kind, w.typ, w.key, w.kind)
+		}
+	}
+
+	shadow := report.Changes[2]
+	if len(shadow.Attributes) != 2 {
+		t.Fatalf("Expected mode and owner changes, got %+v", shadow.Attributes)
+	}
+	if shadow.Attributes[0] != (AttributeChange{Attribute: "mode", Old: "0640", New: "0600"}) {
+		t.Errorf("Unexpected mode change: %+v", shadow.Attributes[0])
+	}
+	if shadow.Attributes[1] != (AttributeChange{Attribute: "owner", Old: "", New: "root"}) {
+		t.Errorf("Unexpected owner change: %+v", shadow.Attributes[1])
+	}
+
+	if report.Summary[0] != (TypeSummary{Type: "file", Added: 1, Removed: 1, Changed: 1}) {
+		t.Errorf("Unexpected file summary: %+v", report.Summary[0])
+	}
+}
+
+func TestCompare_IgnoreUnset(t *testing.T) {
+	old, new := testBaselines()
+
+	report := Compare(old, new, Options{IgnoreUnset: true, Types: []string{"file"}})
+
+	for _, c := range report.Changes {
+		if c.Key != "/etc/shadow" {
+			continue
+		}
+		// Owner is unset in the old baseline and should be ignored
+		if len(c.Attributes) != 1 || c.Attributes[0].Attribute != "mode" {
+			t.Errorf("Expected only mode change, got %+v", c.Attributes)
+		}
+	}
+
+	for _, c := range report.Changes {
+		if c.Type != "file" {
+			t.Errorf("Expected only file changes, got %s", c.Type)
+		}
+	}
+}
+
+func TestCompare_NoChanges(t *testing.T) {
+	old, _ := testBaselines()
+
+	report := Compare(old, old, Options{})
+	if report.HasChanges() {
+		t.Errorf("Expected no changes, got %+v", report.Changes)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	yes, no := true, false
+	attrs := Attributes(spec.ServiceSpec{Name: "cron", Enabled: &no, Running: &yes})
+
+	// Set optional booleans are present, the key field never is
+	if attrs["enabled"] != "false" || attrs["running"] != "true" {
+		t.Errorf("Unexpected service attributes: %v", attrs)
+	}
+	if len(attrs) != 2 {
+		t.Errorf("Expected 2 attributes, got %v", attrs)
+	}
+
+	attrs = Attributes(spec.ServiceSpec{Name: "apt-daily", Enablement: "masked"})
+	if attrs["enablement"] != "masked" {
+		t.Errorf("Expected masked enablement, got %v", attrs)
+	}
+
+	attrs = Attributes(spec.MountSpec{Path: "/", Exists: true, Opts: []string{"rw", "nosuid"}})
+	if attrs["opts"] != "[nosuid, rw]" {
+		t.Errorf("Expected sorted opts, got %q", attrs["opts"])
+	}
+	if _, ok := attrs["source"]; ok {
+		t.Error("Unset omitempty attributes should be left out")
+	}
+	// Optional attributes are pointers, rendered by value when set
+	exists := false
+	attrs = Attributes(spec.FileSpec{Path: "/etc/alternatives/psql", Exists: true, LinkedTo: "/usr/bin/psql", TargetExists: &exists})
+	if attrs["linked-to"] != "/usr/bin/psql" || attrs["target-exists"] != "false" {
+		t.Errorf("Unexpected symlink attributes: %v", attrs)
+	}
+	if _, ok := Attributes(spec.FileSpec{Path: "/etc/passwd", Exists: true})["target-exists"]; ok {
+		t.Error("Unset target-exists should be left out")
+	}
+
+	// Group members are always written, but a spec without them leaves them unset
+	if attrs = Attributes(spec.GroupSpec{Name: "sudo", Exists: true, Members: []string{}}); attrs["members"] != "[]" {
+		t.Errorf("Expected empty members, got %v", attrs)
+	}
+	if _, ok := Attributes(spec.GroupSpec{Name: "sudo", Exists: true})["members"]; ok {
+		t.Error("Nil members should be left out")
+	}
+
+	// Unit properties are compared one by one
+	attrs = Attributes(spec.SystemdUnitSpec{Name: "postgresql.service", Properties: map[string]string{"User": "postgres", "ProtectSystem": "full"}, DropIns: []string{}})
+	if attrs["properties.User"] != "postgres" || attrs["properties.ProtectSystem"] != "full" || attrs["drop-ins"] != "[]" {
+		t.Errorf("Unexpected unit attributes: %v", attrs)
+	}
+	if _, ok := attrs["properties"]; ok {
+		t.Error("Properties should be flattened")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	old, new := testBaselines()
+	report := Compare(old, new, Options{})
+	report.Old = "old.yml"
+	report.New = "new.yml"
+
+	var text bytes.Buffer
+	if err := Write(&text, report, FormatText); err != nil {
+		t.Fatalf("Write text failed: %v", err)
+	}
+	for _, want := range []string{"+ /etc/added.conf", "- /etc/removed.conf", "~ /etc/shadow", "mode: 0640 -> 0600"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Text output missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var md bytes.Buffer
+	if err := Write(&md, report, FormatMarkdown); err != nil {
+		t.Fatalf("Write markdown failed: %v", err)
+	}
+	if !strings.Contains(md.String(), "| changed | `/etc/shadow` | mode | 0640 | 0600 |") {
+		t.Errorf("Markdown output missing mode row:\n%s", md.String())
+	}
+
+	var js bytes.Buffer
+	if err := Write(&js, report, FormatJSON); err != nil {
+		t.Fatalf("Write json failed: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(decoded.Changes) != len(report.Changes) {
+		t.Errorf("Expected %d changes in JSON, got %d", len(report.Changes), len(decoded.Changes))
+	}
+
+	if err := Write(&text, report, "xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestCompare_AbsentMatchesMissing(t *testing.T) {
+	old := spec.NewBaseline()
+	old.Add(spec.PackageSpec{Name: "telnetd", Installed: false})
+	old.Add(spec.FileSpec{Path: "/etc/hosts.equiv", Exists: false})
+	old.Add(spec.PackageSpec{Name: "bash", Installed: true})
+
+	new := spec.NewBaseline()
+
+	report := Compare(old, new, Options{AbsentMatchesMissing: true})
+
+	if len(report.Changes) != 1 || report.Changes[0].Key != "bash" || report.Changes[0].Kind != Removed {
+		t.Errorf("Expected only bash to be removed, got %+v", report.Changes)
+	}
+}
@@ -0,0 +1,128 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Output formats supported by Write
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Write renders a report in the given format
+func Write(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatText:
+		return writeText(w, report)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatMarkdown:
+		return writeMarkdown(w, report)
+	default:
+		return fmt.Errorf("invalid output format: %s (must be text, json or markdown)", format)
+	}
+}
+
+// kindSymbol returns the marker used for a change in text output
+func kindSymbol(kind ChangeKind) string {
+	switch kind {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func writeText(w io.Writer, report *Report) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Comparing %s -> %s\n\n", report.Old, report.New)
+
+	if !report.HasChanges() {
+		b.WriteString("No differences found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("Summary:\n")
+	for _, s := range report.Summary {
+		fmt.Fprintf(&b, "  %-14s %d added, %d removed, %d changed\n", s.Type+":", s.Added, s.Removed, s.Changed)
+	}
+
+	currentType := ""
+	for _, c := range report.Changes {
+		if c.Type != currentType {
+			currentType = c.Type
+			fmt.Fprintf(&b, "\n%s:\n", currentType)
+		}
+		fmt.Fprintf(&b, "  %s %s\n", kindSymbol(c.Kind), c.Key)
+		for _, a := range c.Attributes {
+			fmt.Fprintf(&b, "      %s: %s -> %s\n", a.Attribute, displayValue(a.Old), displayValue(a.New))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdown(w io.Writer, report *Report) error {
+	var b strings.Builder
+
+	b.WriteString("# Baseline diff\n\n")
+	fmt.Fprintf(&b, "`%s` → `%s`\n\n", report.Old, report.New)
+
+	if !report.HasChanges() {
+		b.WriteString("No differences found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("| Type | Added | Removed | Changed |\n")
+	b.WriteString("|------|------:|--------:|--------:|\n")
+	for _, s := range report.Summary {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d |\n", s.Type, s.Added, s.Removed, s.Changed)
+	}
+
+	currentType := ""
+	for _, c := range report.Changes {
+		if c.Type != currentType {
+			currentType = c.Type
+			fmt.Fprintf(&b, "\n## %s\n\n", currentType)
+			b.WriteString("| Change | Resource | Attribute | Old | New |\n")
+			b.WriteString("|--------|----------|-----------|-----|-----|\n")
+		}
+		if len(c.Attributes) == 0 {
+			fmt.Fprintf(&b, "| %s | `%s` | | | |\n", c.Kind, markdownEscape(c.Key))
+			continue
+		}
+		for _, a := range c.Attributes {
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |\n", c.Kind, markdownEscape(c.Key), a.Attribute,
+				markdownEscape(displayValue(a.Old)), markdownEscape(displayValue(a.New)))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// displayValue shows unset attributes explicitly
+func displayValue(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
+
+// markdownEscape keeps values from breaking table cells
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,291 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// paxXattrPrefix prefixes the PAX records holding extended attributes
+const paxXattrPrefix = "SCHILY.xattr."
+
+// maxSymlinkHops bounds symlink resolution, as ELOOP does on Linux
+const maxSymlinkHops = 40
+
+// node is one entry of the flattened image filesystem
+type node struct {
+	name     string
+	header   *tar.Header // Ownership and metadata; Sys() of the node's FileInfo
+	mode     fs.FileMode
+	size     int64
+	modTime  time.Time
+	linkname string
+	data     []byte // File content, only kept for retained paths
+	retained bool
+	sha256   string // Digest computed while applying the layers, if selected
+	children map[string]*node
+	layer    int // Index of the layer that last wrote the node
+}
+
+func (n *node) isDir() bool {
+	return n.mode.IsDir()
+}
+
+func (n *node) isSymlink() bool {
+	return n.mode&fs.ModeSymlink != 0
+}
+
+// newDir creates a directory node, used for the root and for parents that
+// a layer does not list explicitly
+func newDir(name string, layer int) *node {
+	return &node{
+		name:     name,
+		header:   &tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755},
+		mode:     fs.ModeDir | 0755,
+		children: make(map[string]*node),
+		layer:    layer,
+	}
+}
+
+// FS is a read-only, in-memory view of an image's flattened root
+// filesystem. It implements fs.FS, fs.ReadDirFS, fs.StatFS, ReadLink and Getxattr. Paths are
+// resolved within the image, including absolute symlink targets. Directory
+// entries describe the entry itself (like Lstat), and FileInfo.Sys returns
+// the entry's *tar.Header so ownership can be read from it.
+//
+// Only file content under retained paths is kept; reading any other file
+// fails with ErrContentNotRetained. SHA256 also covers the files selected
+// by Options.Digest.
+type FS struct {
+	root   *node
+	digest func(path string, size int64) bool
+}
+
+// ErrContentNotRetained is returned when reading a file whose content was
+// not kept while applying the image layers
+var ErrContentNotRetained = errors.New("file content not retained from image")
+
+// Open opens the named file, following symlinks
+func (f *FS) Open(name string) (fs.File, error) {
+	n, err := f.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return &openFile{node: n, name: name}, nil
+}
+
+// Stat returns information about the named file, following symlinks
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	n, err := f.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{n}, nil
+}
+
+// ReadDir reads the named directory, returning its entries sorted by name
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := f.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !n.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return n.entries(), nil
+}
+
+// ReadLink returns the target of the named symlink
+func (f *FS) ReadLink(name string) (string, error) {
+	n, err := f.resolve("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if !n.isSymlink() {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return n.linkname, nil
+}
+
+// Getxattr returns an extended attribute of the named file, from the PAX
+// records of its layer entry, following symlinks. A missing attribute
+// yields nil.
+func (f *FS) Getxattr(name, attr string) ([]byte, error) {
+	n, err := f.resolve("getxattr", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := n.header.PAXRecords[paxXattrPrefix+attr]; ok {
+		return []byte(value), nil
+	}
+	return nil, nil
+}
+
+// SHA256 returns the hex-encoded sha256 of the named file's content,
+// following symlinks
+func (f *FS) SHA256(name string) (string, error) {
+	n, err := f.resolve("sha256", name, true)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case n.isDir():
+		return "", &fs.PathError{Op: "sha256", Path: name, Err: errors.New("is a directory")}
+	case n.sha256 != "":
+		return n.sha256, nil
+	case n.retained:
+		sum := sha256.Sum256(n.data)
+		return hex.EncodeToString(sum[:]), nil
+	default:
+		return "", &fs.PathError{Op: "sha256", Path: name, Err: ErrContentNotRetained}
+	}
+}
+
+// entries returns the directory's children sorted by name
+func (n *node) entries() []fs.DirEntry {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]fs.DirEntry, len(names))
+	for i, name := range names {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{n.children[name]})
+	}
+	return entries
+}
+
+// resolve looks up a path, following symlinks in every component and, if
+// followLast is set, in the last one
+func (f *FS) resolve(op, name string, followLast bool) (*node, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	var parts []string
+	if name != "." {
+		parts = strings.Split(name, "/")
+	}
+
+	stack := []*node{f.root}
+	hops := 0
+	for i := 0; i < len(parts); i++ {
+		cur := stack[len(stack)-1]
+
+		switch parts[i] {
+		case "", ".":
+			continue
+		case "..":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		if !cur.isDir() {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		child, ok := cur.children[parts[i]]
+		if !ok {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+
+		if child.isSymlink() && (i < len(parts)-1 || followLast) {
+			hops++
+			if hops > maxSymlinkHops {
+				return nil, &fs.PathError{Op: op, Path: name, Err: errors.New("too many levels of symbolic links")}
+			}
+			// Continue with the link target followed by the remaining parts
+			target := child.linkname
+			if path.IsAbs(target) {
+				stack = stack[:1]
+			}
+			parts = append(strings.Split(strings.TrimPrefix(target, "/"), "/"), parts[i+1:]...)
+			i = -1
+			continue
+		}
+
+		stack = append(stack, child)
+	}
+
+	return stack[len(stack)-1], nil
+}
+
+// fileInfo implements fs.FileInfo for a node
+type fileInfo struct {
+	n *node
+}
+
+func (fi fileInfo) Name() string {
+	if fi.n.name == "" {
+		return "."
+	}
+	return fi.n.name
+}
+func (fi fileInfo) Size() int64        { return fi.n.size }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.n.mode }
+func (fi fileInfo) ModTime() time.Time { return fi.n.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.n.isDir() }
+func (fi fileInfo) Sys() interface{}   { return fi.n.header }
+
+// openFile implements fs.File and fs.ReadDirFile for a node
+type openFile struct {
+	node    *node
+	name    string
+	reader  *bytes.Reader
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) {
+	return fileInfo{f.node}, nil
+}
+
+func (f *openFile) Read(b []byte) (int, error) {
+	if f.node.isDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("is a directory")}
+	}
+	if !f.node.retained {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: ErrContentNotRetained}
+	}
+	if f.reader == nil {
+		f.reader = bytes.NewReader(f.node.data)
+	}
+	return f.reader.Read(b)
+}
+
+func (f *openFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !f.node.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not a directory")}
+	}
+	if f.entries == nil {
+		f.entries = f.node.entries()
+	}
+
+	remaining := f.entries[f.offset:]
+	if count <= 0 {
+		f.offset = len(f.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remaining) {
+		count = len(remaining)
+	}
+	f.offset += count
+	return remaining[:count], nil
+}
+
+func (f *openFile) Close() error {
+	return nil
+}
@@ -0,0 +1,428 @@
+// Package image reads container images saved as tarballs (docker save or an
+// OCI image layout) into a virtual root filesystem that the scanners can walk
+// without extracting the image to disk or running a container runtime.
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// Whiteout markers used by image layers to delete lower-layer entries
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// maxRetainedSize bounds the content kept in memory for a single file
+const maxRetainedSize = 16 << 20
+
+// retainedPrefixes lists the paths whose file content is kept, for the
+// scanners that parse configuration rather than only reading metadata
+var retainedPrefixes = []string{
+	"etc/",
+	"var/lib/dpkg/status",
+	"var/spool/cron/",
+}
+
+// retained reports whether the content of a file is kept in memory
+func retained(name string, size int64) bool {
+	if size > maxRetainedSize {
+		return false
+	}
+	for _, prefix := range retainedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveEntry locates a file within the image tarball
+type archiveEntry struct {
+	offset   int64
+	size     int64
+	linkname string // Set for symlinks, resolved relative to the entry's directory
+}
+
+// archive gives random access to the files of an uncompressed tarball
+type archive struct {
+	file    *os.File
+	entries map[string]archiveEntry
+}
+
+// openArchive indexes the entries of a tarball by name
+func openArchive(tarball string) (*archive, error) {
+	file, err := os.Open(tarball)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+
+	a := &archive{file: file, entries: make(map[string]archiveEntry)}
+	tr := tar.NewReader(file)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read image tarball %s (compressed archives must be decompressed first): %w", tarball, err)
+		}
+
+		name := cleanName(hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			// tar.Reader reads the archive without buffering, so the file
+			// position is now at the start of the entry's content
+			offset, err := file.Seek(0, io.SeekCurrent)
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to index image tarball: %w", err)
+			}
+			a.entries[name] = archiveEntry{offset: offset, size: hdr.Size}
+		case tar.TypeSymlink:
+			a.entries[name] = archiveEntry{linkname: path.Join(path.Dir(name), hdr.Linkname)}
+		}
+	}
+
+	return a, nil
+}
+
+// open returns a reader for a file in the archive, following symlinks
+func (a *archive) open(name string) (io.Reader, error) {
+	name = cleanName(name)
+	for hops := 0; hops < maxSymlinkHops; hops++ {
+		entry, ok := a.entries[name]
+		if !ok {
+			return nil, fmt.Errorf("%s not found in image tarball", name)
+		}
+		if entry.linkname == "" {
+			return io.NewSectionReader(a.file, entry.offset, entry.size), nil
+		}
+		name = cleanName(entry.linkname)
+	}
+	return nil, fmt.Errorf("%s: too many levels of symbolic links", name)
+}
+
+// readJSON decodes a JSON file from the archive
+func (a *archive) readJSON(name string, v interface{}) error {
+	r, err := a.open(name)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func (a *archive) has(name string) bool {
+	_, ok := a.entries[cleanName(name)]
+	return ok
+}
+
+// dockerManifest is an entry of the manifest.json written by docker save
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// ociDescriptor references a blob in an OCI image layout
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform,omitempty"`
+}
+
+// ociIndex is an OCI image index (index.json or a multi-platform index blob)
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// ociManifest is an OCI image manifest
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+const ociIndexMediaType = "application/vnd.oci.image.index.v1+json"
+
+// Options configures how an image's layers are applied
+type Options struct {
+	// Digest selects the regular files whose sha256 is computed while the
+	// layers are applied, given the file's absolute path in the image and
+	// its size. Digests of retained files are always available.
+	Digest func(path string, size int64) bool
+}
+
+// Open reads an image tarball written by docker save or containing an OCI
+// image layout, and applies its layers into an in-memory filesystem. When
+// the tarball holds several images, the first one is used; for a
+// multi-platform OCI index, the manifest for the host architecture is
+// preferred.
+func Open(path string, opts Options) (*FS, error) {
+	a, err := openArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer a.file.Close()
+
+	layers, err := a.layers()
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("image %s has no layers", path)
+	}
+
+	fsys := &FS{root: newDir("", 0), digest: opts.Digest}
+	for i, layer := range layers {
+		r, err := a.open(layer)
+		if err != nil {
+			return nil, err
+		}
+		if err := fsys.applyLayer(r, i+1); err != nil {
+			return nil, fmt.Errorf("failed to apply layer %s: %w", layer, err)
+		}
+	}
+
+	return fsys, nil
+}
+
+// layers returns the archive paths of the image's layers, lowest first
+func (a *archive) layers() ([]string, error) {
+	// docker save, including the OCI-compatible format of newer releases
+	if a.has("manifest.json") {
+		var manifests []dockerManifest
+		if err := a.readJSON("manifest.json", &manifests); err != nil {
+			return nil, err
+		}
+		if len(manifests) == 0 {
+			return nil, errors.New("manifest.json lists no images")
+		}
+		return manifests[0].Layers, nil
+	}
+
+	if a.has("index.json") {
+		var index ociIndex
+		if err := a.readJSON("index.json", &index); err != nil {
+			return nil, err
+		}
+		manifest, err := a.resolveManifest(index, 0)
+		if err != nil {
+			return nil, err
+		}
+		layers := make([]string, len(manifest.Layers))
+		for i, layer := range manifest.Layers {
+			layers[i] = blobPath(layer.Digest)
+		}
+		return layers, nil
+	}
+
+	return nil, errors.New("not an image tarball: no manifest.json or index.json")
+}
+
+// resolveManifest picks an image manifest from an index, descending into
+// nested indexes for multi-platform images
+func (a *archive) resolveManifest(index ociIndex, depth int) (*ociManifest, error) {
+	if len(index.Manifests) == 0 {
+		return nil, errors.New("OCI index lists no manifests")
+	}
+	if depth > 4 {
+		return nil, errors.New("OCI indexes nested too deeply")
+	}
+
+	desc := index.Manifests[0]
+	for _, m := range index.Manifests {
+		if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == runtime.GOARCH {
+			desc = m
+			break
+		}
+	}
+
+	if desc.MediaType == ociIndexMediaType {
+		var nested ociIndex
+		if err := a.readJSON(blobPath(desc.Digest), &nested); err != nil {
+			return nil, err
+		}
+		return a.resolveManifest(nested, depth+1)
+	}
+
+	var manifest ociManifest
+	if err := a.readJSON(blobPath(desc.Digest), &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// blobPath maps a digest such as sha256:abc to its OCI layout path
+func blobPath(digest string) string {
+	algorithm, hex, _ := strings.Cut(digest, ":")
+	return path.Join("blobs", algorithm, hex)
+}
+
+// decompress detects gzip-compressed layers; uncompressed layers are
+// returned as is
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return nil, errors.New("zstd-compressed layers are not supported")
+	default:
+		return br, nil
+	}
+}
+
+// applyLayer adds a layer's entries to the filesystem, processing whiteouts
+func (f *FS) applyLayer(r io.Reader, layer int) error {
+	r, err := decompress(r)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := cleanName(hdr.Name)
+		if name == "" {
+			// The root directory itself
+			f.root.setMetadata(hdr, layer)
+			continue
+		}
+
+		dir, base := path.Split(name)
+		parent := f.mkdirAll(strings.TrimSuffix(dir, "/"), layer)
+
+		switch {
+		case base == opaqueWhiteout:
+			// Hide everything lower layers put in the directory
+			parent.pruneLower(layer)
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			delete(parent.children, strings.TrimPrefix(base, whiteoutPrefix))
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if existing, ok := parent.children[base]; ok && existing.isDir() {
+				existing.setMetadata(hdr, layer)
+				continue
+			}
+			n := newDir(base, layer)
+			n.setMetadata(hdr, layer)
+			parent.children[base] = n
+
+		case tar.TypeLink:
+			// Hard links share the target's metadata and content
+			target, err := f.resolve("link", cleanName(hdr.Linkname), false)
+			if err != nil {
+				return fmt.Errorf("hard link %s: %w", name, err)
+			}
+			n := *target
+			n.name = base
+			n.layer = layer
+			parent.children[base] = &n
+
+		default:
+			n := &node{name: base}
+			n.setMetadata(hdr, layer)
+			if hdr.Typeflag == tar.TypeReg && retained(name, hdr.Size) {
+				data, err := io.ReadAll(tr)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", name, err)
+				}
+				n.data = data
+				n.retained = true
+			} else if hdr.Typeflag == tar.TypeReg && f.digest != nil && f.digest("/"+name, hdr.Size) {
+				h := sha256.New()
+				if _, err := io.Copy(h, tr); err != nil {
+					return fmt.Errorf("failed to read %s: %w", name, err)
+				}
+				n.sha256 = hex.EncodeToString(h.Sum(nil))
+			}
+			parent.children[base] = n
+		}
+	}
+}
+
+// pruneLower removes the descendants of a directory that were written by
+// layers below layer
+func (n *node) pruneLower(layer int) {
+	for name, child := range n.children {
+		if child.layer < layer {
+			delete(n.children, name)
+		} else if child.isDir() {
+			child.pruneLower(layer)
+		}
+	}
+}
+
+// setMetadata copies an entry's metadata from its tar header
+func (n *node) setMetadata(hdr *tar.Header, layer int) {
+	info := hdr.FileInfo()
+	n.header = hdr
+	n.mode = info.Mode()
+	n.size = hdr.Size
+	n.modTime = hdr.ModTime
+	n.linkname = hdr.Linkname
+	n.layer = layer
+	if n.isDir() && n.children == nil {
+		n.children = make(map[string]*node)
+	}
+}
+
+// mkdirAll returns the directory at dir, creating missing directories.
+// A non-directory in the way is replaced, as extracting the layer would.
+func (f *FS) mkdirAll(dir string, layer int) *node {
+	cur := f.root
+	if dir == "" {
+		return cur
+	}
+	for _, part := range strings.Split(dir, "/") {
+		child, ok := cur.children[part]
+		if !ok || !child.isDir() {
+			child = newDir(part, layer)
+			cur.children[part] = child
+		}
+		cur = child
+	}
+	return cur
+}
+
+// cleanName normalizes a tar entry name to an fs.FS path without the
+// leading "./" or "/" ("" for the root)
+func cleanName(name string) string {
+	name = path.Clean("/" + name)
+	return strings.TrimPrefix(name, "/")
+}
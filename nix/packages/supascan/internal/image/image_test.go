@@ -0,0 +1,362 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tarEntry describes one entry of a test tarball
+type tarEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	uid      int
+	linkname string
+	content  string
+	xattrs   map[string]string
+}
+
+// buildTar returns a tarball holding the entries
+func buildTar(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     e.mode,
+			Uid:      e.uid,
+			Gid:      e.uid,
+			Linkname: e.linkname,
+			Size:     int64(len(e.content)),
+		}
+		for attr, value := range e.xattrs {
+			if hdr.PAXRecords == nil {
+				hdr.PAXRecords = make(map[string]string)
+			}
+			hdr.PAXRecords["SCHILY.xattr."+attr] = value
+		}
+		if e.typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("Failed to write header %s: %v", e.name, err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatalf("Failed to write %s: %v", e.name, err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tarball: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// testLayers returns a base layer and a gzip-compressed upper layer that
+// exercise whiteouts, opaque directories and links
+func testLayers(t *testing.T) [][]byte {
+	base := buildTar(t, []tarEntry{
+		{name: "./", typeflag: tar.TypeDir, mode: 0755},
+		{name: "etc/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "etc/passwd", typeflag: tar.TypeReg, mode: 0644, content: "root:x:0:0:root:/root:/bin/bash\n"},
+		{name: "etc/motd", typeflag: tar.TypeReg, mode: 0644, content: "hello\n"},
+		{name: "opt/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "opt/app/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "opt/app/old.bin", typeflag: tar.TypeReg, mode: 0755, content: "old"},
+		{name: "opt/app/lib/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "opt/app/lib/old.so", typeflag: tar.TypeReg, mode: 0644, content: "old"},
+		{name: "usr/bin/tool", typeflag: tar.TypeReg, mode: 0755, uid: 1000, content: "binary",
+			xattrs: map[string]string{"security.capability": "\x01\x00\x00\x02\x00\x20\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"}},
+	})
+	upper := buildTar(t, []tarEntry{
+		{name: "etc/.wh.motd", typeflag: tar.TypeReg},
+		{name: "etc/hostname", typeflag: tar.TypeReg, mode: 0600, uid: 101, content: "db\n"},
+		{name: "opt/app/", typeflag: tar.TypeDir, mode: 0750},
+		{name: "opt/app/lib/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "opt/app/.wh..wh..opq", typeflag: tar.TypeReg},
+		{name: "opt/app/new.bin", typeflag: tar.TypeReg, mode: 0755, content: "new"},
+		{name: "usr/bin/tool-link", typeflag: tar.TypeLink, linkname: "usr/bin/tool"},
+		{name: "etc/alternatives", typeflag: tar.TypeSymlink, linkname: "/usr/bin"},
+	})
+	return [][]byte{base, gzipBytes(t, upper)}
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return string(data)
+}
+
+// writeImage writes an outer image tarball to a temp file
+func writeImage(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "image.tar")
+	if err := os.WriteFile(path, buildTar(t, entries), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+	return path
+}
+
+// dockerSaveImage lays the layers out as docker save does
+func dockerSaveImage(t *testing.T, layers [][]byte) string {
+	manifest := []dockerManifest{{Config: "config.json", RepoTags: []string{"test:latest"}}}
+	var entries []tarEntry
+	for i, layer := range layers {
+		name := fmt.Sprintf("layer%d/layer.tar", i)
+		manifest[0].Layers = append(manifest[0].Layers, name)
+		entries = append(entries, tarEntry{name: name, typeflag: tar.TypeReg, mode: 0644, content: string(layer)})
+	}
+	entries = append(entries,
+		tarEntry{name: "config.json", typeflag: tar.TypeReg, mode: 0644, content: "{}"},
+		tarEntry{name: "manifest.json", typeflag: tar.TypeReg, mode: 0644, content: mustJSON(t, manifest)},
+	)
+	return writeImage(t, entries)
+}
+
+// ociImage lays the layers out as an OCI image layout behind a
+// multi-platform index
+func ociImage(t *testing.T, layers [][]byte) string {
+	var entries []tarEntry
+	blob := func(data []byte) string {
+		d := digest(data)
+		entries = append(entries, tarEntry{name: blobPath(d), typeflag: tar.TypeReg, mode: 0644, content: string(data)})
+		return d
+	}
+
+	var manifest ociManifest
+	for _, layer := range layers {
+		manifest.Layers = append(manifest.Layers, ociDescriptor{Digest: blob(layer)})
+	}
+	manifestDigest := blob([]byte(mustJSON(t, manifest)))
+	nested := blob([]byte(mustJSON(t, ociIndex{Manifests: []ociDescriptor{{Digest: manifestDigest}}})))
+
+	index := ociIndex{Manifests: []ociDescriptor{{MediaType: ociIndexMediaType, Digest: nested}}}
+	entries = append(entries,
+		tarEntry{name: "oci-layout", typeflag: tar.TypeReg, mode: 0644, content: `{"imageLayoutVersion":"1.0.0"}`},
+		tarEntry{name: "index.json", typeflag: tar.TypeReg, mode: 0644, content: mustJSON(t, index)},
+	)
+	return writeImage(t, entries)
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		image func(t *testing.T, layers [][]byte) string
+	}{
+		{"docker save", dockerSaveImage},
+		{"OCI layout", ociImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys, err := Open(tt.image(t, testLayers(t)), Options{})
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+
+			var paths []string
+			err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				paths = append(paths, path)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WalkDir() error = %v", err)
+			}
+
+			want := []string{
+				".",
+				"etc",
+				"etc/alternatives",
+				"etc/hostname",
+				"etc/passwd",
+				"opt",
+				"opt/app",
+				"opt/app/lib",
+				"opt/app/new.bin",
+				"usr",
+				"usr/bin",
+				"usr/bin/tool",
+				"usr/bin/tool-link",
+			}
+			if !reflect.DeepEqual(paths, want) {
+				t.Errorf("Paths = %v, want %v", paths, want)
+			}
+
+			passwd, err := fs.ReadFile(fsys, "etc/passwd")
+			if err != nil || string(passwd) != "root:x:0:0:root:/root:/bin/bash\n" {
+				t.Errorf("ReadFile(etc/passwd) = %q, %v", passwd, err)
+			}
+
+			info, err := fs.Stat(fsys, "etc/hostname")
+			if err != nil {
+				t.Fatalf("Stat(etc/hostname) error = %v", err)
+			}
+			if info.Mode() != 0600 {
+				t.Errorf("etc/hostname mode = %v, want 0600", info.Mode())
+			}
+			if hdr, ok := info.Sys().(*tar.Header); !ok || hdr.Uid != 101 {
+				t.Errorf("etc/hostname Sys() = %v, want header with uid 101", info.Sys())
+			}
+
+			info, err = fs.Stat(fsys, "opt/app")
+			if err != nil || info.Mode() != fs.ModeDir|0750 {
+				t.Errorf("Stat(opt/app) = %v, %v, want mode from upper layer", info, err)
+			}
+
+			info, err = fs.Stat(fsys, "usr/bin/tool-link")
+			if err != nil || info.Size() != int64(len("binary")) {
+				t.Errorf("Stat(usr/bin/tool-link) = %v, %v, want hard link to usr/bin/tool", info, err)
+			}
+
+			// Absolute symlinks resolve within the image
+			info, err = fs.Stat(fsys, "etc/alternatives/tool")
+			if err != nil || info.Size() != int64(len("binary")) {
+				t.Errorf("Stat(etc/alternatives/tool) = %v, %v, want usr/bin/tool", info, err)
+			}
+			entries, err := fs.ReadDir(fsys, "etc")
+			if err != nil {
+				t.Fatalf("ReadDir(etc) error = %v", err)
+			}
+			if entries[0].Name() != "alternatives" || entries[0].Type() != fs.ModeSymlink {
+				t.Errorf("ReadDir(etc)[0] = %v, want the symlink itself", entries[0])
+			}
+
+			if target, err := fsys.ReadLink("etc/alternatives"); err != nil || target != "/usr/bin" {
+				t.Errorf("ReadLink(etc/alternatives) = %q, %v, want /usr/bin", target, err)
+			}
+			if _, err := fsys.ReadLink("etc/passwd"); err == nil {
+				t.Error("ReadLink(etc/passwd) succeeded, want error for a regular file")
+			}
+
+			// Extended attributes come from the layer's PAX records
+			if caps, err := fsys.Getxattr("usr/bin/tool-link", "security.capability"); err != nil || len(caps) != 20 || caps[5] != 0x20 {
+				t.Errorf("Getxattr(usr/bin/tool-link) = %x, %v, want the capability of usr/bin/tool", caps, err)
+			}
+			if caps, err := fsys.Getxattr("etc/passwd", "security.capability"); err != nil || caps != nil {
+				t.Errorf("Getxattr(etc/passwd) = %x, %v, want none", caps, err)
+			}
+
+			// Content outside the retained paths is metadata only
+			if _, err := fs.ReadFile(fsys, "usr/bin/tool"); !errors.Is(err, ErrContentNotRetained) {
+				t.Errorf("ReadFile(usr/bin/tool) error = %v, want ErrContentNotRetained", err)
+			}
+		})
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	notImage := writeImage(t, []tarEntry{
+		{name: "README", typeflag: tar.TypeReg, mode: 0644, content: "hi"},
+	})
+	if _, err := Open(notImage, Options{}); err == nil {
+		t.Error("Expected error for a tarball without manifest.json or index.json")
+	}
+
+	compressed := filepath.Join(t.TempDir(), "image.tar.gz")
+	data := gzipBytes(t, buildTar(t, []tarEntry{{name: "manifest.json", typeflag: tar.TypeReg, content: "[]"}}))
+	if err := os.WriteFile(compressed, data, 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+	if _, err := Open(compressed, Options{}); err == nil {
+		t.Error("Expected error for a compressed image tarball")
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.tar"), Options{}); err == nil {
+		t.Error("Expected error for a missing image")
+	}
+}
+
+func TestFS_InvalidPaths(t *testing.T) {
+	fsys, err := Open(dockerSaveImage(t, testLayers(t)), Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for _, name := range []string{"../etc", "/etc/passwd", "etc/passwd/", "etc/missing"} {
+		if _, err := fs.Stat(fsys, name); err == nil {
+			t.Errorf("Stat(%q) succeeded, want error", name)
+		}
+	}
+	if _, err := fs.ReadDir(fsys, "etc/passwd"); err == nil {
+		t.Error("ReadDir(etc/passwd) succeeded, want error")
+	}
+}
+
+func TestFS_SHA256(t *testing.T) {
+	var selected []string
+	fsys, err := Open(dockerSaveImage(t, testLayers(t)), Options{
+		Digest: func(path string, size int64) bool {
+			selected = append(selected, path)
+			return path == "/usr/bin/tool"
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !reflect.DeepEqual(selected, []string{"/opt/app/old.bin", "/opt/app/lib/old.so", "/usr/bin/tool", "/opt/app/new.bin"}) {
+		t.Errorf("Digest called for %v, want files outside the retained paths", selected)
+	}
+
+	tests := map[string]string{
+		"usr/bin/tool":          digest([]byte("binary")),
+		"usr/bin/tool-link":     digest([]byte("binary")),
+		"etc/alternatives/tool": digest([]byte("binary")),
+		"etc/hostname":          digest([]byte("db\n")),
+	}
+	for name, want := range tests {
+		got, err := fsys.SHA256(name)
+		if err != nil {
+			t.Errorf("SHA256(%s) error = %v", name, err)
+			continue
+		}
+		if "sha256:"+got != want {
+			t.Errorf("SHA256(%s) = %s, want %s", name, got, want)
+		}
+	}
+
+	if _, err := fsys.SHA256("opt/app/new.bin"); !errors.Is(err, ErrContentNotRetained) {
+		t.Errorf("SHA256(opt/app/new.bin) error = %v, want ErrContentNotRetained", err)
+	}
+}
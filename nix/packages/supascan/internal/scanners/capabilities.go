@@ -0,0 +1,138 @@
+package scanners
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// capabilityXattr is the extended attribute holding file capabilities
+const capabilityXattr = "security.capability"
+
+// XattrFS is implemented by filesystems that can read extended attributes.
+// Getxattr returns nil without an error if the file does not have the
+// attribute.
+type XattrFS interface {
+	fs.FS
+	Getxattr(name, attr string) ([]byte, error)
+}
+
+// vfs_cap_data layout, see linux/capability.h
+const (
+	vfsCapRevisionMask   = 0xFF000000
+	vfsCapRevision1      = 0x01000000
+	vfsCapRevision2      = 0x02000000
+	vfsCapRevision3      = 0x03000000
+	vfsCapFlagsEffective = 0x000001
+)
+
+// capabilityNames maps capability numbers to their names
+var capabilityNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner",
+	"cap_fsetid", "cap_kill", "cap_setgid", "cap_setuid", "cap_setpcap",
+	"cap_linux_immutable", "cap_net_bind_service", "cap_net_broadcast",
+	"cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace",
+	"cap_sys_pacct", "cap_sys_admin", "cap_sys_boot", "cap_sys_nice",
+	"cap_sys_resource", "cap_sys_time", "cap_sys_tty_config", "cap_mknod",
+	"cap_lease", "cap_audit_write", "cap_audit_control", "cap_setfcap",
+	"cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf",
+	"cap_checkpoint_restore",
+}
+
+func capabilityName(n int) string {
+	if n < len(capabilityNames) {
+		return capabilityNames[n]
+	}
+	return fmt.Sprintf("cap_%d", n)
+}
+
+// fileCapabilities returns the capabilities of a file within fsys in getcap
+// notation (e.g. "cap_net_raw=ep"), or "" if it has none
+func fileCapabilities(fsys fs.FS, name string) (string, error) {
+	xattrs, ok := fsys.(XattrFS)
+	if !ok {
+		return "", nil
+	}
+	data, err := xattrs.Getxattr(name, capabilityXattr)
+	if err != nil || data == nil {
+		return "", err
+	}
+	return parseCapabilities(data)
+}
+
+// parseCapabilities decodes a security.capability xattr into getcap
+// notation. Capabilities sharing the same flags are grouped into one clause,
+// e.g. "cap_net_admin,cap_net_raw=ep", and clauses are ordered by their
+// lowest capability.
+func parseCapabilities(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", fmt.Errorf("capability xattr too short (%d bytes)", len(data))
+	}
+	magic := binary.LittleEndian.Uint32(data)
+
+	words := 2
+	switch magic & vfsCapRevisionMask {
+	case vfsCapRevision1:
+		words = 1
+	case vfsCapRevision2, vfsCapRevision3:
+	default:
+		return "", fmt.Errorf("unknown capability xattr revision %#x", magic&vfsCapRevisionMask)
+	}
+	if len(data) < 4+words*8 {
+		return "", fmt.Errorf("capability xattr too short (%d bytes)", len(data))
+	}
+	effective := magic&vfsCapFlagsEffective != 0
+
+	// Flags per capability, grouped into clauses
+	clauses := make(map[string][]int)
+	for word := 0; word < words; word++ {
+		permitted := binary.LittleEndian.Uint32(data[4+word*8:])
+		inheritable := binary.LittleEndian.Uint32(data[8+word*8:])
+		for bit := 0; bit < 32; bit++ {
+			mask := uint32(1) << bit
+			var flags string
+			if effective && permitted&mask != 0 {
+				flags += "e"
+			}
+			if inheritable&mask != 0 {
+				flags += "i"
+			}
+			if permitted&mask != 0 {
+				flags += "p"
+			}
+			if flags != "" {
+				clauses[flags] = append(clauses[flags], word*32+bit)
+			}
+		}
+	}
+
+	order := make([]string, 0, len(clauses))
+	for flags := range clauses {
+		order = append(order, flags)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return clauses[order[i]][0] < clauses[order[j]][0]
+	})
+
+	parts := make([]string, 0, len(order))
+	for _, flags := range order {
+		names := make([]string, len(clauses[flags]))
+		for i, n := range clauses[flags] {
+			names[i] = capabilityName(n)
+		}
+		parts = append(parts, strings.Join(names, ",")+"="+flags)
+	}
+
+	// Namespaced file capabilities apply only to the owner of a user namespace
+	if magic&vfsCapRevisionMask == vfsCapRevision3 && len(data) >= 24 {
+		if rootID := binary.LittleEndian.Uint32(data[20:]); rootID != 0 {
+			parts = append(parts, fmt.Sprintf("[rootid=%d]", rootID))
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
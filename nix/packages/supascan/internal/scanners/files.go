@@ -7,17 +7,27 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
-	"syscall"
+	"sync"
 
 	"github.com/supabase/supascan/internal/config"
 	"github.com/supabase/supascan/internal/spec"
 )
 
-// FileScanner scans all files on the filesystem and captures permissions.
-// Uses single-threaded filepath.WalkDir for memory efficiency.
+// FileScanner scans all files and symlinks on the filesystem, plus the
+// directories selected by config.Config.Directories, and captures their
+// permissions. The same walk checks every entry for security findings,
+// written to the "finding" section (see FindingsScanner).
+// Uses single-threaded filepath.WalkDir for memory efficiency, or a bounded
+// pool of directory readers when ScanOptions.WalkWorkers is above 1.
 type FileScanner struct {
-	rootPath string // For testing (default: "/")
-	stats    ScanStats
+	rootPath     string // For testing (default: "/")
+	findingsOnly bool   // Only write findings, not file specs
+	stats        ScanStats
+	statsMu      sync.Mutex
+	names        *idNames        // Resolves owners when scanning an offline root (nil: host NSS)
+	fsys         fs.FS           // Filesystem being walked, for hashing file content
+	hasher       *fileHasher     // Hashes files concurrently (nil: hash during the walk)
+	findings     *findingChecker // Checks walked entries for findings (nil: not checked)
 }
 
 func (s *FileScanner) Name() string {
@@ -28,6 +38,10 @@ func (s *FileScanner) IsDynamic() bool {
 	return false // File metadata is relatively static
 }
 
+func (s *FileScanner) SupportsOffline() bool {
+	return true
+}
+
 func (s *FileScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
 	opts.Logger.Info("Starting filesystem scan")
 
@@ -37,15 +51,30 @@ func (s *FileScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, er
 		return s.stats, fmt.Errorf("writer does not implement Writer interface")
 	}
 
-	if err := writer.StartResource("file"); err != nil {
+	// The finding section is started first, so that file specs added with
+	// Add go to the file section
+	if err := writer.StartResource("finding"); err != nil {
 		return s.stats, err
 	}
+	if !s.findingsOnly {
+		if err := writer.StartResource("file"); err != nil {
+			return s.stats, err
+		}
+	}
 
-	// Default to root filesystem
+	// Default to root filesystem. Paths are walked within fsys and reported
+	// under prefix, so an offline root's files are reported as they would
+	// be on the booted system.
 	root := s.rootPath
 	if root == "" {
 		root = "/"
 	}
+	fsys, prefix := DirFS(root), root
+	s.names = nil
+	if opts.RootFS != nil && s.rootPath == "" {
+		fsys, prefix = opts.RootFS, "/"
+		s.names = loadIDNames(opts.RootFS)
+	}
 
 	// Get config
 	cfg, ok := opts.Config.(*config.Config)
@@ -56,108 +85,295 @@ func (s *FileScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, er
 		cfg = &config.Config{} // Empty config if none provided
 	}
 
-	// WalkDir is faster than Walk (doesn't call Lstat unless needed)
-	// Single-threaded scan keeps memory usage bounded
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		// Check context for cancellation (Ctrl+C support)
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	visit := func(path string, d fs.DirEntry, err error) error {
+		return s.visit(ctx, path, rootPath(prefix, path), d, err, cfg, writer, opts)
+	}
+
+	// Concurrent Adds need a writer that is safe for concurrent use
+	rw, concurrent := writer.(ResourceWriter)
+
+	// Findings go to their own section while the walk adds file specs,
+	// which needs a writer that takes the resource type with each spec
+	s.findings = nil
+	switch {
+	case s.findingsOnly:
+		s.findings = newFindingChecker(fsys, cfg, writer.Add, opts)
+	case concurrent:
+		s.findings = newFindingChecker(fsys, cfg, func(finding interface{}) error {
+			return rw.AddResource("finding", finding)
+		}, opts)
+	default:
+		opts.Logger.Warn("Writer cannot mix resource types, skipping security findings")
+	}
+
+	// Files selected by HashPaths are hashed on their own pool, so that
+	// large files do not hold up the walk
+	s.fsys, s.hasher = fsys, nil
+	if len(cfg.HashPaths) > 0 && concurrent && opts.HashWorkers > 1 {
+		opts.Logger.Debug("Hashing files in parallel", "workers", opts.HashWorkers)
+		s.hasher = startFileHasher(fsys, opts.HashWorkers, func(job hashJob, hashErr error) error {
+			return s.writeHashed(job, hashErr, writer, opts)
+		})
+	}
+
+	var err error
+	if concurrent && opts.WalkWorkers > 1 {
+		opts.Logger.Debug("Walking filesystem in parallel", "workers", opts.WalkWorkers)
+		err = walkDirParallel(fsys, opts.WalkWorkers, visit)
+	} else {
+		// WalkDir is faster than Walk (doesn't call Lstat unless needed)
+		// Single-threaded scan keeps memory usage bounded
+		err = fs.WalkDir(fsys, ".", visit)
+	}
+
+	if s.hasher != nil {
+		if hashErr := s.hasher.wait(); err == nil {
+			err = hashErr
 		}
+	}
 
-		// Skip excluded paths (pseudo-filesystems, temp dirs)
-		if cfg.IsPathExcluded(path) {
-			if d != nil && d.IsDir() {
-				return filepath.SkipDir // Don't descend into excluded dirs
-			}
-			return nil
+	return s.stats, err
+}
+
+// visit handles one walked entry. It is called for every entry by both the
+// sequential and the parallel walk, and is safe for concurrent use. fsPath
+// is the entry's path within the walked filesystem, path the reported one.
+func (s *FileScanner) visit(ctx context.Context, fsPath, path string, d fs.DirEntry, err error, cfg *config.Config, writer Writer, opts ScanOptions) error {
+	// Check context for cancellation (Ctrl+C support)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Skip excluded paths (pseudo-filesystems, temp dirs)
+	if cfg.IsPathExcluded(path) {
+		if d != nil && d.IsDir() {
+			return filepath.SkipDir // Don't descend into excluded dirs
 		}
+		return nil
+	}
 
-		// Handle shallow directories - limit recursion depth
-		depth := cfg.GetShallowDirDepth(path)
-		if depth >= 0 {
-			if d != nil && d.IsDir() {
-				if depth == 0 && cfg.ShallowDepth == 0 {
-					// Depth 0 means capture this directory entry but don't recurse into it
-					info, err := d.Info()
-					if err == nil {
+	// Handle shallow directories - limit recursion depth
+	depth := cfg.GetShallowDirDepth(path)
+	if depth >= 0 {
+		if d != nil && d.IsDir() {
+			if depth == 0 && cfg.ShallowDepth == 0 {
+				// Depth 0 means capture this directory entry but don't recurse into it
+				info, err := d.Info()
+				if err == nil {
+					if err := s.checkFindings(fsPath, path, info); err != nil {
+						return err
+					}
+					if !s.findingsOnly {
 						dirSpec := s.buildDirSpec(path, info)
 						if err := writer.Add(dirSpec); err != nil {
 							return fmt.Errorf("failed to write dir spec: %w", err)
 						}
-						s.stats.FilesScanned++
+						s.countScanned()
 					}
-					opts.Logger.Debug("Captured shallow dir, skipping contents", "path", path)
-					return filepath.SkipDir
-				}
-				if depth >= cfg.ShallowDepth {
-					// This directory is at or beyond the configured shallow depth - skip it
-					opts.Logger.Debug("Skipping directory beyond shallow depth", "path", path, "depth", depth, "max_depth", cfg.ShallowDepth)
-					return filepath.SkipDir
 				}
-			} else if d != nil && d.Type().IsRegular() {
-				// For files inside shallow dirs, skip if at or beyond shallow depth
-				if depth > cfg.ShallowDepth {
-					opts.Logger.Debug("Skipping file beyond shallow depth", "path", path, "depth", depth, "max_depth", cfg.ShallowDepth)
-					return nil
+				opts.Logger.Debug("Captured shallow dir, skipping contents", "path", path)
+				return filepath.SkipDir
+			}
+			if depth >= cfg.ShallowDepth {
+				// This directory is at or beyond the configured shallow depth - skip
+				// its contents, but check the directory itself for findings
+				if s.findings != nil {
+					if info, err := d.Info(); err == nil {
+						if err := s.checkFindings(fsPath, path, info); err != nil {
+							return err
+						}
+					}
 				}
+				opts.Logger.Debug("Skipping directory beyond shallow depth", "path", path, "depth", depth, "max_depth", cfg.ShallowDepth)
+				return filepath.SkipDir
+			}
+		} else if d != nil && (d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0) {
+			// For files inside shallow dirs, skip if at or beyond shallow depth
+			if depth > cfg.ShallowDepth {
+				opts.Logger.Debug("Skipping file beyond shallow depth", "path", path, "depth", depth, "max_depth", cfg.ShallowDepth)
+				return nil
 			}
 		}
+	}
+
+	// Handle walk errors (permission denied, etc.)
+	if err != nil {
+		return s.handleError(err, path, opts)
+	}
 
-		// Handle walk errors (permission denied, etc.)
+	// Record directories selected by the config, then descend into them
+	if d != nil && d.IsDir() {
+		record := !s.findingsOnly && cfg.ShouldRecordDirectory(path)
+		if !record && s.findings == nil {
+			return nil
+		}
+		info, err := d.Info()
 		if err != nil {
 			return s.handleError(err, path, opts)
 		}
-
-		// Only process regular files (skip dirs, symlinks, etc.)
-		if d == nil || !d.Type().IsRegular() {
+		if err := s.checkFindings(fsPath, path, info); err != nil {
+			return err
+		}
+		if !record {
 			return nil
 		}
+		if err := writer.Add(s.buildDirSpec(path, info)); err != nil {
+			return fmt.Errorf("failed to write dir spec: %w", err)
+		}
+		s.countScanned()
+		return nil
+	}
 
-		// Get file info
-		info, err := d.Info()
+	if d == nil {
+		return nil
+	}
+
+	// Only record regular files and symlinks; devices, sockets and the
+	// like are only checked for findings
+	recordable := d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0
+	if !recordable && s.findings == nil {
+		return nil
+	}
+
+	// Get file info
+	info, err := d.Info()
+	if err != nil {
+		return s.handleError(err, path, opts)
+	}
+
+	if err := s.checkFindings(fsPath, path, info); err != nil {
+		return err
+	}
+	if !recordable || s.findingsOnly {
+		return nil
+	}
+
+	// Build GOSS file spec
+	fileSpec := s.buildFileSpec(path, info)
+
+	if cfg.Capabilities && d.Type().IsRegular() {
+		caps, err := fileCapabilities(s.fsys, fsPath)
 		if err != nil {
 			return s.handleError(err, path, opts)
 		}
+		fileSpec.Capabilities = caps
+	}
 
-		// Build GOSS file spec
-		fileSpec := s.buildFileSpec(path, info)
-
-		// Add to chunked writer (auto-flushes every 1000 files)
+	if d.Type()&fs.ModeSymlink != 0 {
+		if err := s.addLinkTarget(&fileSpec, fsPath, cfg); err != nil {
+			return s.handleError(err, path, opts)
+		}
 		if err := writer.Add(fileSpec); err != nil {
 			return fmt.Errorf("failed to write file spec: %w", err)
 		}
+		s.countScanned()
+		return nil
+	}
 
-		s.stats.FilesScanned++
-
-		// Log progress every 10k files
-		if s.stats.FilesScanned%10000 == 0 {
-			opts.Logger.Debug("Scan progress", "files_scanned", s.stats.FilesScanned)
+	// Add to chunked writer (auto-flushes every 1000 files), hashing the
+	// content first if the config selects the file
+	if cfg.ShouldHash(path, info.Size()) {
+		if err := s.hashFile(hashJob{fsPath: fsPath, spec: fileSpec}, writer, opts); err != nil {
+			return err
 		}
+	} else if err := writer.Add(fileSpec); err != nil {
+		return fmt.Errorf("failed to write file spec: %w", err)
+	}
+
+	scanned := s.countScanned()
+
+	// Log progress every 10k files
+	if scanned%10000 == 0 {
+		opts.Logger.Debug("Scan progress", "files_scanned", scanned)
+	}
 
+	return nil
+}
+
+// checkFindings writes the findings for one walked entry, if findings are
+// being checked
+func (s *FileScanner) checkFindings(fsPath, path string, info fs.FileInfo) error {
+	if s.findings == nil {
 		return nil
-	})
+	}
+	return s.findings.check(fsPath, path, info)
+}
 
-	return s.stats, err
+// addLinkTarget records a symlink's target in its spec, and whether the
+// target exists if the config asks for it
+func (s *FileScanner) addLinkTarget(fileSpec *spec.FileSpec, fsPath string, cfg *config.Config) error {
+	target, err := readLink(s.fsys, fsPath)
+	if err != nil {
+		return err
+	}
+
+	fileSpec.Filetype = "symlink"
+	fileSpec.LinkedTo = target
+	if cfg.SymlinkTargets {
+		exists := linkTargetExists(s.fsys, fsPath, target)
+		fileSpec.TargetExists = &exists
+	}
+	return nil
 }
 
-// buildFileSpec creates a GOSS file spec from os.FileInfo
-func (s *FileScanner) buildFileSpec(path string, info fs.FileInfo) spec.FileSpec {
-	// Extract Unix permissions and ownership
-	sys := info.Sys().(*syscall.Stat_t)
+// hashFile adds the sha256 of a file to its spec and writes it, queueing
+// it on the hash pool if one is running
+func (s *FileScanner) hashFile(job hashJob, writer Writer, opts ScanOptions) error {
+	if s.hasher != nil {
+		return s.hasher.submit(job)
+	}
 
-	// Mode with leading zero (GOSS format: "0644" not "644")
-	mode := fmt.Sprintf("0%o", info.Mode().Perm())
+	digest, hashErr := fsSHA256(s.fsys, job.fsPath)
+	if hashErr == nil {
+		job.spec.Sha256 = digest
+	}
+	return s.writeHashed(job, hashErr, writer, opts)
+}
 
+// writeHashed writes the spec of a hashed file. A file that could not be
+// read is recorded without its hash unless in strict mode.
+func (s *FileScanner) writeHashed(job hashJob, hashErr error, writer Writer, opts ScanOptions) error {
+	if hashErr != nil {
+		if opts.Strict {
+			return fmt.Errorf("failed to hash %s: %w", job.spec.Path, hashErr)
+		}
+		opts.Logger.Warn("Failed to hash file, recording it without sha256", "path", job.spec.Path, "error", hashErr.Error())
+	}
+
+	if err := writer.Add(job.spec); err != nil {
+		return fmt.Errorf("failed to write file spec: %w", err)
+	}
+	return nil
+}
+
+// countScanned increments FilesScanned and returns the new count
+func (s *FileScanner) countScanned() int {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	s.stats.FilesScanned++
+	return s.stats.FilesScanned
+}
+
+// countSkipped increments FilesSkipped
+func (s *FileScanner) countSkipped() {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	s.stats.FilesSkipped++
+}
+
+// buildFileSpec creates a GOSS file spec from os.FileInfo
+func (s *FileScanner) buildFileSpec(path string, info fs.FileInfo) spec.FileSpec {
 	// Get username/groupname from UID/GID
-	owner := getUsername(sys.Uid)
-	group := getGroupname(sys.Gid)
+	owner, group := s.ownerNames(info)
 
 	return spec.FileSpec{
 		Path:     path,
 		Exists:   true,
-		Mode:     mode,
+		Mode:     formatMode(info.Mode()),
 		Owner:    owner,
 		Group:    group,
 		Filetype: "file",
@@ -166,33 +382,120 @@ func (s *FileScanner) buildFileSpec(path string, info fs.FileInfo) spec.FileSpec
 
 // buildDirSpec creates a GOSS file spec for a directory
 func (s *FileScanner) buildDirSpec(path string, info fs.FileInfo) spec.FileSpec {
-	// Extract Unix permissions and ownership
-	sys := info.Sys().(*syscall.Stat_t)
-
-	// Mode with leading zero (GOSS format: "0755" not "755")
-	mode := fmt.Sprintf("0%o", info.Mode().Perm())
-
 	// Get username/groupname from UID/GID
-	owner := getUsername(sys.Uid)
-	group := getGroupname(sys.Gid)
+	owner, group := s.ownerNames(info)
 
 	return spec.FileSpec{
 		Path:     path,
 		Exists:   true,
-		Mode:     mode,
+		Mode:     formatMode(info.Mode()),
 		Owner:    owner,
 		Group:    group,
 		Filetype: "directory",
 	}
 }
 
+// LookupFile builds the GOSS file spec for a single path on the live system.
+// The path is not followed if it is a symlink; the spec then includes the
+// link target and whether it exists. File capabilities are always read. A
+// path that does not exist yields a spec with Exists set to false rather
+// than an error.
+func LookupFile(path string) (spec.FileSpec, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return spec.FileSpec{Path: path, Exists: false}, nil
+	}
+	if err != nil {
+		return spec.FileSpec{Path: path}, err
+	}
+
+	s := &FileScanner{}
+	if info.IsDir() {
+		return s.buildDirSpec(path, info), nil
+	}
+
+	fileSpec := s.buildFileSpec(path, info)
+	fileSpec.Filetype = filetypeOf(info.Mode())
+	if info.Mode().IsRegular() {
+		data, err := getxattr(path, capabilityXattr)
+		if err != nil {
+			return fileSpec, err
+		}
+		if data != nil {
+			if fileSpec.Capabilities, err = parseCapabilities(data); err != nil {
+				return fileSpec, err
+			}
+		}
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return fileSpec, err
+		}
+		fileSpec.LinkedTo = target
+		_, err = os.Stat(path)
+		exists := err == nil
+		fileSpec.TargetExists = &exists
+	}
+	return fileSpec, nil
+}
+
+// formatMode renders the permission and special bits of a file mode in the
+// GOSS format: four octal digits, e.g. "0644", or "4755" for a setuid binary
+func formatMode(mode fs.FileMode) string {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
+
+// filetypeOf maps a file mode to the GOSS filetype name
+func filetypeOf(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode&fs.ModeDevice != 0 && mode&fs.ModeCharDevice != 0:
+		return "character-device"
+	case mode&fs.ModeDevice != 0:
+		return "block-device"
+	case mode&fs.ModeNamedPipe != 0:
+		return "pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	default:
+		return "file"
+	}
+}
+
+// ownerNames resolves the owner and group of a file, against the offline
+// root's passwd and group files when scanning one, else the host's NSS
+func (s *FileScanner) ownerNames(info fs.FileInfo) (string, string) {
+	uid, gid, ok := fileOwnerIDs(info)
+	if !ok {
+		return "", ""
+	}
+	if s.names != nil {
+		return s.names.username(uid), s.names.groupname(gid)
+	}
+	return getUsername(uid), getGroupname(gid)
+}
+
 // handleError processes errors based on strict mode
 func (s *FileScanner) handleError(err error, path string, opts ScanOptions) error {
 	// Permission denied is common and expected
 	if os.IsPermission(err) {
 		opts.Logger.Debug("Permission denied, skipping", "path", path, "error", err.Error())
 
-		s.stats.FilesSkipped++
+		s.countSkipped()
 
 		if opts.Strict {
 			return fmt.Errorf("permission denied: %s: %w", path, err)
@@ -207,7 +510,7 @@ func (s *FileScanner) handleError(err error, path string, opts ScanOptions) erro
 
 	opts.Logger.Warn("Failed to access file, skipping", "path", path, "error", err.Error())
 
-	s.stats.FilesSkipped++
+	s.countSkipped()
 	return nil
 }
 
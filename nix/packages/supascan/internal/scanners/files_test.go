@@ -2,8 +2,16 @@ package scanners
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/supabase/supascan/internal/config"
@@ -194,3 +202,424 @@ func TestFileScanner_ShallowDirsDepthOne(t *testing.T) {
 		t.Errorf("nested.txt should be skipped with depth 1")
 	}
 }
+
+func TestLookupFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	target := filepath.Join(tmpDir, "file.conf")
+	os.WriteFile(target, []byte("data"), 0640)
+	link := filepath.Join(tmpDir, "link.conf")
+	os.Symlink(target, link)
+
+	fileSpec, err := LookupFile(target)
+	if err != nil {
+		t.Fatalf("LookupFile failed: %v", err)
+	}
+	if !fileSpec.Exists || fileSpec.Mode != "0640" || fileSpec.Filetype != "file" {
+		t.Errorf("Unexpected file spec: %+v", fileSpec)
+	}
+
+	dirSpec, err := LookupFile(tmpDir)
+	if err != nil {
+		t.Fatalf("LookupFile failed: %v", err)
+	}
+	if dirSpec.Filetype != "directory" {
+		t.Errorf("Expected filetype directory, got %s", dirSpec.Filetype)
+	}
+
+	linkSpec, err := LookupFile(link)
+	if err != nil {
+		t.Fatalf("LookupFile failed: %v", err)
+	}
+	if linkSpec.Filetype != "symlink" || linkSpec.LinkedTo != target {
+		t.Errorf("Expected symlink to %s, got %+v", target, linkSpec)
+	}
+	if linkSpec.TargetExists == nil || !*linkSpec.TargetExists {
+		t.Error("Expected the link target to exist")
+	}
+
+	missing, err := LookupFile(filepath.Join(tmpDir, "missing"))
+	if err != nil {
+		t.Fatalf("LookupFile should not fail for missing files: %v", err)
+	}
+	if missing.Exists {
+		t.Error("Expected Exists=false for missing file")
+	}
+}
+
+// buildTree creates a directory tree wide and deep enough for parallel
+// walkers to interleave
+func buildTree(t *testing.T, root string) {
+	t.Helper()
+	for _, dir := range []string{"etc", "proc", "nix/store/a/b", "nix/store/c", "usr/lib/x/y/z", "var/log"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{"", "etc", "proc", "nix", "nix/store", "nix/store/a", "nix/store/a/b", "nix/store/c", "usr/lib/x/y/z", "var/log"} {
+		for i := 0; i < 5; i++ {
+			path := filepath.Join(root, dir, fmt.Sprintf("file%d", i))
+			if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+				t.Fatalf("Failed to create %s: %v", path, err)
+			}
+		}
+	}
+	os.Symlink(filepath.Join(root, "etc"), filepath.Join(root, "etc-link"))
+}
+
+func TestFileScanner_ParallelMatchesSequential(t *testing.T) {
+	tmpDir := t.TempDir()
+	buildTree(t, tmpDir)
+	shallowDir := filepath.Join(tmpDir, "nix/store")
+
+	configs := map[string]*config.Config{
+		"no exclusions":   {},
+		"exclusions":      {Paths: []string{filepath.Join(tmpDir, "proc") + "/*", filepath.Join(tmpDir, "var")}},
+		"shallow depth 0": {ShallowDirs: []string{shallowDir}, ShallowDepth: 0},
+		"shallow depth 1": {ShallowDirs: []string{shallowDir}, ShallowDepth: 1},
+		"shallow depth 2": {ShallowDirs: []string{shallowDir}, ShallowDepth: 2},
+		"directories":     {Directories: []string{tmpDir + "/*"}, ShallowDirs: []string{shallowDir}, ShallowDepth: 1},
+	}
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			scan := func(walkWorkers int) (ScanStats, map[string]spec.FileSpec) {
+				writer := spec.NewTestWriter()
+				stats, err := (&FileScanner{rootPath: tmpDir}).Scan(context.Background(), ScanOptions{
+					Writer:      writer,
+					Config:      cfg,
+					Logger:      testLogger(),
+					WalkWorkers: walkWorkers,
+				})
+				if err != nil {
+					t.Fatalf("Scan(walkWorkers=%d) failed: %v", walkWorkers, err)
+				}
+				return stats, writer.GetFileResults()
+			}
+
+			seqStats, seqFiles := scan(1)
+			parStats, parFiles := scan(4)
+
+			if len(seqFiles) == 0 {
+				t.Fatal("Expected files to be scanned")
+			}
+			if !reflect.DeepEqual(seqFiles, parFiles) {
+				t.Errorf("Parallel walk found %d files, sequential %d", len(parFiles), len(seqFiles))
+			}
+			if seqStats.FilesScanned != parStats.FilesScanned || seqStats.FilesSkipped != parStats.FilesSkipped {
+				t.Errorf("Stats differ: sequential %+v, parallel %+v", seqStats, parStats)
+			}
+		})
+	}
+}
+
+func TestFileScanner_ParallelCancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	buildTree(t, tmpDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := (&FileScanner{rootPath: tmpDir}).Scan(ctx, ScanOptions{
+		Writer:      spec.NewTestWriter(),
+		Logger:      testLogger(),
+		WalkWorkers: 4,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWalkDirParallel_StopsOnError(t *testing.T) {
+	tmpDir := t.TempDir()
+	buildTree(t, tmpDir)
+
+	boom := errors.New("boom")
+	err := walkDirParallel(os.DirFS(tmpDir), 4, func(path string, d fs.DirEntry, err error) error {
+		if filepath.Base(path) == "store" {
+			return boom
+		}
+		return nil
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("Expected walk error to be returned, got %v", err)
+	}
+
+	err = walkDirParallel(os.DirFS(filepath.Join(tmpDir, "missing")), 4, func(path string, d fs.DirEntry, err error) error {
+		return err
+	})
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected missing root error, got %v", err)
+	}
+}
+
+func TestFileScanner_Directories(t *testing.T) {
+	tmpDir := t.TempDir()
+	buildTree(t, tmpDir)
+	os.Chmod(filepath.Join(tmpDir, "var", "log"), 0777)
+
+	writer := spec.NewTestWriter()
+	_, err := (&FileScanner{rootPath: tmpDir}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Config: &config.Config{
+			Directories: []string{filepath.Join(tmpDir, "var") + "/*", filepath.Join(tmpDir, "etc")},
+			Paths:       []string{filepath.Join(tmpDir, "usr")},
+		},
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var dirs []string
+	for path, fileSpec := range writer.GetFileResults() {
+		if fileSpec.Filetype == "directory" {
+			dirs = append(dirs, strings.TrimPrefix(path, tmpDir))
+		}
+	}
+	sort.Strings(dirs)
+	if want := []string{"/etc", "/var/log"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("Recorded directories %v, want %v", dirs, want)
+	}
+
+	logDir := writer.GetFileResults()[filepath.Join(tmpDir, "var", "log")]
+	if logDir.Mode != "0777" || logDir.Owner == "" || logDir.Group == "" {
+		t.Errorf("Unexpected directory spec: %+v", logDir)
+	}
+}
+
+func TestFileScanner_OfflineRoot(t *testing.T) {
+	imageRoot := t.TempDir()
+	buildTree(t, imageRoot)
+
+	// The image maps the scanning user's ids to names the host does not use
+	uid, gid := os.Getuid(), os.Getgid()
+	os.WriteFile(filepath.Join(imageRoot, "etc", "passwd"), []byte(fmt.Sprintf("imageuser:x:%d:%d::/:/bin/sh\n", uid, gid)), 0644)
+	os.WriteFile(filepath.Join(imageRoot, "etc", "group"), []byte(fmt.Sprintf("imagegroup:x:%d:\n", gid)), 0644)
+
+	writer := spec.NewTestWriter()
+	_, err := (&FileScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Config: &config.Config{Paths: []string{"/proc/*"}},
+		Logger: testLogger(),
+		RootFS: os.DirFS(imageRoot),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	files := writer.GetFileResults()
+	passwd, found := files["/etc/passwd"]
+	if !found {
+		t.Fatalf("Expected paths relative to the image root, got %d files", len(files))
+	}
+	if passwd.Owner != "imageuser" || passwd.Group != "imagegroup" {
+		t.Errorf("Expected owner resolved from the image, got %s:%s", passwd.Owner, passwd.Group)
+	}
+	for path := range files {
+		if strings.HasPrefix(path, imageRoot) {
+			t.Errorf("Path %s reported with the image mount point", path)
+		}
+		if strings.HasPrefix(path, "/proc/") {
+			t.Errorf("Excluded path %s was scanned", path)
+		}
+	}
+}
+
+func TestFileScanner_Hashing(t *testing.T) {
+	tmpDir := t.TempDir()
+	buildTree(t, tmpDir)
+	os.WriteFile(filepath.Join(tmpDir, "etc", "big"), []byte(strings.Repeat("x", 100)), 0644)
+
+	cfg := &config.Config{
+		HashPaths:   []string{filepath.Join(tmpDir, "etc") + "/*"},
+		HashMaxSize: 50,
+	}
+	// sha256 of "data"
+	const dataSHA256 = "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+
+	for _, hashWorkers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("hashWorkers=%d", hashWorkers), func(t *testing.T) {
+			writer := spec.NewTestWriter()
+			stats, err := (&FileScanner{rootPath: tmpDir}).Scan(context.Background(), ScanOptions{
+				Writer:      writer,
+				Config:      cfg,
+				Logger:      testLogger(),
+				WalkWorkers: 2,
+				HashWorkers: hashWorkers,
+			})
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+
+			files := writer.GetFileResults()
+			if len(files) != stats.FilesScanned {
+				t.Errorf("Wrote %d files, scanned %d", len(files), stats.FilesScanned)
+			}
+			for path, fileSpec := range files {
+				want := ""
+				if filepath.Dir(path) == filepath.Join(tmpDir, "etc") && filepath.Base(path) != "big" {
+					want = dataSHA256
+				}
+				if fileSpec.Sha256 != want {
+					t.Errorf("%s sha256 = %q, want %q", path, fileSpec.Sha256, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileScanner_Symlinks(t *testing.T) {
+	imageRoot := t.TempDir()
+	os.MkdirAll(filepath.Join(imageRoot, "etc", "alternatives"), 0755)
+	os.MkdirAll(filepath.Join(imageRoot, "usr", "bin"), 0755)
+	os.WriteFile(filepath.Join(imageRoot, "usr", "bin", "psql"), []byte("binary"), 0755)
+	// Absolute targets resolve within the scanned root, not the host
+	os.Symlink("/usr/bin/psql", filepath.Join(imageRoot, "etc", "alternatives", "psql"))
+	os.Symlink("../../usr/bin/psql", filepath.Join(imageRoot, "etc", "alternatives", "psql-relative"))
+	os.Symlink("/usr/bin/missing", filepath.Join(imageRoot, "etc", "alternatives", "dangling"))
+
+	want := map[string]struct {
+		target string
+		exists bool
+	}{
+		"/etc/alternatives/psql":          {"/usr/bin/psql", true},
+		"/etc/alternatives/psql-relative": {"../../usr/bin/psql", true},
+		"/etc/alternatives/dangling":      {"/usr/bin/missing", false},
+	}
+
+	for _, symlinkTargets := range []bool{false, true} {
+		t.Run(fmt.Sprintf("symlinkTargets=%v", symlinkTargets), func(t *testing.T) {
+			writer := spec.NewTestWriter()
+			_, err := (&FileScanner{}).Scan(context.Background(), ScanOptions{
+				Writer: writer,
+				Config: &config.Config{SymlinkTargets: symlinkTargets},
+				Logger: testLogger(),
+				RootFS: DirFS(imageRoot),
+			})
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+
+			files := writer.GetFileResults()
+			if files["/usr/bin/psql"].Filetype != "file" {
+				t.Errorf("Expected /usr/bin/psql as a file, got %+v", files["/usr/bin/psql"])
+			}
+			for path, w := range want {
+				link, found := files[path]
+				if !found {
+					t.Errorf("Symlink %s not recorded", path)
+					continue
+				}
+				if link.Filetype != "symlink" || link.LinkedTo != w.target {
+					t.Errorf("%s: expected symlink to %s, got %+v", path, w.target, link)
+				}
+				switch {
+				case !symlinkTargets && link.TargetExists != nil:
+					t.Errorf("%s: target-exists recorded without the option", path)
+				case symlinkTargets && (link.TargetExists == nil || *link.TargetExists != w.exists):
+					t.Errorf("%s: expected target-exists %v, got %v", path, w.exists, link.TargetExists)
+				}
+			}
+		})
+	}
+}
+
+func TestDirFS_AbsoluteLinksStayInRoot(t *testing.T) {
+	// A file that exists only on the host, outside the scanned root
+	hostDir := t.TempDir()
+	hostFile := filepath.Join(hostDir, "sudoers")
+	os.WriteFile(hostFile, []byte("mallory ALL=(ALL) NOPASSWD: ALL\n"), 0644)
+
+	imageRoot := t.TempDir()
+	os.MkdirAll(filepath.Join(imageRoot, "etc"), 0755)
+	os.MkdirAll(filepath.Join(imageRoot, hostDir), 0755)
+	os.WriteFile(filepath.Join(imageRoot, hostDir, "other"), []byte("image\n"), 0644)
+	os.Symlink(hostFile, filepath.Join(imageRoot, "etc", "sudoers"))
+	os.Symlink(hostDir, filepath.Join(imageRoot, "etc", "dir"))
+	os.Symlink("../../../../../..", filepath.Join(imageRoot, "etc", "up"))
+
+	fsys := DirFS(imageRoot)
+
+	if _, err := fs.ReadFile(fsys, "etc/sudoers"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the host file to be out of reach, got %v", err)
+	}
+	if linkTargetExists(fsys, "etc/sudoers", hostFile) {
+		t.Error("Expected target-exists false for a target only on the host")
+	}
+	if data, err := fs.ReadFile(fsys, "etc/dir/other"); err != nil || string(data) != "image\n" {
+		t.Errorf("Expected the file under the image's %s, got %q, %v", hostDir, data, err)
+	}
+	if _, err := fs.Stat(fsys, path.Join("etc/up", hostDir, "other")); err != nil {
+		t.Errorf("Expected .. to stop at the root, got %v", err)
+	}
+	if target, err := readLink(fsys, "etc/sudoers"); err != nil || target != hostFile {
+		t.Errorf("Expected the link itself to be read, got %q, %v", target, err)
+	}
+
+	writer := spec.NewTestWriter()
+	if _, err := (&SudoersScanner{}).Scan(context.Background(), ScanOptions{Writer: writer, Logger: testLogger(), RootFS: fsys}); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := writer.GetResourceCount(); n != 0 {
+		t.Errorf("Expected no sudoers rules from the host, got %d", n)
+	}
+}
+
+func TestFormatMode(t *testing.T) {
+	tests := []struct {
+		mode fs.FileMode
+		want string
+	}{
+		{0644, "0644"},
+		{0, "0000"},
+		{fs.ModeDir | 0755, "0755"},
+		{fs.ModeSetuid | 0755, "4755"},
+		{fs.ModeSetgid | 0755, "2755"},
+		{fs.ModeDir | fs.ModeSticky | 0777, "1777"},
+		{fs.ModeSetuid | fs.ModeSetgid | 0750, "6750"},
+	}
+	for _, tt := range tests {
+		if got := formatMode(tt.mode); got != tt.want {
+			t.Errorf("formatMode(%v) = %s, want %s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	// vfsCapData builds a security.capability xattr from its 32-bit words
+	vfsCapData := func(words ...uint32) []byte {
+		data := make([]byte, 4*len(words))
+		for i, w := range words {
+			binary.LittleEndian.PutUint32(data[4*i:], w)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{"revision 2 effective", vfsCapData(0x02000001, 1<<13, 0, 0, 0), "cap_net_raw=ep", false},
+		{"grouped by flags", vfsCapData(0x02000001, 1<<12|1<<13|1<<10, 1<<10, 0, 0), "cap_net_bind_service=eip cap_net_admin,cap_net_raw=ep", false},
+		{"not effective", vfsCapData(0x02000000, 1<<21, 0, 0, 0), "cap_sys_admin=p", false},
+		{"high capabilities", vfsCapData(0x02000001, 0, 0, 1<<7, 0), "cap_bpf=ep", false},
+		{"revision 1", vfsCapData(0x01000001, 1<<13, 0), "cap_net_raw=ep", false},
+		{"revision 3 rootid", vfsCapData(0x03000001, 1<<13, 0, 0, 0, 1000), "cap_net_raw=ep [rootid=1000]", false},
+		{"unknown revision", vfsCapData(0x07000000, 0, 0, 0, 0), "", true},
+		{"truncated", vfsCapData(0x02000000, 0), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCapabilities(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCapabilities() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseCapabilities() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package scanners
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// FindingsScanner flags risky file states checked by the CIS benchmarks:
+// world-writable files outside sticky directories such as /tmp, files whose
+// owner or group has no /etc/passwd or /etc/group entry, and setuid or
+// setgid binaries not on config.Config.AllowedSetuid. The findings go to
+// their own "finding" section, which validate treats as critical.
+//
+// genspec gets the findings from FileScanner, which checks them on its own
+// walk; this scanner walks the same paths for the findings alone, as
+// validate needs them.
+type FindingsScanner struct {
+	rootPath string // For testing (default: "/")
+}
+
+func (s *FindingsScanner) Name() string {
+	return "findings"
+}
+
+func (s *FindingsScanner) IsDynamic() bool {
+	return false
+}
+
+func (s *FindingsScanner) SupportsOffline() bool {
+	return true
+}
+
+func (s *FindingsScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	opts.Logger.Info("Starting security findings scan")
+
+	files := &FileScanner{rootPath: s.rootPath, findingsOnly: true}
+	if _, err := files.Scan(ctx, opts); err != nil {
+		return ScanStats{}, err
+	}
+	return ScanStats{}, nil
+}
+
+// findingChecker checks the entries of a file walk for findings. It is
+// safe for concurrent use.
+type findingChecker struct {
+	fsys  fs.FS
+	cfg   *config.Config
+	names *idNames // nil when owners cannot be checked
+	add   func(finding interface{}) error
+}
+
+// newFindingChecker creates a checker for the files of fsys, writing
+// findings with add
+func newFindingChecker(fsys fs.FS, cfg *config.Config, add func(interface{}) error, opts ScanOptions) *findingChecker {
+	// Owners are checked against the scanned root's own passwd and group
+	// files. Without them every file would be reported, so the check is
+	// skipped instead.
+	names := loadIDNames(fsys)
+	if len(names.users) == 0 || len(names.groups) == 0 {
+		opts.Logger.Warn("No passwd or group entries found, skipping unowned file checks")
+		names = nil
+	}
+
+	return &findingChecker{fsys: fsys, cfg: cfg, names: names, add: add}
+}
+
+// check writes the findings for one walked entry
+func (c *findingChecker) check(fsPath, path string, info fs.FileInfo) error {
+	for _, finding := range fileFindings(c.fsys, fsPath, path, info, c.cfg, c.names) {
+		if err := c.add(finding); err != nil {
+			return fmt.Errorf("failed to write finding: %w", err)
+		}
+	}
+	return nil
+}
+
+// fileFindings returns the findings for one file. names is nil when owners
+// cannot be checked.
+func fileFindings(fsys fs.FS, fsPath, path string, info fs.FileInfo, cfg *config.Config, names *idNames) []spec.FindingSpec {
+	mode := info.Mode()
+	uid, gid, hasOwner := fileOwnerIDs(info)
+
+	var checks []string
+	if names != nil && hasOwner {
+		if !names.hasUser(uid) {
+			checks = append(checks, "unowned")
+		}
+		if !names.hasGroup(gid) {
+			checks = append(checks, "ungrouped")
+		}
+	}
+
+	// Sticky world-writable directories such as /tmp are expected, and so
+	// is anything written into them
+	worldWritable := mode.Perm()&0002 != 0 &&
+		(mode.IsRegular() || (mode.IsDir() && mode&fs.ModeSticky == 0))
+	if worldWritable && !inStickyDir(fsys, fsPath) {
+		checks = append(checks, "world-writable")
+	}
+
+	if mode.IsRegular() && mode&(fs.ModeSetuid|fs.ModeSetgid) != 0 && !cfg.IsSetuidAllowed(path) {
+		if mode&fs.ModeSetuid != 0 {
+			checks = append(checks, "setuid")
+		}
+		if mode&fs.ModeSetgid != 0 {
+			checks = append(checks, "setgid")
+		}
+	}
+
+	if len(checks) == 0 {
+		return nil
+	}
+
+	finding := spec.FindingSpec{Path: path, Mode: formatMode(mode)}
+	if hasOwner {
+		if names != nil {
+			finding.Owner, finding.Group = names.username(uid), names.groupname(gid)
+		} else {
+			finding.Owner, finding.Group = fmt.Sprintf("%d", uid), fmt.Sprintf("%d", gid)
+		}
+	}
+
+	findings := make([]spec.FindingSpec, len(checks))
+	for i, check := range checks {
+		finding.ID, finding.Check = spec.FindingID(check, path), check
+		findings[i] = finding
+	}
+	return findings
+}
+
+// inStickyDir reports whether a path within fsys lies beneath a
+// world-writable directory with the sticky bit set
+func inStickyDir(fsys fs.FS, fsPath string) bool {
+	for dir := path.Dir(fsPath); dir != "."; dir = path.Dir(dir) {
+		info, err := fs.Stat(fsys, dir)
+		if err == nil && info.Mode()&fs.ModeSticky != 0 && info.Mode().Perm()&0002 != 0 {
+			return true
+		}
+	}
+	return false
+}
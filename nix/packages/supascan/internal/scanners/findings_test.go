@@ -0,0 +1,170 @@
+package scanners
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// buildFindingsTree creates a tree with one example of each finding, and
+// passwd and group files listing the given ids
+func buildFindingsTree(t *testing.T, root string, uid, gid int) {
+	t.Helper()
+
+	for _, dir := range []string{"etc", "data/open", "tmp", "usr/bin", "excluded"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	files := map[string]os.FileMode{
+		"etc/hosts":     0644,
+		"data/shared":   0666,
+		"tmp/scratch":   0666,
+		"usr/bin/sudo":  0755 | os.ModeSetuid,
+		"usr/bin/evil":  0755 | os.ModeSetuid,
+		"usr/bin/write": 0755 | os.ModeSetgid,
+		"excluded/open": 0666,
+	}
+	for name, mode := range files {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		// Chmod, since WriteFile is subject to the umask and drops special bits
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("Failed to chmod %s: %v", name, err)
+		}
+	}
+	os.Chmod(filepath.Join(root, "data/open"), 0777)
+	os.Chmod(filepath.Join(root, "tmp"), 0777|os.ModeSticky)
+
+	passwd := fmt.Sprintf("owner:x:%d:%d::/home/owner:/bin/sh\n", uid, gid)
+	group := fmt.Sprintf("owners:x:%d:\n", gid)
+	os.WriteFile(filepath.Join(root, "etc/passwd"), []byte(passwd), 0644)
+	os.WriteFile(filepath.Join(root, "etc/group"), []byte(group), 0644)
+}
+
+func scanFindings(t *testing.T, root string, cfg *config.Config, walkWorkers int) []string {
+	t.Helper()
+
+	writer := spec.NewTestWriter()
+	_, err := (&FindingsScanner{rootPath: root}).Scan(context.Background(), ScanOptions{
+		Writer:      writer,
+		Config:      cfg,
+		Logger:      testLogger(),
+		WalkWorkers: walkWorkers,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var ids []string
+	for id, finding := range writer.GetFindingResults() {
+		if id != spec.FindingID(finding.Check, finding.Path) {
+			t.Errorf("Finding %s has check %s and path %s", id, finding.Check, finding.Path)
+		}
+		ids = append(ids, strings.Replace(id, root, "", 1))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestFindingsScanner(t *testing.T) {
+	tmpDir := t.TempDir()
+	buildFindingsTree(t, tmpDir, os.Getuid(), os.Getgid())
+
+	cfg := &config.Config{
+		Paths:         []string{filepath.Join(tmpDir, "excluded")},
+		AllowedSetuid: []string{filepath.Join(tmpDir, "usr/bin/sudo")},
+	}
+
+	want := []string{
+		"setgid:/usr/bin/write",
+		"setuid:/usr/bin/evil",
+		"world-writable:/data/open",
+		"world-writable:/data/shared",
+	}
+	if got := scanFindings(t, tmpDir, cfg, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("Findings = %v, want %v", got, want)
+	}
+	if got := scanFindings(t, tmpDir, cfg, 4); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parallel findings = %v, want %v", got, want)
+	}
+}
+
+func TestFindingsScanner_Unowned(t *testing.T) {
+	tmpDir := t.TempDir()
+	// The passwd and group files do not list the test's own ids
+	buildFindingsTree(t, tmpDir, os.Getuid()+1, os.Getgid()+1)
+
+	writer := spec.NewTestWriter()
+	_, err := (&FindingsScanner{rootPath: tmpDir}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Config: &config.Config{},
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	hosts := filepath.Join(tmpDir, "etc/hosts")
+	for _, check := range []string{"unowned", "ungrouped"} {
+		finding, ok := writer.GetFindingResults()[spec.FindingID(check, hosts)]
+		if !ok {
+			t.Errorf("Expected %s finding for %s", check, hosts)
+			continue
+		}
+		if finding.Owner != fmt.Sprint(os.Getuid()) || finding.Mode != "0644" {
+			t.Errorf("Unexpected finding: %+v", finding)
+		}
+	}
+}
+
+func TestFindingsScanner_NoPasswd(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "file"), []byte("data"), 0644)
+
+	// Without passwd and group files nothing can be checked for owners
+	if got := scanFindings(t, tmpDir, &config.Config{}, 0); len(got) != 0 {
+		t.Errorf("Expected no findings, got %v", got)
+	}
+}
+
+func TestFileScanner_Findings(t *testing.T) {
+	tmpDir := t.TempDir()
+	buildFindingsTree(t, tmpDir, os.Getuid(), os.Getgid())
+
+	cfg := &config.Config{
+		Paths:         []string{filepath.Join(tmpDir, "excluded")},
+		AllowedSetuid: []string{filepath.Join(tmpDir, "usr/bin/sudo")},
+	}
+
+	// genspec gets the findings from the file walk, alongside the file specs
+	for _, workers := range []int{0, 4} {
+		writer := spec.NewTestWriter()
+		_, err := (&FileScanner{rootPath: tmpDir}).Scan(context.Background(), ScanOptions{
+			Writer:      writer,
+			Config:      cfg,
+			Logger:      testLogger(),
+			WalkWorkers: workers,
+		})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+
+		if _, ok := writer.GetFileResults()[filepath.Join(tmpDir, "etc/hosts")]; !ok {
+			t.Errorf("Expected file spec for etc/hosts with %d workers", workers)
+		}
+		if got := len(writer.GetFindingResults()); got != 4 {
+			t.Errorf("Expected 4 findings with %d workers, got %d", workers, got)
+		}
+	}
+}
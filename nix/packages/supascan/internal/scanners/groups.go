@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -25,6 +27,10 @@ func (s *GroupScanner) IsDynamic() bool {
 	return false // Groups are relatively static
 }
 
+func (s *GroupScanner) SupportsOffline() bool {
+	return true
+}
+
 func (s *GroupScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
 	opts.Logger.Info("Starting group scan")
 
@@ -63,7 +69,13 @@ func (s *GroupScanner) getGroups(opts ScanOptions) (map[string]spec.GroupSpec, e
 		groupPath = "/etc/group"
 	}
 
-	file, err := os.Open(groupPath)
+	var file io.ReadCloser
+	var err error
+	if s.groupPath != "" {
+		file, err = os.Open(groupPath)
+	} else {
+		file, err = openSystemFile(opts, groupPath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open %s: %w", groupPath, err)
 	}
@@ -92,6 +104,7 @@ func (s *GroupScanner) getGroups(opts ScanOptions) (map[string]spec.GroupSpec, e
 
 		groupname := fields[0]
 		gidStr := fields[2]
+		members := fields[3]
 
 		// Parse GID
 		gid, err := strconv.Atoi(gidStr)
@@ -101,9 +114,10 @@ func (s *GroupScanner) getGroups(opts ScanOptions) (map[string]spec.GroupSpec, e
 		}
 
 		groups[groupname] = spec.GroupSpec{
-			Name:   groupname,
-			Exists: true,
-			GID:    gid,
+			Name:    groupname,
+			Exists:  true,
+			GID:     &gid,
+			Members: parseMembers(members),
 		}
 	}
 
@@ -113,3 +127,16 @@ func (s *GroupScanner) getGroups(opts ScanOptions) (map[string]spec.GroupSpec, e
 
 	return groups, nil
 }
+
+// parseMembers splits the comma-separated member list of a group entry,
+// sorted so that reordering the file is not reported as a change
+func parseMembers(field string) []string {
+	members := []string{}
+	for _, member := range strings.Split(field, ",") {
+		if member = strings.TrimSpace(member); member != "" {
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/supabase/supascan/internal/spec"
@@ -51,8 +52,8 @@ ubuntu:x:1000:
 	if !ok {
 		t.Fatalf("root group not found")
 	}
-	if root.GID != 0 {
-		t.Errorf("Expected root GID=0, got %d", root.GID)
+	if root.GID == nil || *root.GID != 0 {
+		t.Errorf("Expected root GID=0, got %v", root.GID)
 	}
 
 	// Check ubuntu group
@@ -60,8 +61,16 @@ ubuntu:x:1000:
 	if !ok {
 		t.Fatalf("ubuntu group not found")
 	}
-	if ubuntu.GID != 1000 {
-		t.Errorf("Expected ubuntu GID=1000, got %d", ubuntu.GID)
+	if ubuntu.GID == nil || *ubuntu.GID != 1000 {
+		t.Errorf("Expected ubuntu GID=1000, got %v", ubuntu.GID)
+	}
+
+	// Members are sorted, and a group without members has an empty list
+	if adm := results["adm"].Members; !reflect.DeepEqual(adm, []string{"syslog", "ubuntu"}) {
+		t.Errorf("Expected adm members [syslog ubuntu], got %v", adm)
+	}
+	if members := ubuntu.Members; members == nil || len(members) != 0 {
+		t.Errorf("Expected empty ubuntu members, got %#v", members)
 	}
 }
 
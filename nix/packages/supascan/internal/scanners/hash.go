@@ -0,0 +1,134 @@
+package scanners
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// DigestFS is implemented by filesystems that compute file digests
+// themselves, such as image filesystems that do not keep file content
+type DigestFS interface {
+	SHA256(name string) (string, error)
+}
+
+// FileSHA256 returns the hex-encoded sha256 of a file on the live system
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return readerSHA256(file)
+}
+
+// fsSHA256 returns the hex-encoded sha256 of a file within fsys
+func fsSHA256(fsys fs.FS, name string) (string, error) {
+	if digests, ok := fsys.(DigestFS); ok {
+		return digests.SHA256(name)
+	}
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return readerSHA256(file)
+}
+
+func readerSHA256(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashJob is a file spec waiting for its sha256
+type hashJob struct {
+	fsPath string // Path within the walked fs.FS
+	spec   spec.FileSpec
+}
+
+// fileHasher hashes files for the file scanner on a bounded pool of
+// goroutines, so reading file content does not hold up the walk. Each
+// completed job is passed to done, which writes the spec; the first error
+// from done stops the pool and is returned by submit and wait.
+type fileHasher struct {
+	fsys fs.FS
+	done func(job hashJob, hashErr error) error
+	jobs chan hashJob
+	wg   sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// startFileHasher starts workers goroutines hashing files within fsys
+func startFileHasher(fsys fs.FS, workers int, done func(job hashJob, hashErr error) error) *fileHasher {
+	h := &fileHasher{
+		fsys: fsys,
+		done: done,
+		jobs: make(chan hashJob, workers*4),
+	}
+	for i := 0; i < workers; i++ {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.work()
+		}()
+	}
+	return h
+}
+
+// work hashes queued files until the queue is closed. Jobs queued after a
+// failure are drained without being hashed.
+func (h *fileHasher) work() {
+	for job := range h.jobs {
+		if h.failed() != nil {
+			continue
+		}
+
+		digest, hashErr := fsSHA256(h.fsys, job.fsPath)
+		if hashErr == nil {
+			job.spec.Sha256 = digest
+		}
+		if err := h.done(job, hashErr); err != nil {
+			h.mu.Lock()
+			if h.err == nil {
+				h.err = err
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// submit queues a file for hashing. It is safe for concurrent use and
+// returns the pool's error once a job has failed.
+func (h *fileHasher) submit(job hashJob) error {
+	if err := h.failed(); err != nil {
+		return err
+	}
+	h.jobs <- job
+	return nil
+}
+
+// wait finishes the queued jobs and stops the workers
+func (h *fileHasher) wait() error {
+	close(h.jobs)
+	h.wg.Wait()
+	return h.failed()
+}
+
+func (h *fileHasher) failed() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
@@ -3,14 +3,19 @@ package scanners
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os/exec"
 	"strings"
 
 	"github.com/supabase/supascan/internal/spec"
 )
 
+// dpkgStatusPath is the dpkg database read when scanning an offline root
+const dpkgStatusPath = "/var/lib/dpkg/status"
+
 // PackageScanner scans all installed packages using dpkg.
 type PackageScanner struct {
 	stats ScanStats
@@ -24,6 +29,10 @@ func (s *PackageScanner) IsDynamic() bool {
 	return false // Package installations are relatively static
 }
 
+func (s *PackageScanner) SupportsOffline() bool {
+	return true
+}
+
 func (s *PackageScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
 	opts.Logger.Info("Starting package scan")
 
@@ -57,6 +66,11 @@ func (s *PackageScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats,
 
 // getInstalledPackages executes dpkg-query and returns parsed packages
 func (s *PackageScanner) getInstalledPackages(ctx context.Context, opts ScanOptions) (map[string]spec.PackageSpec, error) {
+	// dpkg-query would read the host's database, read the image's directly
+	if opts.RootFS != nil {
+		return s.getOfflinePackages(opts)
+	}
+
 	// Check if dpkg is available
 	dpkgPath, err := exec.LookPath("dpkg-query")
 	if err != nil {
@@ -136,3 +150,77 @@ func (s *PackageScanner) parsePackages(r io.Reader) (map[string]spec.PackageSpec
 
 	return packages, nil
 }
+
+// getOfflinePackages reads installed packages from the dpkg status file of
+// an offline root filesystem
+func (s *PackageScanner) getOfflinePackages(opts ScanOptions) (map[string]spec.PackageSpec, error) {
+	file, err := openSystemFile(opts, dpkgStatusPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		opts.Logger.Warn("dpkg status file not found, skipping package scan (not a Debian-based image?)")
+		return make(map[string]spec.PackageSpec), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", dpkgStatusPath, err)
+	}
+	defer file.Close()
+
+	return s.parseDpkgStatus(file)
+}
+
+// parseDpkgStatus parses a dpkg status file into PackageSpec map. Stanzas
+// are separated by blank lines; only the Package, Version and Status fields
+// are used.
+func (s *PackageScanner) parseDpkgStatus(r io.Reader) (map[string]spec.PackageSpec, error) {
+	packages := make(map[string]spec.PackageSpec)
+
+	var name, version, status string
+	flush := func() {
+		// Only include packages with status "install ok installed", as
+		// with dpkg-query
+		if name != "" && status == "install ok installed" {
+			packages[name] = spec.PackageSpec{
+				Name:      name,
+				Installed: true,
+				Versions:  []string{version},
+			}
+		}
+		name, version, status = "", "", ""
+	}
+
+	scanner := bufio.NewScanner(r)
+	// Description and Conffiles lines can be long
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		// Continuation lines belong to multi-line fields
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch field {
+		case "Package":
+			name = value
+		case "Version":
+			version = value
+		case "Status":
+			status = value
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading dpkg status: %w", err)
+	}
+
+	return packages, nil
+}
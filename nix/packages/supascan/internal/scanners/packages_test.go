@@ -1,8 +1,10 @@
 package scanners
 
 import (
+	"context"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/supabase/supascan/internal/spec"
 )
@@ -116,3 +118,58 @@ another-good-pkg	2.0	install ok installed
 		t.Errorf("Expected 2 valid packages, got %d", len(packages))
 	}
 }
+
+func TestPackageScanner_OfflineDpkgStatus(t *testing.T) {
+	status := `Package: bash
+Status: install ok installed
+Priority: required
+Version: 5.1-6ubuntu1
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+ .
+ Version: not a field
+
+Package: removed-pkg
+Status: deinstall ok config-files
+Version: 1.0
+
+Package: libc6
+Status: install ok installed
+Architecture: amd64
+Version: 2.35-0ubuntu3.8
+`
+
+	rootFS := fstest.MapFS{
+		"var/lib/dpkg/status": &fstest.MapFile{Data: []byte(status)},
+	}
+	writer := spec.NewTestWriter()
+	_, err := (&PackageScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: rootFS,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	packages := writer.GetPackageResults()
+	if len(packages) != 2 {
+		t.Fatalf("Expected 2 installed packages, got %d: %v", len(packages), packages)
+	}
+	if v := packages["bash"].Versions; len(v) != 1 || v[0] != "5.1-6ubuntu1" {
+		t.Errorf("Expected bash version 5.1-6ubuntu1, got %v", v)
+	}
+	if _, found := packages["removed-pkg"]; found {
+		t.Error("Packages that are not installed should be skipped")
+	}
+
+	// An image without dpkg yields no packages rather than an error
+	writer = spec.NewTestWriter()
+	if _, err := (&PackageScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: fstest.MapFS{},
+	}); err != nil {
+		t.Errorf("Expected no error without a dpkg status file, got %v", err)
+	}
+}
@@ -0,0 +1,455 @@
+package scanners
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// postgresConfigPath is the main PostgreSQL configuration file
+const postgresConfigPath = "/etc/postgresql/postgresql.conf"
+
+// maxPostgresIncludeDepth limits nested include directives, as PostgreSQL
+// does
+const maxPostgresIncludeDepth = 10
+
+// PostgresConfigScanner scans the PostgreSQL server configuration: every
+// setting of postgresql.conf after its include, include_if_exists and
+// include_dir directives and postgresql.auto.conf are applied, the client
+// authentication rules of pg_hba.conf and the user name maps of
+// pg_ident.conf. The hba and ident files are found through the hba_file
+// and ident_file settings.
+type PostgresConfigScanner struct {
+	rootPath string // For testing (default: "/")
+	stats    ScanStats
+}
+
+func (s *PostgresConfigScanner) Name() string {
+	return "postgres-config"
+}
+
+func (s *PostgresConfigScanner) IsDynamic() bool {
+	return false // The server configuration is static
+}
+
+func (s *PostgresConfigScanner) SupportsOffline() bool {
+	return true
+}
+
+func (s *PostgresConfigScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	opts.Logger.Info("Starting PostgreSQL configuration scan")
+	s.stats = ScanStats{}
+
+	writer, ok := opts.Writer.(Writer)
+	if !ok {
+		return s.stats, fmt.Errorf("writer does not implement Writer interface")
+	}
+
+	fsys := scanRoot(opts, s.rootPath)
+
+	settings, err := s.getSettings(fsys, opts)
+	if err != nil {
+		return s.stats, err
+	}
+	if settings == nil {
+		return s.stats, nil
+	}
+
+	if err := writer.StartResource("postgres-config"); err != nil {
+		return s.stats, err
+	}
+	for name, setting := range settings {
+		if err := writer.Add(setting); err != nil {
+			return s.stats, fmt.Errorf("failed to write postgres config spec for %s: %w", name, err)
+		}
+	}
+
+	// The hba and ident files default to the data directory, which is the
+	// configuration directory unless data_directory is set
+	dataDir := path.Dir(postgresConfigPath)
+	if setting, ok := settings["data_directory"]; ok {
+		dataDir = setting.Value
+	}
+	hbaPath := path.Join(dataDir, "pg_hba.conf")
+	if setting, ok := settings["hba_file"]; ok {
+		hbaPath = setting.Value
+	}
+	identPath := path.Join(dataDir, "pg_ident.conf")
+	if setting, ok := settings["ident_file"]; ok {
+		identPath = setting.Value
+	}
+
+	rules, err := s.getHbaRules(fsys, hbaPath, opts)
+	if err != nil {
+		return s.stats, err
+	}
+	if err := writer.StartResource("postgres-hba"); err != nil {
+		return s.stats, err
+	}
+	for id, rule := range rules {
+		if err := writer.Add(rule); err != nil {
+			return s.stats, fmt.Errorf("failed to write postgres hba spec for %s: %w", id, err)
+		}
+	}
+
+	maps, err := s.getIdentMaps(fsys, identPath, opts)
+	if err != nil {
+		return s.stats, err
+	}
+	if err := writer.StartResource("postgres-ident"); err != nil {
+		return s.stats, err
+	}
+	for id, m := range maps {
+		if err := writer.Add(m); err != nil {
+			return s.stats, fmt.Errorf("failed to write postgres ident spec for %s: %w", id, err)
+		}
+	}
+
+	opts.Logger.Info("PostgreSQL configuration scan complete",
+		"settings_found", len(settings), "hba_rules_found", len(rules), "ident_maps_found", len(maps))
+
+	return s.stats, nil
+}
+
+// skip reports whether a configuration file that cannot be read should be
+// skipped, see skipUnreadable
+func (s *PostgresConfigScanner) skip(opts ScanOptions, name string, err error) bool {
+	return skipUnreadable(opts, &s.stats, "PostgreSQL configuration file", name, err)
+}
+
+// getSettings parses postgresql.conf and then postgresql.auto.conf. It
+// returns nil if PostgreSQL is not configured on this system.
+func (s *PostgresConfigScanner) getSettings(fsys fs.FS, opts ScanOptions) (map[string]spec.PostgresConfigSpec, error) {
+	settings := make(map[string]spec.PostgresConfigSpec)
+
+	if err := s.parseConfFile(fsys, postgresConfigPath, settings, opts, 0); err != nil {
+		if s.skip(opts, postgresConfigPath, err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// ALTER SYSTEM writes postgresql.auto.conf in the data directory, read
+	// after postgresql.conf so its settings win
+	if setting, ok := settings["data_directory"]; ok {
+		autoConf := path.Join(setting.Value, "postgresql.auto.conf")
+		if err := s.parseConfFile(fsys, autoConf, settings, opts, 0); err != nil && !s.skip(opts, autoConf, err) {
+			return nil, err
+		}
+	}
+
+	return settings, nil
+}
+
+// parseConfFile parses one postgresql.conf style file into
+// settings. Later settings replace earlier ones, as in PostgreSQL.
+func (s *PostgresConfigScanner) parseConfFile(fsys fs.FS, name string, settings map[string]spec.PostgresConfigSpec, opts ScanOptions, depth int) error {
+	if depth > maxPostgresIncludeDepth {
+		return fmt.Errorf("PostgreSQL include nested too deeply at %s", name)
+	}
+
+	data, err := fs.ReadFile(fsys, strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		key, value, ok := splitPostgresSetting(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "include", "include_if_exists":
+			target := resolveIncludePath(name, value)
+			if err := s.parseConfFile(fsys, target, settings, opts, depth+1); err != nil {
+				if key == "include_if_exists" && errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				if !s.skip(opts, target, err) {
+					return err
+				}
+			}
+		case "include_dir":
+			dir := resolveIncludePath(name, value)
+			for _, file := range s.includeDir(fsys, dir, opts) {
+				if err := s.parseConfFile(fsys, file, settings, opts, depth+1); err != nil && !s.skip(opts, file, err) {
+					return err
+				}
+			}
+		default:
+			settings[key] = spec.PostgresConfigSpec{Name: key, Value: value}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// splitPostgresSetting parses a "name = value" line. The "=" is optional,
+// names are case-insensitive, and values may be single-quoted strings or
+// bare words, followed by an optional comment.
+func splitPostgresSetting(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end <= 0 {
+		return "", "", false
+	}
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	if strings.HasPrefix(rest, "'") {
+		var value strings.Builder
+		for i := 1; i < len(rest); i++ {
+			switch c := rest[i]; {
+			case c == '\'' && i+1 < len(rest) && rest[i+1] == '\'':
+				value.WriteByte('\'')
+				i++
+			case c == '\'':
+				return key, value.String(), true
+			case c == '\\' && i+1 < len(rest):
+				value.WriteByte(rest[i+1])
+				i++
+			default:
+				value.WriteByte(c)
+			}
+		}
+		// Unterminated string
+		return "", "", false
+	}
+
+	value := rest
+	if end := strings.IndexAny(value, " \t#"); end >= 0 {
+		value = value[:end]
+	}
+	if value == "" {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// includeDir lists the files of an include_dir in the order they are read:
+// names ending in ".conf", not hidden, sorted
+func (s *PostgresConfigScanner) includeDir(fsys fs.FS, dir string, opts ScanOptions) []string {
+	files, err := includeDirFiles(fsys, dir, func(name string) bool {
+		return !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".conf")
+	})
+	if err != nil {
+		s.skip(opts, dir, err)
+	}
+	return files
+}
+
+// getHbaRules parses the client authentication rules of pg_hba.conf
+func (s *PostgresConfigScanner) getHbaRules(fsys fs.FS, hbaPath string, opts ScanOptions) (map[string]spec.PostgresHbaSpec, error) {
+	rules := make(map[string]spec.PostgresHbaSpec)
+
+	lines, err := s.readAuthFile(fsys, hbaPath, opts, 0)
+	if err != nil {
+		if s.skip(opts, hbaPath, err) {
+			return rules, nil
+		}
+		return nil, err
+	}
+
+	order := 0
+	for _, fields := range lines {
+		rule, ok := parseHbaRule(fields)
+		if !ok {
+			opts.Logger.Debug("Skipping malformed pg_hba.conf line", "line", strings.Join(fields, " "))
+			continue
+		}
+
+		order++
+		rule.Order = order
+		// Only the first of several rules for the same connections is ever
+		// used
+		if _, exists := rules[rule.ID]; exists {
+			opts.Logger.Debug("Skipping unreachable pg_hba.conf rule", "rule", rule.ID)
+			continue
+		}
+		rules[rule.ID] = rule
+	}
+
+	return rules, nil
+}
+
+// parseHbaRule parses the fields of one pg_hba.conf line:
+// "local database user method [options]" or
+// "host* database user address [mask] method [options]"
+func parseHbaRule(fields []string) (spec.PostgresHbaSpec, bool) {
+	if len(fields) < 4 {
+		return spec.PostgresHbaSpec{}, false
+	}
+
+	rule := spec.PostgresHbaSpec{
+		Type:     fields[0],
+		Database: fields[1],
+		User:     fields[2],
+	}
+	rest := fields[3:]
+
+	switch rule.Type {
+	case "local":
+	case "host", "hostssl", "hostnossl", "hostgssenc", "hostnogssenc":
+		if len(rest) < 2 {
+			return spec.PostgresHbaSpec{}, false
+		}
+		rule.Address = rest[0]
+		rest = rest[1:]
+		// An IP address may be followed by a separate netmask
+		if net.ParseIP(rule.Address) != nil && len(rest) > 1 && net.ParseIP(rest[0]) != nil {
+			rule.Address += " " + rest[0]
+			rest = rest[1:]
+		}
+	default:
+		return spec.PostgresHbaSpec{}, false
+	}
+
+	rule.Method = rest[0]
+	rule.Options = strings.Join(rest[1:], " ")
+	rule.ID = spec.PostgresHbaID(rule.Type, rule.Database, rule.User, rule.Address)
+	return rule, true
+}
+
+// getIdentMaps parses the user name maps of pg_ident.conf
+func (s *PostgresConfigScanner) getIdentMaps(fsys fs.FS, identPath string, opts ScanOptions) (map[string]spec.PostgresIdentSpec, error) {
+	maps := make(map[string]spec.PostgresIdentSpec)
+
+	lines, err := s.readAuthFile(fsys, identPath, opts, 0)
+	if err != nil {
+		if s.skip(opts, identPath, err) {
+			return maps, nil
+		}
+		return nil, err
+	}
+
+	for _, fields := range lines {
+		if len(fields) != 3 {
+			opts.Logger.Debug("Skipping malformed pg_ident.conf line", "line", strings.Join(fields, " "))
+			continue
+		}
+		m := spec.PostgresIdentSpec{
+			ID:         strings.Join(fields, " "),
+			Map:        fields[0],
+			SystemUser: fields[1],
+			PgUser:     fields[2],
+		}
+		maps[m.ID] = m
+	}
+
+	return maps, nil
+}
+
+// readAuthFile reads the fields of each line of pg_hba.conf or
+// pg_ident.conf, following their include, include_if_exists and
+// include_dir directives. Fields are separated by whitespace and may be
+// double-quoted, "#" starts a comment, and a trailing backslash continues a
+// line.
+func (s *PostgresConfigScanner) readAuthFile(fsys fs.FS, name string, opts ScanOptions, depth int) ([][]string, error) {
+	if depth > maxPostgresIncludeDepth {
+		return nil, fmt.Errorf("PostgreSQL include nested too deeply at %s", name)
+	}
+
+	data, err := fs.ReadFile(fsys, strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	var lines [][]string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	var pending string
+
+	for scanner.Scan() {
+		line := pending + scanner.Text()
+		pending = ""
+		if strings.HasSuffix(line, "\\") {
+			pending = strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+
+		fields := splitPostgresAuthLine(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) == 2 {
+			switch fields[0] {
+			case "include", "include_if_exists":
+				target := resolveIncludePath(name, fields[1])
+				included, err := s.readAuthFile(fsys, target, opts, depth+1)
+				if err != nil {
+					if fields[0] == "include_if_exists" && errors.Is(err, fs.ErrNotExist) {
+						continue
+					}
+					if !s.skip(opts, target, err) {
+						return nil, err
+					}
+				}
+				lines = append(lines, included...)
+				continue
+			case "include_dir":
+				dir := resolveIncludePath(name, fields[1])
+				for _, file := range s.includeDir(fsys, dir, opts) {
+					included, err := s.readAuthFile(fsys, file, opts, depth+1)
+					if err != nil && !s.skip(opts, file, err) {
+						return nil, err
+					}
+					lines = append(lines, included...)
+				}
+				continue
+			}
+		}
+
+		lines = append(lines, fields)
+	}
+
+	return lines, scanner.Err()
+}
+
+// splitPostgresAuthLine splits a pg_hba.conf or pg_ident.conf line into its
+// fields. Quotes are removed; a quoted field keeps its whitespace and "#".
+func splitPostgresAuthLine(line string) []string {
+	var fields []string
+	var field strings.Builder
+	inField, quoted := false, false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inField = true
+		case quoted:
+			field.WriteByte(c)
+		case c == '#':
+			i = len(line)
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteByte(c)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
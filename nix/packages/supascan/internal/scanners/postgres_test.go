@@ -0,0 +1,196 @@
+package scanners
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+const testPostgresConf = `# PostgreSQL configuration
+data_directory = '/var/lib/postgresql/data'
+hba_file = '/etc/postgresql/pg_hba.conf'	# host-based authentication file
+ident_file = '/etc/postgresql/pg_ident.conf'
+
+listen_addresses = '*'
+Max_Connections = 100
+shared_buffers 128MB
+ssl = off
+search_path = '"$user", public'
+log_line_prefix = '%m [%p] it''s here '
+
+include = 'logging.conf'
+include_if_exists = '/etc/postgresql-custom/missing.conf'
+include_dir = '/etc/postgresql-custom/conf.d'
+`
+
+const testPgHba = `# TYPE  DATABASE        USER            ADDRESS                 METHOD
+local   all             supabase_admin                          scram-sha-256
+local   all             all                                     peer map=supabase_map
+host    all             all             127.0.0.1/32            trust
+host    all             all             10.0.0.0 255.0.0.0      scram-sha-256
+hostssl "my db"         all             0.0.0.0/0               scram-sha-256 \
+        clientcert=verify-full
+host    all             all             127.0.0.1/32            md5
+include_if_exists pg_hba_extra.conf
+garbage
+`
+
+func testPostgresFS() fstest.MapFS {
+	return fstest.MapFS{
+		"etc/postgresql/postgresql.conf":                      {Data: []byte(testPostgresConf)},
+		"etc/postgresql/logging.conf":                         {Data: []byte("log_destination = 'csvlog'\nssl = on\n")},
+		"etc/postgresql-custom/conf.d/20-second.conf":         {Data: []byte("work_mem = '8MB'\n")},
+		"etc/postgresql-custom/conf.d/10-first.conf":          {Data: []byte("work_mem = '4MB'\nmax_connections = 200\n")},
+		"etc/postgresql-custom/conf.d/README":                 {Data: []byte("work_mem = '1GB'\n")},
+		"var/lib/postgresql/data/postgresql.auto.conf":        {Data: []byte("# Do not edit this file manually!\nshared_buffers = '1GB'\n")},
+		"etc/postgresql/pg_hba.conf":                          {Data: []byte(testPgHba)},
+		"etc/postgresql/pg_hba_extra.conf":                    {Data: []byte("host replication replicator 10.0.0.0/8 scram-sha-256\n")},
+		"etc/postgresql/pg_ident.conf":                        {Data: []byte("# MAPNAME SYSTEM-USERNAME PG-USERNAME\nsupabase_map postgres postgres\nsupabase_map gotrue supabase_auth_admin\n")},
+		"etc/postgresql-custom/conf.d/.hidden.conf":           {Data: []byte("work_mem = '2GB'\n")},
+		"etc/postgresql-custom/conf.d/subdir/ignored.conf":    {Data: []byte("work_mem = '3GB'\n")},
+		"var/lib/postgresql/data/pg_hba.conf":                 {Data: []byte("local all all trust\n")},
+		"etc/postgresql-custom/conf.d/30-empty.conf":          {Data: []byte("\n")},
+		"etc/postgresql-custom/conf.d/40-comment-only.conf":   {Data: []byte("# nothing here\n")},
+		"etc/postgresql-custom/conf.d/50-unterminated.conf":   {Data: []byte("bad_value = 'oops\n")},
+		"etc/postgresql-custom/conf.d/60-custom-guc.conf":     {Data: []byte("supautils.reserved_roles = 'supabase_admin'\n")},
+		"etc/postgresql-custom/conf.d/70-no-equals.conf":      {Data: []byte("port 5432\n")},
+		"etc/postgresql-custom/conf.d/80-trailing-space.conf": {Data: []byte("timezone = 'UTC'   \n")},
+	}
+}
+
+func TestPostgresConfigScanner_Settings(t *testing.T) {
+	writer := spec.NewTestWriter()
+	_, err := (&PostgresConfigScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: testPostgresFS(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetPostgresConfigResults()
+	expected := map[string]string{
+		"data_directory":           "/var/lib/postgresql/data",
+		"listen_addresses":         "*",
+		"max_connections":          "200", // include_dir is read after the main file
+		"shared_buffers":           "1GB", // postgresql.auto.conf wins
+		"ssl":                      "on",  // included after the main file's setting
+		"work_mem":                 "8MB", // include_dir files are read in order
+		"search_path":              `"$user", public`,
+		"log_line_prefix":          "%m [%p] it's here ",
+		"log_destination":          "csvlog",
+		"supautils.reserved_roles": "supabase_admin",
+		"port":                     "5432",
+		"timezone":                 "UTC",
+	}
+	for name, want := range expected {
+		if got, ok := results[name]; !ok || got.Value != want {
+			t.Errorf("%s = %q, want %q", name, got.Value, want)
+		}
+	}
+	for _, name := range []string{"include", "include_if_exists", "include_dir", "bad_value"} {
+		if _, ok := results[name]; ok {
+			t.Errorf("Did not expect a %s setting", name)
+		}
+	}
+}
+
+func TestPostgresConfigScanner_Hba(t *testing.T) {
+	writer := spec.NewTestWriter()
+	_, err := (&PostgresConfigScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: testPostgresFS(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetPostgresHbaResults()
+	expected := map[string]spec.PostgresHbaSpec{
+		"local all supabase_admin":        {Order: 1, Type: "local", Database: "all", User: "supabase_admin", Method: "scram-sha-256"},
+		"local all all":                   {Order: 2, Type: "local", Database: "all", User: "all", Method: "peer", Options: "map=supabase_map"},
+		"host all all 127.0.0.1/32":       {Order: 3, Type: "host", Database: "all", User: "all", Address: "127.0.0.1/32", Method: "trust"},
+		"host all all 10.0.0.0 255.0.0.0": {Order: 4, Type: "host", Database: "all", User: "all", Address: "10.0.0.0 255.0.0.0", Method: "scram-sha-256"},
+		"hostssl my db all 0.0.0.0/0": {Order: 5, Type: "hostssl", Database: "my db", User: "all", Address: "0.0.0.0/0",
+			Method: "scram-sha-256", Options: "clientcert=verify-full"},
+		// The later 127.0.0.1/32 rule is unreachable and not recorded
+		"host replication replicator 10.0.0.0/8": {Order: 7, Type: "host", Database: "replication", User: "replicator", Address: "10.0.0.0/8", Method: "scram-sha-256"},
+	}
+	if len(results) != len(expected) {
+		t.Errorf("Expected %d rules, got %d: %v", len(expected), len(results), results)
+	}
+	for id, want := range expected {
+		want.ID = id
+		if got := results[id]; got != want {
+			t.Errorf("Rule %q = %+v, want %+v", id, got, want)
+		}
+	}
+
+	idents := writer.GetPostgresIdentResults()
+	if len(idents) != 2 {
+		t.Errorf("Expected 2 ident maps, got %v", idents)
+	}
+	if m := idents["supabase_map gotrue supabase_auth_admin"]; m.Map != "supabase_map" || m.SystemUser != "gotrue" || m.PgUser != "supabase_auth_admin" {
+		t.Errorf("Unexpected ident map: %+v", m)
+	}
+}
+
+func TestPostgresConfigScanner_NotInstalled(t *testing.T) {
+	writer := spec.NewTestWriter()
+	_, err := (&PostgresConfigScanner{rootPath: t.TempDir()}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := writer.GetResourceCount(); n != 0 {
+		t.Errorf("Expected no resources without postgresql.conf, got %d", n)
+	}
+}
+
+func TestPostgresConfigScanner_IncludeLoop(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/postgresql/postgresql.conf": {Data: []byte("include 'postgresql.conf'\n")},
+	}
+
+	_, err := (&PostgresConfigScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: spec.NewTestWriter(),
+		Logger: testLogger(),
+		RootFS: fsys,
+	})
+	if err == nil {
+		t.Error("Expected an error for a recursive include")
+	}
+}
+
+func TestSplitPostgresAuthLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"# comment", nil},
+		{"local all all peer # trailing", []string{"local", "all", "all", "peer"}},
+		{`host "db # one" all 0.0.0.0/0 md5`, []string{"host", "db # one", "all", "0.0.0.0/0", "md5"}},
+		{"\thost\tall  all\t::1/128\ttrust", []string{"host", "all", "all", "::1/128", "trust"}},
+	}
+
+	for _, tt := range tests {
+		got := splitPostgresAuthLine(tt.line)
+		if len(got) != len(tt.want) {
+			t.Errorf("splitPostgresAuthLine(%q) = %q, want %q", tt.line, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("splitPostgresAuthLine(%q) = %q, want %q", tt.line, got, tt.want)
+				break
+			}
+		}
+	}
+}
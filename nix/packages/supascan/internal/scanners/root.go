@@ -0,0 +1,390 @@
+package scanners
+
+import (
+	"archive/tar"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// OfflineScanner is implemented by scanners that can read an offline root
+// filesystem (ScanOptions.RootFS). Scanners that describe the running system,
+// such as services or kernel parameters, do not implement it and are skipped
+// when scanning offline.
+type OfflineScanner interface {
+	SupportsOffline() bool
+}
+
+// supportsOffline reports whether a scanner can run against ScanOptions.RootFS
+func supportsOffline(scanner Scanner) bool {
+	offline, ok := scanner.(OfflineScanner)
+	return ok && offline.SupportsOffline()
+}
+
+// openSystemFile opens a system file such as /etc/passwd, from the offline
+// root filesystem if one is being scanned
+func openSystemFile(opts ScanOptions, path string) (io.ReadCloser, error) {
+	if opts.RootFS != nil {
+		return opts.RootFS.Open(strings.TrimPrefix(path, "/"))
+	}
+	return os.Open(path)
+}
+
+// ReadLinkFS is implemented by filesystems that can read symlink targets.
+// It matches fs.ReadLinkFS from newer Go releases.
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// maxSymlinkHops bounds symlink resolution, as the kernel's ELOOP limit does
+const maxSymlinkHops = 40
+
+// DirFS returns a filesystem for the tree rooted at dir, like os.DirFS, that
+// also implements ReadLinkFS and XattrFS. Unless dir is "/", symlinks are
+// resolved within dir, as they would be on the booted system: an absolute
+// link in an offline root points into the root, not into the host.
+func DirFS(dir string) fs.FS {
+	return dirFS{dir: dir}
+}
+
+type dirFS struct {
+	dir string
+}
+
+func (d dirFS) Open(name string) (fs.File, error) {
+	p, err := d.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, rename(err, name)
+	}
+	return f, nil
+}
+
+func (d dirFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := d.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, rename(err, name)
+	}
+	return info, nil
+}
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	p, err := d.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, rename(err, name)
+	}
+	return data, nil
+}
+
+func (d dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := d.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return entries, rename(err, name)
+	}
+	return entries, nil
+}
+
+func (d dirFS) ReadLink(name string) (string, error) {
+	p, err := d.resolve("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(p)
+}
+
+func (d dirFS) Getxattr(name, attr string) ([]byte, error) {
+	p, err := d.resolve("getxattr", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return getxattr(p, attr)
+}
+
+// resolve maps a path within the filesystem to a host path, following
+// symlinks in every component and, if followLast is set, in the last one.
+// Links, absolute or with "..", cannot leave dir.
+func (d dirFS) resolve(op, name string, followLast bool) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if d.dir == "/" {
+		return filepath.Join(d.dir, filepath.FromSlash(name)), nil
+	}
+
+	var parts []string
+	if name != "." {
+		parts = strings.Split(name, "/")
+	}
+
+	var resolved []string
+	hops := 0
+	for i := 0; i < len(parts); i++ {
+		switch parts[i] {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) > 0 {
+				resolved = resolved[:len(resolved)-1]
+			}
+			continue
+		}
+
+		next := append(resolved, parts[i])
+		if i == len(parts)-1 && !followLast {
+			resolved = next
+			continue
+		}
+		// A missing component is left for the caller's operation to report
+		p := filepath.Join(append([]string{d.dir}, next...)...)
+		info, err := os.Lstat(p)
+		if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return "", &fs.PathError{Op: op, Path: name, Err: errors.New("too many levels of symbolic links")}
+		}
+		target, err := os.Readlink(p)
+		if err != nil {
+			return "", &fs.PathError{Op: op, Path: name, Err: err}
+		}
+		// Continue with the link target followed by the remaining parts
+		if path.IsAbs(target) {
+			resolved = resolved[:0]
+		}
+		parts = append(strings.Split(strings.TrimPrefix(target, "/"), "/"), parts[i+1:]...)
+		i = -1
+	}
+
+	return filepath.Join(append([]string{d.dir}, resolved...)...), nil
+}
+
+// rename reports an error of an operation on a host path with the path
+// within the filesystem, as os.DirFS does
+func rename(err error, name string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		pathErr.Path = name
+	}
+	return err
+}
+
+// readLink returns the target of a symlink within fsys
+func readLink(fsys fs.FS, name string) (string, error) {
+	links, ok := fsys.(ReadLinkFS)
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: errors.ErrUnsupported}
+	}
+	return links.ReadLink(name)
+}
+
+// linkTargetExists reports whether the target of the symlink at name exists
+// within fsys. Absolute targets are resolved against the root of fsys.
+func linkTargetExists(fsys fs.FS, name, target string) bool {
+	if path.IsAbs(target) {
+		target = path.Clean(target)
+	} else {
+		target = path.Join("/", path.Dir(name), target)
+	}
+	target = strings.TrimPrefix(target, "/")
+	if target == "" {
+		target = "."
+	}
+	_, err := fs.Stat(fsys, target)
+	return err == nil
+}
+
+// rootPath maps a path within an fs.FS walked from "." to the absolute path
+// reported in specs, e.g. "etc/passwd" under prefix "/" to "/etc/passwd"
+func rootPath(prefix, fsPath string) string {
+	if fsPath == "." {
+		return prefix
+	}
+	return filepath.Join(prefix, filepath.FromSlash(fsPath))
+}
+
+// idNames resolves numeric owners against an offline root filesystem's
+// /etc/passwd and /etc/group instead of the host's NSS
+type idNames struct {
+	users  map[uint32]string
+	groups map[uint32]string
+}
+
+// loadIDNames reads the user and group names of an offline root filesystem.
+// Missing files leave the ids unresolved, so they are reported numerically.
+func loadIDNames(fsys fs.FS) *idNames {
+	return &idNames{
+		users:  readIDFile(fsys, "etc/passwd"),
+		groups: readIDFile(fsys, "etc/group"),
+	}
+}
+
+// readIDFile maps the id in the third field of a passwd or group file to the
+// name in the first
+func readIDFile(fsys fs.FS, path string) map[uint32]string {
+	names := make(map[uint32]string)
+
+	file, err := fsys.Open(path)
+	if err != nil {
+		return names
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		// The first entry wins, as with getpwuid
+		if _, ok := names[uint32(id)]; !ok {
+			names[uint32(id)] = fields[0]
+		}
+	}
+
+	return names
+}
+
+// username returns the name for a UID (or the UID as a string if unknown)
+func (n *idNames) username(uid uint32) string {
+	if name, ok := n.users[uid]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", uid)
+}
+
+// groupname returns the name for a GID (or the GID as a string if unknown)
+func (n *idNames) groupname(gid uint32) string {
+	if name, ok := n.groups[gid]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", gid)
+}
+
+// hasUser reports whether a UID has a passwd entry
+func (n *idNames) hasUser(uid uint32) bool {
+	_, ok := n.users[uid]
+	return ok
+}
+
+// hasGroup reports whether a GID has a group entry
+func (n *idNames) hasGroup(gid uint32) bool {
+	_, ok := n.groups[gid]
+	return ok
+}
+
+// fileOwnerIDs extracts the numeric owner and group of a file
+func fileOwnerIDs(info fs.FileInfo) (uid, gid uint32, ok bool) {
+	switch sys := info.Sys().(type) {
+	case *syscall.Stat_t:
+		return sys.Uid, sys.Gid, true
+	case *tar.Header:
+		// Image filesystems report ownership from the layer tarballs
+		return uint32(sys.Uid), uint32(sys.Gid), true
+	default:
+		return 0, 0, false
+	}
+}
+
+// scanRoot returns the filesystem a scanner reads system files from: the
+// offline root filesystem if one is being scanned, otherwise rootPath, which
+// scanners set for testing and defaults to "/"
+func scanRoot(opts ScanOptions, rootPath string) fs.FS {
+	if opts.RootFS != nil {
+		return opts.RootFS
+	}
+	if rootPath == "" {
+		rootPath = "/"
+	}
+	return DirFS(rootPath)
+}
+
+// skipUnreadable reports whether a file or directory that cannot be read
+// should be skipped: a missing one always is, an unreadable one (many are
+// only readable by root) with a warning in stats unless in strict mode.
+// kind names what is being read in log messages, e.g. "sudoers file".
+func skipUnreadable(opts ScanOptions, stats *ScanStats, kind, name string, err error) bool {
+	if errors.Is(err, fs.ErrNotExist) {
+		opts.Logger.Debug("Skipping missing "+kind, "path", name)
+		return true
+	}
+	if !opts.Strict && errors.Is(err, fs.ErrPermission) {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: %v, skipped", name, fs.ErrPermission))
+		opts.Logger.Warn("Cannot read "+kind+", skipping", "path", name, "error", err)
+		return true
+	}
+	return false
+}
+
+// warnLine records a line of a configuration file that could not be parsed
+func warnLine(opts ScanOptions, stats *ScanStats, kind, source string, lineNum int, reason string) {
+	stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: %s", source, lineNum, reason))
+	opts.Logger.Warn("Skipping unparseable "+kind+" line", "path", source, "line", lineNum, "reason", reason)
+}
+
+// resolveIncludePath resolves an included path against the directory of
+// the file including it
+func resolveIncludePath(from, target string) string {
+	if path.IsAbs(target) {
+		return path.Clean(target)
+	}
+	return path.Join(path.Dir(from), target)
+}
+
+// includeDirFiles lists the files of an included directory whose names keep
+// accepts, sorted as they are read
+func includeDirFiles(fsys fs.FS, dir string, keep func(name string) bool) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, strings.TrimPrefix(dir, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !isDir(fsys, dir, entry) && keep(entry.Name()) {
+			files = append(files, path.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isDir reports whether an entry of dir is a directory, or a symlink to one
+func isDir(fsys fs.FS, dir string, entry fs.DirEntry) bool {
+	if entry.Type()&fs.ModeSymlink == 0 {
+		return entry.IsDir()
+	}
+	info, err := fs.Stat(fsys, strings.TrimPrefix(path.Join(dir, entry.Name()), "/"))
+	return err == nil && info.IsDir()
+}
@@ -3,6 +3,9 @@ package scanners
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/log"
@@ -46,6 +49,28 @@ type ScanOptions struct {
 
 	// Logger for diagnostic output
 	Logger *log.Logger
+
+	// Workers is the number of scanners RunAll runs concurrently. Values
+	// below 2 run the scanners one after another, as does a Writer that does
+	// not implement ResourceWriter.
+	Workers int
+
+	// RootFS, when set, is an offline root filesystem (a mounted image or a
+	// chroot) to scan instead of the live system. Paths are reported as they
+	// would be on the booted system, and scanners that do not implement
+	// OfflineScanner are skipped.
+	RootFS fs.FS
+
+	// WalkWorkers is the number of directories the file scanner reads
+	// concurrently. Values below 2 use a sequential filepath.WalkDir, as
+	// does a Writer that does not implement ResourceWriter.
+	WalkWorkers int
+
+	// HashWorkers is the number of files the file scanner hashes
+	// concurrently when config.Config.HashPaths selects any. Values below 2
+	// hash each file during the walk, as does a Writer that does not
+	// implement ResourceWriter.
+	HashWorkers int
 }
 
 // ScanStats contains aggregate statistics from scanner runs
@@ -78,9 +103,15 @@ var AllScanners = []Scanner{
 	&FileScanner{},
 	&PackageScanner{},
 	&ServiceScanner{},
+	&SystemdUnitScanner{},
+	&CronScanner{},
 	&UserScanner{},
 	&GroupScanner{},
+	&ShadowScanner{},
+	&SudoersScanner{},
 	&KernelParamScanner{},
+	&SshdScanner{},
+	&PostgresConfigScanner{},
 	&MountScanner{},
 	&CommandScanner{},
 
@@ -89,34 +120,63 @@ var AllScanners = []Scanner{
 	&ProcessScanner{},
 }
 
-// RunAll executes all registered scanners and returns aggregate statistics
+// scanOutcome is the result of running one scanner
+type scanOutcome struct {
+	ran   bool
+	stats ScanStats
+	err   error
+}
+
+// RunAll executes all registered scanners and returns aggregate statistics.
+// With opts.Workers above 1 the scanners run concurrently; statistics are
+// still aggregated in registry order, so they match a sequential run.
 func RunAll(ctx context.Context, opts ScanOptions) (ScanStats, error) {
 	startTime := time.Now()
 	var aggregateStats ScanStats
 
+	var selected []Scanner
 	for _, scanner := range AllScanners {
 		// Skip dynamic scanners unless explicitly included
 		if scanner.IsDynamic() && !opts.IncludeDynamic {
 			opts.Logger.Debug("Skipping dynamic scanner", "scanner", scanner.Name())
 			continue
 		}
+		// Skip scanners that can only read the running system
+		if opts.RootFS != nil && !supportsOffline(scanner) {
+			opts.Logger.Info("Skipping scanner not supported offline", "scanner", scanner.Name())
+			continue
+		}
+		selected = append(selected, scanner)
+	}
 
-		opts.Logger.Info("Running scanner", "scanner", scanner.Name())
+	var outcomes []scanOutcome
+	if rw, ok := opts.Writer.(ResourceWriter); ok && opts.Workers > 1 {
+		outcomes = runConcurrent(ctx, selected, opts, rw)
+	} else {
+		outcomes = runSequential(ctx, selected, opts)
+	}
 
-		stats, err := scanner.Scan(ctx, opts)
-		if err != nil {
+	for i, outcome := range outcomes {
+		if !outcome.ran {
+			// Only scanners after a strict-mode failure are left unrun
+			break
+		}
+		scanner := selected[i]
+
+		if outcome.err != nil {
 			if opts.Strict {
 				// In strict mode, fail fast
-				return aggregateStats, fmt.Errorf("scanner %s failed: %w", scanner.Name(), err)
+				return aggregateStats, fmt.Errorf("scanner %s failed: %w", scanner.Name(), outcome.err)
 			}
 			// In non-strict mode, log error and continue
-			warning := fmt.Sprintf("Scanner %s failed: %v", scanner.Name(), err)
+			warning := fmt.Sprintf("Scanner %s failed: %v", scanner.Name(), outcome.err)
 			aggregateStats.Warnings = append(aggregateStats.Warnings, warning)
-			opts.Logger.Warn("Scanner failed", "scanner", scanner.Name(), "error", err)
+			opts.Logger.Warn("Scanner failed", "scanner", scanner.Name(), "error", outcome.err)
 			continue
 		}
 
 		// Aggregate statistics
+		stats := outcome.stats
 		aggregateStats.ScannersRun++
 		aggregateStats.FilesScanned += stats.FilesScanned
 		aggregateStats.FilesSkipped += stats.FilesSkipped
@@ -128,3 +188,58 @@ func RunAll(ctx context.Context, opts ScanOptions) (ScanStats, error) {
 	aggregateStats.Duration = time.Since(startTime)
 	return aggregateStats, nil
 }
+
+// runSequential runs the scanners one after another, stopping at the first
+// failure in strict mode
+func runSequential(ctx context.Context, selected []Scanner, opts ScanOptions) []scanOutcome {
+	outcomes := make([]scanOutcome, len(selected))
+	for i, scanner := range selected {
+		opts.Logger.Info("Running scanner", "scanner", scanner.Name())
+
+		stats, err := scanner.Scan(ctx, opts)
+		outcomes[i] = scanOutcome{ran: true, stats: stats, err: err}
+		if err != nil && opts.Strict {
+			break
+		}
+	}
+	return outcomes
+}
+
+// runConcurrent runs the scanners on up to opts.Workers goroutines. Each
+// scanner writes through its own scopedWriter. Scanners are started in
+// registry order and, in strict mode, none are started after a failure.
+func runConcurrent(ctx context.Context, selected []Scanner, opts ScanOptions, rw ResourceWriter) []scanOutcome {
+	outcomes := make([]scanOutcome, len(selected))
+	slots := make(chan struct{}, opts.Workers)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+
+	for i, scanner := range selected {
+		slots <- struct{}{}
+		if opts.Strict && failed.Load() {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, scanner Scanner) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			opts.Logger.Info("Running scanner", "scanner", scanner.Name())
+
+			scanOpts := opts
+			scanOpts.Writer = newScopedWriter(rw)
+			stats, err := scanner.Scan(ctx, scanOpts)
+			outcomes[i] = scanOutcome{ran: true, stats: stats, err: err}
+			if err != nil {
+				failed.Store(true)
+			}
+		}(i, scanner)
+	}
+
+	wg.Wait()
+	return outcomes
+}
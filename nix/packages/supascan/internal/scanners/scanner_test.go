@@ -2,10 +2,17 @@ package scanners
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"reflect"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/charmbracelet/log"
+
+	"github.com/supabase/supascan/internal/spec"
 )
 
 // mockStaticScanner implements Scanner for testing
@@ -80,6 +87,146 @@ func TestRunAll_SkipsDynamic(t *testing.T) {
 	}
 }
 
+// mockOfflineScanner can read an offline root filesystem
+type mockOfflineScanner struct {
+	mockStaticScanner
+}
+
+func (m *mockOfflineScanner) SupportsOffline() bool {
+	return true
+}
+
+func TestRunAll_OfflineRootSkipsLiveScanners(t *testing.T) {
+	originalScanners := AllScanners
+	defer func() { AllScanners = originalScanners }()
+
+	AllScanners = []Scanner{
+		&mockOfflineScanner{mockStaticScanner{name: "offline"}},
+		&mockStaticScanner{name: "live-only"},
+	}
+
+	stats, err := RunAll(context.Background(), ScanOptions{
+		Writer: &mockWriter{},
+		Logger: testLogger(),
+		RootFS: fstest.MapFS{},
+	})
+	if err != nil {
+		t.Fatalf("RunAll() error = %v", err)
+	}
+	if stats.ScannersRun != 1 {
+		t.Errorf("ScannersRun = %d, want 1", stats.ScannersRun)
+	}
+}
+
+// mockResourceScanner writes packages under its own name so concurrent
+// scanners interleave their writes
+type mockResourceScanner struct {
+	name     string
+	packages int
+	delay    time.Duration
+	err      error
+}
+
+func (m *mockResourceScanner) Name() string {
+	return m.name
+}
+
+func (m *mockResourceScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	if err := opts.Writer.StartResource("package"); err != nil {
+		return ScanStats{}, err
+	}
+	for i := 0; i < m.packages; i++ {
+		if err := opts.Writer.Add(spec.PackageSpec{Name: fmt.Sprintf("%s-%d", m.name, i), Installed: true}); err != nil {
+			return ScanStats{}, err
+		}
+		time.Sleep(m.delay)
+	}
+	if m.err != nil {
+		return ScanStats{}, m.err
+	}
+	return ScanStats{
+		FilesScanned: m.packages,
+		Warnings:     []string{m.name + " warning"},
+	}, nil
+}
+
+func (m *mockResourceScanner) IsDynamic() bool {
+	return false
+}
+
+func TestRunAll_ConcurrentMatchesSequential(t *testing.T) {
+	originalScanners := AllScanners
+	defer func() { AllScanners = originalScanners }()
+
+	AllScanners = []Scanner{
+		&mockResourceScanner{name: "slow", packages: 20, delay: time.Millisecond},
+		&mockResourceScanner{name: "failing", packages: 2, err: errors.New("boom")},
+		&mockResourceScanner{name: "fast", packages: 50},
+		&mockDynamicScanner{name: "dynamic"},
+		&mockResourceScanner{name: "medium", packages: 30},
+	}
+
+	run := func(workers int) (ScanStats, *spec.TestWriter) {
+		writer := spec.NewTestWriter()
+		stats, err := RunAll(context.Background(), ScanOptions{
+			Writer:  writer,
+			Logger:  testLogger(),
+			Workers: workers,
+		})
+		if err != nil {
+			t.Fatalf("RunAll(workers=%d) error = %v", workers, err)
+		}
+		stats.Duration = 0
+		return stats, writer
+	}
+
+	seqStats, seqWriter := run(1)
+	conStats, conWriter := run(3)
+
+	if !reflect.DeepEqual(seqStats, conStats) {
+		t.Errorf("Concurrent stats differ:\nsequential: %+v\nconcurrent: %+v", seqStats, conStats)
+	}
+	if !reflect.DeepEqual(seqWriter.GetPackageResults(), conWriter.GetPackageResults()) {
+		t.Error("Concurrent run wrote different resources")
+	}
+
+	if conStats.ScannersRun != 3 || conStats.FilesScanned != 100 {
+		t.Errorf("Unexpected stats: %+v", conStats)
+	}
+	wantWarnings := []string{"slow warning", "Scanner failing failed: boom", "fast warning", "medium warning"}
+	if !reflect.DeepEqual(conStats.Warnings, wantWarnings) {
+		t.Errorf("Warnings = %v, want %v", conStats.Warnings, wantWarnings)
+	}
+}
+
+func TestRunAll_ConcurrentStrict(t *testing.T) {
+	originalScanners := AllScanners
+	defer func() { AllScanners = originalScanners }()
+
+	AllScanners = []Scanner{
+		&mockResourceScanner{name: "first", packages: 5, delay: time.Millisecond},
+		&mockResourceScanner{name: "failing", err: errors.New("boom")},
+		&mockResourceScanner{name: "last", packages: 5},
+	}
+
+	_, err := RunAll(context.Background(), ScanOptions{
+		Writer:  spec.NewTestWriter(),
+		Logger:  testLogger(),
+		Strict:  true,
+		Workers: 4,
+	})
+	if err == nil || err.Error() != "scanner failing failed: boom" {
+		t.Errorf("RunAll() error = %v, want failure of scanner failing", err)
+	}
+}
+
+func TestScopedWriter_RequiresStartResource(t *testing.T) {
+	w := newScopedWriter(spec.NewTestWriter())
+	if err := w.Add(spec.PackageSpec{Name: "bash"}); err == nil {
+		t.Error("Expected error when adding before StartResource")
+	}
+}
+
 // mockWriter implements Writer for testing
 type mockWriter struct{}
 
@@ -13,7 +13,10 @@ import (
 
 // ServiceScanner scans all systemd services using systemctl.
 type ServiceScanner struct {
-	stats ScanStats
+	mockListUnits     string // For testing (systemctl list-units --output=json)
+	mockListUnitFiles string // For testing (systemctl list-unit-files --output=json)
+	mockShowInstances string // For testing (systemctl show --property=Id,UnitFileState)
+	stats             ScanStats
 }
 
 func (s *ServiceScanner) Name() string {
@@ -65,8 +68,43 @@ type systemdUnit struct {
 	Description string `json:"description"`
 }
 
+// systemdUnitFile represents a unit file from "systemctl list-unit-files"
+// JSON output
+type systemdUnitFile struct {
+	UnitFile string `json:"unit_file"`
+	State    string `json:"state"`
+}
+
+// parseUnitFiles parses "systemctl list-unit-files --output=json" into unit
+// file states ("enabled", "disabled", "static", "masked", ...) by unit name
+func parseUnitFiles(output []byte) (map[string]string, error) {
+	var files []systemdUnitFile
+	if err := json.Unmarshal(output, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse systemctl list-unit-files output: %w", err)
+	}
+
+	states := make(map[string]string, len(files))
+	for _, file := range files {
+		states[file.UnitFile] = file.State
+	}
+	return states, nil
+}
+
 // getServices retrieves all systemd services
 func (s *ServiceScanner) getServices(ctx context.Context, opts ScanOptions) (map[string]spec.ServiceSpec, error) {
+	if s.mockListUnits != "" {
+		var units []systemdUnit
+		if err := json.Unmarshal([]byte(s.mockListUnits), &units); err != nil {
+			return nil, fmt.Errorf("failed to parse systemctl list-units output: %w", err)
+		}
+		states, err := parseUnitFiles([]byte(s.mockListUnitFiles))
+		if err != nil {
+			return nil, err
+		}
+		s.addInstanceStates(ctx, "", units, states, opts)
+		return serviceSpecs(units, states), nil
+	}
+
 	// Check if systemctl is available
 	systemctlPath, err := exec.LookPath("systemctl")
 	if err != nil {
@@ -101,7 +139,33 @@ func (s *ServiceScanner) getServices(ctx context.Context, opts ScanOptions) (map
 		return nil, fmt.Errorf("systemctl command failed: %w", err)
 	}
 
-	// Convert units to service specs
+	// Get the unit file states of all units in one call
+	output, err := exec.CommandContext(ctx, systemctlPath, "list-unit-files", "--type=service", "--no-pager", "--output=json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("systemctl list-unit-files failed: %w", err)
+	}
+	states, err := parseUnitFiles(output)
+	if err != nil {
+		return nil, err
+	}
+	s.addInstanceStates(ctx, systemctlPath, units, states, opts)
+
+	return serviceSpecs(units, states), nil
+}
+
+// enabledStates are the unit file states "systemctl is-enabled" reports as
+// enabled, such as "generated" for units converted from SysV init scripts
+var enabledStates = map[string]bool{
+	"enabled":         true,
+	"enabled-runtime": true,
+	"generated":       true,
+	"alias":           true,
+}
+
+// serviceSpecs converts listed units into service specs. Instances such as
+// getty@tty1.service have no unit file of their own; they take the state
+// added by addInstanceStates, or else that of their template.
+func serviceSpecs(units []systemdUnit, states map[string]string) map[string]spec.ServiceSpec {
 	services := make(map[string]spec.ServiceSpec)
 	for _, unit := range units {
 		if !strings.HasSuffix(unit.Unit, ".service") {
@@ -109,18 +173,79 @@ func (s *ServiceScanner) getServices(ctx context.Context, opts ScanOptions) (map
 		}
 
 		serviceName := strings.TrimSuffix(unit.Unit, ".service")
-
-		// Get enabled status for this service
-		enabled := s.isServiceEnabled(ctx, serviceName, opts)
+		enablement := unitFileState(unit.Unit, states)
+		enabled := enabledStates[enablement]
+		running := unit.Active == "active"
 
 		services[serviceName] = spec.ServiceSpec{
-			Name:    serviceName,
-			Enabled: enabled,
-			Running: unit.Active == "active",
+			Name:       serviceName,
+			Enabled:    &enabled,
+			Enablement: enablement,
+			Running:    &running,
 		}
 	}
+	return services
+}
 
-	return services, nil
+// unitFileState returns the unit file state of a unit, falling back to its
+// template for instances
+func unitFileState(unit string, states map[string]string) string {
+	if state, ok := states[unit]; ok {
+		return state
+	}
+	if prefix, _, ok := strings.Cut(unit, "@"); ok {
+		return states[prefix+"@"+unit[strings.LastIndex(unit, "."):]]
+	}
+	return ""
+}
+
+// addInstanceStates reads the unit file state of each listed instance with
+// "systemctl show", since an instance is enabled on its own (getty@tty1
+// can be enabled while getty@tty2 is not). If that fails, instances take
+// the state of their template.
+func (s *ServiceScanner) addInstanceStates(ctx context.Context, systemctlPath string, units []systemdUnit, states map[string]string, opts ScanOptions) {
+	var instances []string
+	for _, unit := range units {
+		if _, ok := states[unit.Unit]; !ok && strings.HasSuffix(unit.Unit, ".service") && strings.Contains(unit.Unit, "@") {
+			instances = append(instances, unit.Unit)
+		}
+	}
+	if len(instances) == 0 {
+		return
+	}
+
+	output := []byte(s.mockShowInstances)
+	if systemctlPath != "" {
+		args := append([]string{"show", "--no-pager", "--property=Id,UnitFileState"}, instances...)
+		var err error
+		if output, err = exec.CommandContext(ctx, systemctlPath, args...).Output(); err != nil {
+			opts.Logger.Warn("Failed to read the state of service instances, using their templates'", "error", err)
+			return
+		}
+	}
+
+	// Blocks are separated by blank lines and matched by their Id, not by
+	// position
+	var id, state string
+	flush := func() {
+		if id != "" && state != "" {
+			states[id] = state
+		}
+		id, state = "", ""
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "":
+			flush()
+		case "Id":
+			id = value
+		case "UnitFileState":
+			state = value
+		}
+	}
+	flush()
 }
 
 // getServicesFallback uses simple text parsing when JSON output is not available
@@ -139,37 +264,16 @@ func (s *ServiceScanner) getServicesFallback(ctx context.Context, opts ScanOptio
 		return nil, fmt.Errorf("failed to start systemctl: %w", err)
 	}
 
-	services := make(map[string]spec.ServiceSpec)
+	var units []systemdUnit
 	scanner := bufio.NewScanner(stdout)
 
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
 		// Parse line format: UNIT LOAD ACTIVE SUB DESCRIPTION
-		fields := strings.Fields(line)
+		fields := strings.Fields(scanner.Text())
 		if len(fields) < 4 {
 			continue
 		}
-
-		unitName := fields[0]
-		if !strings.HasSuffix(unitName, ".service") {
-			continue
-		}
-
-		serviceName := strings.TrimSuffix(unitName, ".service")
-		activeState := fields[2]
-
-		// Get enabled status
-		enabled := s.isServiceEnabled(ctx, serviceName, opts)
-
-		services[serviceName] = spec.ServiceSpec{
-			Name:    serviceName,
-			Enabled: enabled,
-			Running: activeState == "active",
-		}
+		units = append(units, systemdUnit{Unit: fields[0], Load: fields[1], Active: fields[2], Sub: fields[3]})
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -180,21 +284,30 @@ func (s *ServiceScanner) getServicesFallback(ctx context.Context, opts ScanOptio
 		return nil, fmt.Errorf("systemctl command failed: %w", err)
 	}
 
-	return services, nil
-}
-
-// isServiceEnabled checks if a service is enabled
-func (s *ServiceScanner) isServiceEnabled(ctx context.Context, serviceName string, opts ScanOptions) bool {
-	systemctlPath, _ := exec.LookPath("systemctl")
+	states, err := s.getUnitFileStatesFallback(ctx, systemctlPath)
+	if err != nil {
+		return nil, err
+	}
+	s.addInstanceStates(ctx, systemctlPath, units, states, opts)
 
-	cmd := exec.CommandContext(ctx, systemctlPath, "is-enabled", serviceName)
-	output, err := cmd.Output()
+	return serviceSpecs(units, states), nil
+}
 
+// getUnitFileStatesFallback reads unit file states from the plain
+// "UNIT FILE STATE [PRESET]" listing
+func (s *ServiceScanner) getUnitFileStatesFallback(ctx context.Context, systemctlPath string) (map[string]string, error) {
+	output, err := exec.CommandContext(ctx, systemctlPath, "list-unit-files", "--type=service", "--no-pager", "--no-legend").Output()
 	if err != nil {
-		// Service might not be enabled or doesn't exist
-		return false
+		return nil, fmt.Errorf("systemctl list-unit-files failed: %w", err)
 	}
 
-	status := strings.TrimSpace(string(output))
-	return status == "enabled"
+	states := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 {
+			states[fields[0]] = fields[1]
+		}
+	}
+	return states, nil
 }
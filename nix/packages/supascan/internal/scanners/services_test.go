@@ -2,6 +2,7 @@ package scanners
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/supabase/supascan/internal/spec"
@@ -35,6 +36,93 @@ func TestServiceScanner_BasicScan(t *testing.T) {
 	}
 }
 
+func TestServiceScanner_Enablement(t *testing.T) {
+	scanner := &ServiceScanner{
+		mockListUnits: `[
+{"unit":"postgresql.service","load":"loaded","active":"active","sub":"running","description":"PostgreSQL"},
+{"unit":"ssh.service","load":"loaded","active":"inactive","sub":"dead","description":"OpenSSH"},
+{"unit":"apt-daily.service","load":"masked","active":"inactive","sub":"dead","description":"apt-daily.service"},
+{"unit":"systemd-journald.service","load":"loaded","active":"active","sub":"running","description":"Journal"},
+{"unit":"getty@tty1.service","load":"loaded","active":"active","sub":"running","description":"Getty on tty1"},
+{"unit":"transient.service","load":"loaded","active":"active","sub":"running","description":"Transient"},
+{"unit":"logrotate.timer","load":"loaded","active":"active","sub":"waiting","description":"Log rotation"}
+]`,
+		mockListUnitFiles: `[
+{"unit_file":"postgresql.service","state":"enabled","preset":"enabled"},
+{"unit_file":"ssh.service","state":"disabled","preset":"enabled"},
+{"unit_file":"apt-daily.service","state":"masked","preset":null},
+{"unit_file":"systemd-journald.service","state":"static","preset":null},
+{"unit_file":"getty@.service","state":"enabled","preset":"enabled"}
+]`,
+	}
+	writer := spec.NewTestWriter()
+
+	stats, err := scanner.Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	yes, no := true, false
+	expected := map[string]spec.ServiceSpec{
+		"postgresql":       {Enabled: &yes, Enablement: "enabled", Running: &yes},
+		"ssh":              {Enabled: &no, Enablement: "disabled", Running: &no},
+		"apt-daily":        {Enabled: &no, Enablement: "masked", Running: &no},
+		"systemd-journald": {Enabled: &no, Enablement: "static", Running: &yes},
+		"getty@tty1":       {Enabled: &yes, Enablement: "enabled", Running: &yes}, // From the template
+		"transient":        {Enabled: &no, Running: &yes},
+	}
+	results := writer.GetServiceResults()
+	if len(results) != len(expected) || stats.ServicesScanned != len(expected) {
+		t.Errorf("Expected %d services, got %d: %v", len(expected), len(results), results)
+	}
+	for name, want := range expected {
+		want.Name = name
+		if got := results[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Service %s = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestServiceScanner_GeneratedAndInstances(t *testing.T) {
+	scanner := &ServiceScanner{
+		mockListUnits: `[
+{"unit":"grub-common.service","load":"loaded","active":"inactive","sub":"dead","description":"LSB: Record successful boot for GRUB"},
+{"unit":"getty@tty1.service","load":"loaded","active":"active","sub":"running","description":"Getty on tty1"},
+{"unit":"serial-getty@ttyS0.service","load":"loaded","active":"active","sub":"running","description":"Serial Getty on ttyS0"}
+]`,
+		mockListUnitFiles: `[
+{"unit_file":"grub-common.service","state":"generated","preset":null},
+{"unit_file":"getty@.service","state":"enabled","preset":"enabled"},
+{"unit_file":"serial-getty@.service","state":"disabled","preset":"enabled"}
+]`,
+		// Read per instance, in any order
+		mockShowInstances: "UnitFileState=enabled\nId=serial-getty@ttyS0.service\n\nId=getty@tty1.service\nUnitFileState=disabled\n",
+	}
+	writer := spec.NewTestWriter()
+
+	if _, err := scanner.Scan(context.Background(), ScanOptions{Writer: writer, Logger: testLogger()}); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	yes, no := true, false
+	expected := map[string]spec.ServiceSpec{
+		// SysV scripts are generated units, which is-enabled reports as enabled
+		"grub-common":        {Enabled: &yes, Enablement: "generated", Running: &no},
+		"getty@tty1":         {Enabled: &no, Enablement: "disabled", Running: &yes},
+		"serial-getty@ttyS0": {Enabled: &yes, Enablement: "enabled", Running: &yes},
+	}
+	results := writer.GetServiceResults()
+	for name, want := range expected {
+		want.Name = name
+		if got := results[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Service %s = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
 func TestServiceScanner_Properties(t *testing.T) {
 	scanner := &ServiceScanner{}
 
@@ -0,0 +1,240 @@
+package scanners
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// loginDefsKey is the resource key of the login.defs defaults, which cannot
+// collide with a username since usernames cannot contain "/"
+const loginDefsKey = "/etc/login.defs"
+
+// ShadowScanner scans the password policy of each account from /etc/shadow,
+// and the defaults for new accounts from /etc/login.defs. Password hashes
+// are read only to tell usable, locked and empty passwords apart and are
+// never written to the spec.
+type ShadowScanner struct {
+	shadowPath    string // For testing (default: "/etc/shadow")
+	loginDefsPath string // For testing (default: "/etc/login.defs")
+	stats         ScanStats
+}
+
+func (s *ShadowScanner) Name() string {
+	return "shadow"
+}
+
+func (s *ShadowScanner) IsDynamic() bool {
+	return false // Password policies are relatively static
+}
+
+func (s *ShadowScanner) SupportsOffline() bool {
+	return true
+}
+
+func (s *ShadowScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	opts.Logger.Info("Starting password policy scan")
+	s.stats = ScanStats{}
+
+	writer, ok := opts.Writer.(Writer)
+	if !ok {
+		return s.stats, fmt.Errorf("writer does not implement Writer interface")
+	}
+
+	if err := writer.StartResource("shadow"); err != nil {
+		return s.stats, err
+	}
+
+	accounts, err := s.getAccounts(opts)
+	if err != nil {
+		return s.stats, err
+	}
+	for username, account := range accounts {
+		if err := writer.Add(account); err != nil {
+			return s.stats, fmt.Errorf("failed to write shadow spec for %s: %w", username, err)
+		}
+	}
+
+	defaults, found, err := s.getLoginDefaults(opts)
+	if err != nil {
+		return s.stats, err
+	}
+	if found {
+		if err := writer.Add(defaults); err != nil {
+			return s.stats, fmt.Errorf("failed to write login.defs spec: %w", err)
+		}
+	}
+
+	opts.Logger.Info("Password policy scan complete", "accounts_found", len(accounts))
+
+	return s.stats, nil
+}
+
+// open opens a system file, or the path injected for testing
+func (s *ShadowScanner) open(opts ScanOptions, testPath, path string) (io.ReadCloser, string, error) {
+	if testPath != "" {
+		file, err := os.Open(testPath)
+		return file, testPath, err
+	}
+	file, err := openSystemFile(opts, path)
+	return file, path, err
+}
+
+// skip reports whether a shadow or login.defs file that cannot be read
+// should be skipped, see skipUnreadable
+func (s *ShadowScanner) skip(opts ScanOptions, name string, err error) bool {
+	return skipUnreadable(opts, &s.stats, "password policy file", name, err)
+}
+
+// getAccounts reads and parses /etc/shadow. An unreadable shadow file (the
+// scan is not running as root) is skipped with a warning unless in strict
+// mode.
+func (s *ShadowScanner) getAccounts(opts ScanOptions) (map[string]spec.ShadowSpec, error) {
+	file, shadowPath, err := s.open(opts, s.shadowPath, "/etc/shadow")
+	if err != nil {
+		if s.skip(opts, shadowPath, err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", shadowPath, err)
+	}
+	defer file.Close()
+
+	accounts := make(map[string]spec.ShadowSpec)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Parse shadow line: username:password:lastchg:min:max:warn:inactive:expire:reserved
+		// Lines are never logged, they contain password hashes
+		fields := strings.Split(line, ":")
+		if len(fields) != 9 {
+			warnLine(opts, &s.stats, "shadow", shadowPath, lineNum, fmt.Sprintf("expected 9 fields, got %d", len(fields)))
+			continue
+		}
+
+		account := spec.ShadowSpec{
+			Username: fields[0],
+			Password: passwordState(fields[1]),
+		}
+		aging := []struct {
+			value string
+			field **int
+		}{
+			{fields[3], &account.MinDays},
+			{fields[4], &account.MaxDays},
+			{fields[5], &account.WarnDays},
+			{fields[6], &account.InactiveDays},
+		}
+		valid := true
+		for _, a := range aging {
+			if *a.field, err = optionalInt(a.value); err != nil {
+				valid = false
+			}
+		}
+		if !valid {
+			warnLine(opts, &s.stats, "shadow", shadowPath, lineNum, "invalid aging field")
+			continue
+		}
+
+		accounts[account.Username] = account
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", shadowPath, err)
+	}
+
+	return accounts, nil
+}
+
+// getLoginDefaults reads the password aging defaults for new accounts from
+// /etc/login.defs. found is false if the file does not exist or is skipped
+// as unreadable.
+func (s *ShadowScanner) getLoginDefaults(opts ScanOptions) (defaults spec.ShadowSpec, found bool, err error) {
+	file, loginDefsPath, err := s.open(opts, s.loginDefsPath, loginDefsKey)
+	if err != nil {
+		if s.skip(opts, loginDefsPath, err) {
+			return defaults, false, nil
+		}
+		return defaults, false, fmt.Errorf("failed to open %s: %w", loginDefsPath, err)
+	}
+	defer file.Close()
+
+	defaults.Username = loginDefsKey
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		// Parse "KEY value" lines, as login.defs(5) describes
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var field **int
+		switch fields[0] {
+		case "PASS_MIN_DAYS":
+			field = &defaults.MinDays
+		case "PASS_MAX_DAYS":
+			field = &defaults.MaxDays
+		case "PASS_WARN_AGE":
+			field = &defaults.WarnDays
+		case "ENCRYPT_METHOD":
+			defaults.EncryptMethod = fields[1]
+			continue
+		default:
+			continue
+		}
+		value, err := optionalInt(fields[1])
+		if err != nil {
+			warnLine(opts, &s.stats, "login.defs", loginDefsPath, lineNum, "invalid "+fields[0]+" value")
+			continue
+		}
+		*field = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return defaults, false, fmt.Errorf("error reading %s: %w", loginDefsPath, err)
+	}
+
+	return defaults, true, nil
+}
+
+// passwordState describes the password field of a shadow entry without
+// revealing it: "empty" allows logging in without a password, and a hash
+// prefixed with "!" or "*" (or either alone) cannot be used to log in
+func passwordState(hash string) string {
+	switch {
+	case hash == "":
+		return "empty"
+	case strings.HasPrefix(hash, "!"), strings.HasPrefix(hash, "*"):
+		return "locked"
+	default:
+		return "usable"
+	}
+}
+
+// optionalInt parses a numeric shadow or login.defs field, nil if empty
+func optionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
@@ -0,0 +1,162 @@
+package scanners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+const testShadow = `root:$6$salt$c2VjcmV0aGFzaA:19500:0:99999:7:::
+daemon:*:19500:0:99999:7:::
+ubuntu:!$6$salt$bG9ja2VkaGFzaA:19500:1:90:7:30::
+guest::19500::::::
+malformed:line
+broken:$6$salt$YnJva2VuaGFzaA:19500:x:99999:7:::
+`
+
+const testLoginDefs = `# Password aging controls
+PASS_MAX_DAYS	365
+PASS_MIN_DAYS	1
+PASS_WARN_AGE	7
+#PASS_MIN_LEN	14
+ENCRYPT_METHOD SHA512
+UMASK		022
+`
+
+func TestShadowScanner_BasicScan(t *testing.T) {
+	tmpDir := t.TempDir()
+	shadowFile := filepath.Join(tmpDir, "shadow")
+	loginDefsFile := filepath.Join(tmpDir, "login.defs")
+	os.WriteFile(shadowFile, []byte(testShadow), 0600)
+	os.WriteFile(loginDefsFile, []byte(testLoginDefs), 0644)
+
+	writer := spec.NewTestWriter()
+	stats, err := (&ShadowScanner{shadowPath: shadowFile, loginDefsPath: loginDefsFile}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetShadowResults()
+	if len(results) != 5 {
+		t.Errorf("Expected 4 accounts and login.defs, got %d", len(results))
+	}
+
+	states := map[string]string{"root": "usable", "daemon": "locked", "ubuntu": "locked", "guest": "empty"}
+	for username, want := range states {
+		if got := results[username].Password; got != want {
+			t.Errorf("%s password = %q, want %q", username, got, want)
+		}
+	}
+
+	ubuntu := results["ubuntu"]
+	if ubuntu.MinDays == nil || *ubuntu.MinDays != 1 || *ubuntu.MaxDays != 90 || *ubuntu.WarnDays != 7 || *ubuntu.InactiveDays != 30 {
+		t.Errorf("Unexpected ubuntu aging: %+v", ubuntu)
+	}
+	if guest := results["guest"]; guest.MinDays != nil || guest.MaxDays != nil || guest.InactiveDays != nil {
+		t.Errorf("Expected unset aging for guest, got %+v", guest)
+	}
+
+	defaults := results["/etc/login.defs"]
+	if defaults.Password != "" || *defaults.MaxDays != 365 || *defaults.MinDays != 1 || *defaults.WarnDays != 7 || defaults.EncryptMethod != "SHA512" {
+		t.Errorf("Unexpected login.defs defaults: %+v", defaults)
+	}
+
+	// Warnings name the line, never its content
+	want := []string{shadowFile + ":5: expected 9 fields, got 2", shadowFile + ":6: invalid aging field"}
+	if !slices.Equal(stats.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", stats.Warnings, want)
+	}
+}
+
+func TestShadowScanner_NeverWritesHashes(t *testing.T) {
+	tmpDir := t.TempDir()
+	shadowFile := filepath.Join(tmpDir, "shadow")
+	os.WriteFile(shadowFile, []byte(testShadow), 0600)
+
+	outputFile := filepath.Join(tmpDir, "spec.yaml")
+	writer, err := spec.NewWriter(outputFile)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	_, err = (&ShadowScanner{shadowPath: shadowFile, loginDefsPath: filepath.Join(tmpDir, "missing")}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(outputFile)
+	for _, secret := range []string{"$6$", "c2VjcmV0aGFzaA", "bG9ja2VkaGFzaA"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Spec contains password hash material %q:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "password: usable") {
+		t.Errorf("Expected password state in spec:\n%s", data)
+	}
+}
+
+func TestShadowScanner_Unreadable(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "shadow")
+
+	writer := spec.NewTestWriter()
+	scanner := &ShadowScanner{shadowPath: missing, loginDefsPath: missing}
+	if _, err := scanner.Scan(context.Background(), ScanOptions{Writer: writer, Logger: testLogger(), Strict: true}); err != nil {
+		t.Errorf("Expected missing shadow to be skipped, got %v", err)
+	}
+	if len(writer.GetShadowResults()) != 0 {
+		t.Errorf("Expected no accounts, got %v", writer.GetShadowResults())
+	}
+
+	fsys := unreadableFS{MapFS: fstest.MapFS{"etc/login.defs": {Data: []byte("PASS_MAX_DAYS 90\n")}}, name: "etc/shadow"}
+	writer = spec.NewTestWriter()
+	stats, err := (&ShadowScanner{}).Scan(context.Background(), ScanOptions{Writer: writer, Logger: testLogger(), RootFS: fsys})
+	if err != nil {
+		t.Errorf("Expected unreadable shadow to be skipped, got %v", err)
+	}
+	if want := []string{"/etc/shadow: permission denied, skipped"}; !slices.Equal(stats.Warnings, want) {
+		t.Errorf("Warnings = %v, want %v", stats.Warnings, want)
+	}
+	if len(writer.GetShadowResults()) != 1 {
+		t.Errorf("Expected only the login.defs defaults, got %v", writer.GetShadowResults())
+	}
+
+	if _, err := (&ShadowScanner{}).Scan(context.Background(), ScanOptions{Writer: spec.NewTestWriter(), Logger: testLogger(), RootFS: fsys, Strict: true}); err == nil {
+		t.Error("Expected error in strict mode")
+	}
+}
+
+func TestShadowScanner_OfflineRoot(t *testing.T) {
+	rootFS := fstest.MapFS{
+		"etc/shadow":     &fstest.MapFile{Data: []byte("postgres:!:19500:0:99999:7:::\n")},
+		"etc/login.defs": &fstest.MapFile{Data: []byte("PASS_MAX_DAYS 90\n")},
+	}
+
+	writer := spec.NewTestWriter()
+	_, err := (&ShadowScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: rootFS,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetShadowResults()
+	if results["postgres"].Password != "locked" || *results["/etc/login.defs"].MaxDays != 90 {
+		t.Errorf("Expected policies from the image's shadow and login.defs, got %v", results)
+	}
+}
@@ -0,0 +1,304 @@
+package scanners
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// sshdConfigPath is the main sshd configuration file
+const sshdConfigPath = "/etc/ssh/sshd_config"
+
+// maxSshdIncludeDepth limits nested Include directives, as sshd does
+const maxSshdIncludeDepth = 16
+
+// sshdCumulativeKeys are the directives that may be given more than once,
+// with every occurrence taking effect. Their values are joined with
+// newlines. For every other directive the first occurrence wins.
+var sshdCumulativeKeys = map[string]bool{
+	"acceptenv":       true,
+	"allowgroups":     true,
+	"allowusers":      true,
+	"denygroups":      true,
+	"denyusers":       true,
+	"hostcertificate": true,
+	"hostkey":         true,
+	"listenaddress":   true,
+	"permitlisten":    true,
+	"permitopen":      true,
+	"port":            true,
+	"setenv":          true,
+	"subsystem":       true,
+}
+
+// SshdScanner scans the effective OpenSSH server configuration. On a live
+// system it asks "sshd -T", which reports every directive including the
+// compiled-in defaults. Without an sshd binary, or when scanning an offline
+// root, it parses sshd_config and its Include files instead, which reports
+// only the directives that are set. Directives within Match blocks are
+// always read from the configuration files, since "sshd -T" only reports
+// them for a given connection. Each directive records which of the two it
+// was read from, so validate can compare it with a value read the same way.
+type SshdScanner struct {
+	rootPath       string // For testing (default: "/")
+	mockSshdOutput string // For testing
+	stats          ScanStats
+}
+
+func (s *SshdScanner) Name() string {
+	return "sshd"
+}
+
+func (s *SshdScanner) IsDynamic() bool {
+	return false // The server configuration is static
+}
+
+func (s *SshdScanner) SupportsOffline() bool {
+	return true
+}
+
+func (s *SshdScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	opts.Logger.Info("Starting sshd configuration scan")
+
+	writer, ok := opts.Writer.(Writer)
+	if !ok {
+		return s.stats, fmt.Errorf("writer does not implement Writer interface")
+	}
+
+	if err := writer.StartResource("sshd-config"); err != nil {
+		return s.stats, err
+	}
+
+	directives, err := s.getDirectives(ctx, opts)
+	if err != nil {
+		return s.stats, err
+	}
+
+	for key, directive := range directives {
+		if err := writer.Add(directive); err != nil {
+			return s.stats, fmt.Errorf("failed to write sshd config spec for %s: %w", key, err)
+		}
+	}
+
+	opts.Logger.Info("sshd configuration scan complete", "directives_found", len(directives))
+
+	return s.stats, nil
+}
+
+// getDirectives resolves the effective configuration, from "sshd -T" when
+// possible and otherwise from the configuration files
+func (s *SshdScanner) getDirectives(ctx context.Context, opts ScanOptions) (map[string]spec.SshdConfigSpec, error) {
+	fsys := scanRoot(opts, s.rootPath)
+
+	parsed := newSshdConfig(spec.SshdSourceConfig)
+	if err := parsed.parseFile(fsys, sshdConfigPath, "", 0); err != nil {
+		if skipUnreadable(opts, &s.stats, "sshd configuration", sshdConfigPath, err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	output, ok := s.sshdOutput(ctx, opts)
+	if !ok {
+		return parsed.directives, nil
+	}
+
+	// sshd -T reports the global configuration, keep the Match blocks from
+	// the configuration files
+	directives := parseSshdOutput(output)
+	for key, directive := range parsed.directives {
+		if strings.HasPrefix(key, "[") {
+			directives[key] = directive
+		}
+	}
+	return directives, nil
+}
+
+// sshdOutput runs "sshd -T". It reports false when scanning an offline root
+// or a test root, or when sshd is missing or fails, e.g. when not running as
+// root.
+func (s *SshdScanner) sshdOutput(ctx context.Context, opts ScanOptions) (string, bool) {
+	if s.mockSshdOutput != "" {
+		return s.mockSshdOutput, true
+	}
+	if opts.RootFS != nil || s.rootPath != "" {
+		return "", false
+	}
+
+	sshdPath, err := exec.LookPath("sshd")
+	if err != nil {
+		sshdPath = "/usr/sbin/sshd"
+		if _, err := exec.LookPath(sshdPath); err != nil {
+			opts.Logger.Debug("sshd not found, parsing sshd_config instead")
+			return "", false
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, sshdPath, "-T", "-f", sshdConfigPath)
+	stdout, err := cmd.Output()
+	if err != nil {
+		opts.Logger.Debug("sshd -T failed, parsing sshd_config instead", "error", err)
+		return "", false
+	}
+
+	return string(stdout), true
+}
+
+// parseSshdOutput parses the lowercase "key value" lines of "sshd -T"
+func parseSshdOutput(output string) map[string]spec.SshdConfigSpec {
+	config := newSshdConfig(spec.SshdSourceEffective)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		key, value, ok := splitSshdDirective(scanner.Text())
+		if !ok {
+			continue
+		}
+		// sshd -T prints every occurrence of a repeated directive on its own
+		// line, and each one is in effect
+		config.add(key, value, true)
+	}
+
+	return config.directives
+}
+
+// sshdConfig accumulates directives read from one source
+type sshdConfig struct {
+	source     string
+	directives map[string]spec.SshdConfigSpec
+}
+
+func newSshdConfig(source string) *sshdConfig {
+	return &sshdConfig{source: source, directives: make(map[string]spec.SshdConfigSpec)}
+}
+
+// add records a directive. The first occurrence wins unless the directive
+// is cumulative.
+func (c *sshdConfig) add(key, value string, cumulative bool) {
+	existing, ok := c.directives[key]
+	if !ok {
+		c.directives[key] = spec.SshdConfigSpec{Key: key, Value: value, Source: c.source}
+		return
+	}
+	if cumulative {
+		existing.Value += "\n" + value
+		c.directives[key] = existing
+	}
+}
+
+// parseFile parses one configuration file. match is the Match block the
+// file was included from, if any; a Match block started within the file
+// ends with it.
+func (c *sshdConfig) parseFile(fsys fs.FS, name, match string, depth int) error {
+	if depth > maxSshdIncludeDepth {
+		return fmt.Errorf("sshd Include nested too deeply at %s", name)
+	}
+
+	data, err := fs.ReadFile(fsys, strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := splitSshdDirective(line)
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "match":
+			// "Match all" ends a Match block, the directives after it
+			// apply to every connection again
+			if strings.EqualFold(value, "all") {
+				match = ""
+			} else {
+				match = "[match " + value + "]"
+			}
+		case "include":
+			for _, pattern := range strings.Fields(value) {
+				if err := c.include(fsys, pattern, match, depth); err != nil {
+					return err
+				}
+			}
+		default:
+			cumulative := sshdCumulativeKeys[key]
+			if match != "" {
+				key = match + " " + key
+			}
+			c.add(key, unquoteSshdValue(value), cumulative)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// include parses the files matching an Include pattern in lexical order.
+// Relative patterns are resolved against /etc/ssh, and patterns that match
+// nothing are ignored, as sshd does.
+func (c *sshdConfig) include(fsys fs.FS, pattern, match string, depth int) error {
+	if !path.IsAbs(pattern) {
+		pattern = path.Join(path.Dir(sshdConfigPath), pattern)
+	}
+
+	matches, err := fs.Glob(fsys, strings.TrimPrefix(path.Clean(pattern), "/"))
+	if err != nil {
+		return fmt.Errorf("invalid sshd Include pattern %q: %w", pattern, err)
+	}
+	sort.Strings(matches)
+
+	for _, m := range matches {
+		if info, err := fs.Stat(fsys, m); err != nil || info.IsDir() {
+			continue
+		}
+		if err := c.parseFile(fsys, "/"+m, match, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitSshdDirective splits a configuration line into its lowercase
+// keyword and its value, with runs of whitespace collapsed. The keyword may
+// be separated from the value by whitespace or "=".
+func splitSshdDirective(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	end := strings.IndexAny(line, " \t=")
+	if end <= 0 {
+		return "", "", false
+	}
+
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	value := strings.Join(strings.Fields(rest), " ")
+	if value == "" {
+		return "", "", false
+	}
+
+	return key, value, true
+}
+
+// unquoteSshdValue removes the quotes around a value written as one quoted
+// argument
+func unquoteSshdValue(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' && !strings.Contains(value[1:len(value)-1], "\"") {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
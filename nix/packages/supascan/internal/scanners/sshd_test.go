@@ -0,0 +1,198 @@
+package scanners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+const testSshdConfig = `# Drop-ins come first so they override the defaults below
+Include sshd_config.d/*.conf
+
+Port 22
+Port 2222
+PermitRootLogin yes
+PasswordAuthentication=no
+Ciphers   aes256-gcm@openssh.com,chacha20-poly1305@openssh.com
+Subsystem sftp /usr/lib/openssh/sftp-server
+
+Match User backup
+	PasswordAuthentication yes
+	ForceCommand "internal-sftp"
+`
+
+const testSshdDropIn = `PermitRootLogin prohibit-password
+MACs hmac-sha2-512-etm@openssh.com
+`
+
+func writeSshdTree(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	sshDir := filepath.Join(root, "etc", "ssh")
+	if err := os.MkdirAll(filepath.Join(sshDir, "sshd_config.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(sshDir, "sshd_config"), []byte(testSshdConfig), 0644)
+	os.WriteFile(filepath.Join(sshDir, "sshd_config.d", "50-hardening.conf"), []byte(testSshdDropIn), 0644)
+	os.WriteFile(filepath.Join(sshDir, "sshd_config.d", "README"), []byte("PermitRootLogin yes\n"), 0644)
+	return root
+}
+
+func TestSshdScanner_ParseConfig(t *testing.T) {
+	writer := spec.NewTestWriter()
+	_, err := (&SshdScanner{rootPath: writeSshdTree(t)}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetSshdConfigResults()
+	expected := map[string]string{
+		"permitrootlogin":        "prohibit-password",
+		"passwordauthentication": "no",
+		"port":                   "22\n2222",
+		"ciphers":                "aes256-gcm@openssh.com,chacha20-poly1305@openssh.com",
+		"macs":                   "hmac-sha2-512-etm@openssh.com",
+		"subsystem":              "sftp /usr/lib/openssh/sftp-server",
+		"[match User backup] passwordauthentication": "yes",
+		"[match User backup] forcecommand":           "internal-sftp",
+	}
+	if len(results) != len(expected) {
+		t.Errorf("Expected %d directives, got %d: %v", len(expected), len(results), results)
+	}
+	for key, want := range expected {
+		if got := results[key].Value; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+		if source := results[key].Source; source != spec.SshdSourceConfig {
+			t.Errorf("%s source = %q, want %q", key, source, spec.SshdSourceConfig)
+		}
+	}
+}
+
+func TestSshdScanner_SshdOutput(t *testing.T) {
+	writer := spec.NewTestWriter()
+	scanner := &SshdScanner{
+		rootPath: writeSshdTree(t),
+		mockSshdOutput: `port 22
+port 2222
+permitrootlogin without-password
+passwordauthentication no
+x11forwarding no
+hostkey /etc/ssh/ssh_host_rsa_key
+hostkey /etc/ssh/ssh_host_ed25519_key
+`,
+	}
+	_, err := scanner.Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetSshdConfigResults()
+	expected := map[string]string{
+		"port":                   "22\n2222",
+		"permitrootlogin":        "without-password",
+		"passwordauthentication": "no",
+		"x11forwarding":          "no",
+		"hostkey":                "/etc/ssh/ssh_host_rsa_key\n/etc/ssh/ssh_host_ed25519_key",
+		// Match blocks still come from the configuration files
+		"[match User backup] passwordauthentication": "yes",
+		"[match User backup] forcecommand":           "internal-sftp",
+	}
+	if len(results) != len(expected) {
+		t.Errorf("Expected %d directives, got %d: %v", len(expected), len(results), results)
+	}
+	for key, want := range expected {
+		if got := results[key].Value; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if source := results["permitrootlogin"].Source; source != spec.SshdSourceEffective {
+		t.Errorf("permitrootlogin source = %q, want %q", source, spec.SshdSourceEffective)
+	}
+	if source := results["[match User backup] forcecommand"].Source; source != spec.SshdSourceConfig {
+		t.Errorf("Match block source = %q, want %q", source, spec.SshdSourceConfig)
+	}
+}
+
+func TestSshdScanner_Offline(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/ssh/sshd_config":                {Data: []byte("Include /etc/ssh/sshd_config.d/*.conf\nPermitRootLogin no\n")},
+		"etc/ssh/sshd_config.d/10-auth.conf": {Data: []byte("PubkeyAuthentication yes\n")},
+	}
+
+	writer := spec.NewTestWriter()
+	_, err := (&SshdScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: fsys,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetSshdConfigResults()
+	if len(results) != 2 || results["permitrootlogin"].Value != "no" || results["pubkeyauthentication"].Value != "yes" {
+		t.Errorf("Unexpected offline directives: %v", results)
+	}
+}
+
+func TestSshdScanner_MatchAll(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/ssh/sshd_config": {Data: []byte("Match User backup\n\tForceCommand internal-sftp\nMatch All\nX11Forwarding no\n")},
+	}
+
+	writer := spec.NewTestWriter()
+	_, err := (&SshdScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: fsys,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetSshdConfigResults()
+	if len(results) != 2 || results["[match User backup] forcecommand"].Value != "internal-sftp" || results["x11forwarding"].Value != "no" {
+		t.Errorf("Expected Match all to end the Match block, got %v", results)
+	}
+}
+
+func TestSshdScanner_MissingConfig(t *testing.T) {
+	writer := spec.NewTestWriter()
+	_, err := (&SshdScanner{rootPath: t.TempDir()}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := len(writer.GetSshdConfigResults()); n != 0 {
+		t.Errorf("Expected no directives without sshd_config, got %d", n)
+	}
+}
+
+func TestSshdScanner_IncludeLoop(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/ssh/sshd_config": {Data: []byte("Include sshd_config\n")},
+	}
+
+	_, err := (&SshdScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: spec.NewTestWriter(),
+		Logger: testLogger(),
+		RootFS: fsys,
+	})
+	if err == nil {
+		t.Error("Expected an error for a recursive Include")
+	}
+}
@@ -0,0 +1,456 @@
+package scanners
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// sudoersPath is the main sudoers file
+const sudoersPath = "/etc/sudoers"
+
+// maxSudoersIncludeDepth limits nested include directives
+const maxSudoersIncludeDepth = 8
+
+// sudoersAliasTypes start the lines that define aliases
+var sudoersAliasTypes = map[string]bool{
+	"User_Alias": true, "Runas_Alias": true, "Host_Alias": true,
+	"Cmnd_Alias": true, "Cmd_Alias": true,
+}
+
+// sudoersHostSeparator matches the ": host_list =" that starts another set
+// of commands for other hosts in the same user specification
+var sudoersHostSeparator = regexp.MustCompile(`\s+:\s*([^\s=:(),]+(?:\s*,\s*[^\s=:(),]+)*)\s*=`)
+
+// sudoersTag matches a command tag ("NOPASSWD:") or option ("CWD=/tmp")
+// before a command
+var sudoersTag = regexp.MustCompile(`^(?:([A-Z_]+):|((?:ROLE|TYPE|CWD|CHROOT|TIMEOUT|NOTBEFORE|NOTAFTER|APPARMOR_PROFILE|PRIVS|LIMITPRIVS)=\S+))\s*`)
+
+// sudoersSettingName matches the name of a Defaults setting
+var sudoersSettingName = regexp.MustCompile(`^[a-z_]+$`)
+
+// sudoersListComma matches a comma in a list with the spaces around it
+var sudoersListComma = regexp.MustCompile(`\s*,\s*`)
+
+// SudoersScanner scans the sudo policy: every user specification of
+// /etc/sudoers and the files it includes (normally all of /etc/sudoers.d)
+// as a rule with its users, hosts, run-as users, commands and tags, and
+// every Defaults setting. Lines that cannot be parsed are reported as scan
+// warnings.
+type SudoersScanner struct {
+	rootPath string // For testing (default: "/")
+	stats    ScanStats
+}
+
+func (s *SudoersScanner) Name() string {
+	return "sudoers"
+}
+
+func (s *SudoersScanner) IsDynamic() bool {
+	return false // The sudo policy is static
+}
+
+func (s *SudoersScanner) SupportsOffline() bool {
+	return true
+}
+
+// sudoersPolicy collects the rules and Defaults settings of the sudoers files
+type sudoersPolicy struct {
+	rules    map[string]spec.SudoersRuleSpec
+	defaults map[string]spec.SudoersDefaultSpec
+}
+
+func (s *SudoersScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	opts.Logger.Info("Starting sudoers scan")
+	s.stats = ScanStats{}
+
+	writer, ok := opts.Writer.(Writer)
+	if !ok {
+		return s.stats, fmt.Errorf("writer does not implement Writer interface")
+	}
+
+	fsys := scanRoot(opts, s.rootPath)
+
+	policy := &sudoersPolicy{
+		rules:    make(map[string]spec.SudoersRuleSpec),
+		defaults: make(map[string]spec.SudoersDefaultSpec),
+	}
+	if err := s.parseFile(fsys, sudoersPath, policy, opts, 0); err != nil {
+		if s.skip(opts, sudoersPath, err) {
+			return s.stats, nil
+		}
+		return s.stats, err
+	}
+
+	if err := writer.StartResource("sudoers-rule"); err != nil {
+		return s.stats, err
+	}
+	for id, rule := range policy.rules {
+		if err := writer.Add(rule); err != nil {
+			return s.stats, fmt.Errorf("failed to write sudoers rule spec for %s: %w", id, err)
+		}
+	}
+
+	if err := writer.StartResource("sudoers-default"); err != nil {
+		return s.stats, err
+	}
+	for id, setting := range policy.defaults {
+		if err := writer.Add(setting); err != nil {
+			return s.stats, fmt.Errorf("failed to write sudoers default spec for %s: %w", id, err)
+		}
+	}
+
+	opts.Logger.Info("Sudoers scan complete", "rules_found", len(policy.rules), "defaults_found", len(policy.defaults))
+
+	return s.stats, nil
+}
+
+// skip reports whether a sudoers file that cannot be read should be
+// skipped, see skipUnreadable
+func (s *SudoersScanner) skip(opts ScanOptions, name string, err error) bool {
+	return skipUnreadable(opts, &s.stats, "sudoers file", name, err)
+}
+
+// parseFile parses one sudoers file, following its include and includedir
+// directives
+func (s *SudoersScanner) parseFile(fsys fs.FS, name string, policy *sudoersPolicy, opts ScanOptions, depth int) error {
+	if depth > maxSudoersIncludeDepth {
+		return fmt.Errorf("sudoers include nested too deeply at %s", name)
+	}
+
+	data, err := fs.ReadFile(fsys, strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	for _, line := range sudoersLines(string(data)) {
+		text := line.text
+
+		// "#include" is the older spelling of "@include"
+		if fields := strings.Fields(text); len(fields) == 2 && strings.ContainsAny(fields[0][:1], "#@") {
+			target := fields[1]
+			switch fields[0][1:] {
+			case "include":
+				include := resolveIncludePath(name, strings.Trim(target, `"`))
+				if err := s.parseFile(fsys, include, policy, opts, depth+1); err != nil && !s.skip(opts, include, err) {
+					return err
+				}
+				continue
+			case "includedir":
+				dir := resolveIncludePath(name, strings.Trim(target, `"`))
+				for _, include := range s.includeDir(fsys, dir, opts) {
+					if err := s.parseFile(fsys, include, policy, opts, depth+1); err != nil && !s.skip(opts, include, err) {
+						return err
+					}
+				}
+				continue
+			}
+		}
+
+		// A "#" starts a comment, unless it starts a numeric user ID
+		if strings.HasPrefix(text, "#") && !startsWithDigit(text[1:]) {
+			continue
+		}
+		text = stripSudoersComment(text)
+		if text == "" {
+			continue
+		}
+
+		head := strings.Fields(text)[0]
+		switch {
+		case sudoersAliasTypes[head]:
+			// Aliases are recorded by name where rules use them
+			continue
+		case strings.HasPrefix(head, "Defaults"):
+			if reason := parseSudoersDefaults(text, name, policy); reason != "" {
+				warnLine(opts, &s.stats, "sudoers", name, line.num, reason)
+			}
+		default:
+			if reason := parseSudoersRule(text, name, policy); reason != "" {
+				warnLine(opts, &s.stats, "sudoers", name, line.num, reason)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sudoersLine is a logical sudoers line, with backslash continuations
+// joined, and the number of the line it starts on
+type sudoersLine struct {
+	num  int
+	text string
+}
+
+// sudoersLines splits a sudoers file into logical lines
+func sudoersLines(data string) []sudoersLine {
+	var lines []sudoersLine
+	var current strings.Builder
+	start := 0
+
+	for i, raw := range strings.Split(data, "\n") {
+		if current.Len() == 0 {
+			start = i + 1
+		}
+		raw = strings.TrimRight(raw, " \t\r")
+		if strings.HasSuffix(raw, "\\") && !strings.HasSuffix(raw, "\\\\") {
+			current.WriteString(strings.TrimSuffix(raw, "\\"))
+			current.WriteByte(' ')
+			continue
+		}
+		current.WriteString(raw)
+		if text := strings.TrimSpace(current.String()); text != "" {
+			lines = append(lines, sudoersLine{num: start, text: text})
+		}
+		current.Reset()
+	}
+	if text := strings.TrimSpace(current.String()); text != "" {
+		lines = append(lines, sudoersLine{num: start, text: text})
+	}
+
+	return lines
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// stripSudoersComment removes a trailing comment: a "#" after whitespace
+// that does not start a numeric ID
+func stripSudoersComment(line string) string {
+	for i := 1; i < len(line); i++ {
+		if line[i] == '#' && (line[i-1] == ' ' || line[i-1] == '\t') && !startsWithDigit(line[i+1:]) {
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return line
+}
+
+// includeDir lists the files of an includedir in the order sudo reads
+// them: sorted, skipping names that end in "~" or contain a "."
+func (s *SudoersScanner) includeDir(fsys fs.FS, dir string, opts ScanOptions) []string {
+	files, err := includeDirFiles(fsys, dir, func(name string) bool {
+		return !strings.HasSuffix(name, "~") && !strings.Contains(name, ".")
+	})
+	if err != nil {
+		s.skip(opts, dir, err)
+	}
+	return files
+}
+
+// splitSudoersList splits a comma-separated list, leaving commas that are
+// escaped, quoted or within parentheses alone
+func splitSudoersList(list string) []string {
+	var items []string
+	depth := 0
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(list); i++ {
+		switch c := list[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == '(' && !quoted:
+			depth++
+		case c == ')' && !quoted && depth > 0:
+			depth--
+		case c == ',' && !quoted && depth == 0:
+			items = append(items, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, strings.TrimSpace(list[start:]))
+}
+
+// parseSudoersDefaults parses a "Defaults[:@!>scope] setting, ..." line.
+// It returns why the line cannot be parsed, or "" on success.
+func parseSudoersDefaults(line, source string, policy *sudoersPolicy) string {
+	head := strings.Fields(line)[0]
+	settings := strings.TrimSpace(line[len(head):])
+	if head != "Defaults" && !strings.ContainsAny(head[len("Defaults"):len("Defaults")+1], ":@!>") {
+		return "unknown Defaults type " + head
+	}
+	if settings == "" {
+		return "Defaults without settings"
+	}
+
+	for _, setting := range splitSudoersList(settings) {
+		var name, value string
+		operator := "="
+		switch {
+		case strings.HasPrefix(setting, "!"):
+			name, value = strings.TrimSpace(setting[1:]), "false"
+		case strings.Contains(setting, "="):
+			name, value, _ = strings.Cut(setting, "=")
+			name = strings.TrimSpace(name)
+			if strings.HasSuffix(name, "+") || strings.HasSuffix(name, "-") {
+				operator = name[len(name)-1:] + "="
+				name = strings.TrimSpace(name[:len(name)-1])
+			}
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+		default:
+			name, value = setting, "true"
+		}
+		if !sudoersSettingName.MatchString(name) {
+			return "unexpected Defaults setting " + setting
+		}
+
+		id := head + " " + name
+		if operator != "=" {
+			value = operator + " " + value
+			if existing, ok := policy.defaults[id]; ok {
+				value = existing.Value + "\n" + value
+			}
+		}
+		policy.defaults[id] = spec.SudoersDefaultSpec{ID: id, Value: value, Source: source}
+	}
+
+	return ""
+}
+
+// sudoersCommands is the state a user specification carries from one
+// command to the next: the run-as users and the tags
+type sudoersCommands struct {
+	runAs    string
+	noPasswd bool
+	tags     []string
+}
+
+// parseSudoersRule parses a user specification,
+// "users hosts = [(runas)] [TAG:] command, ... [: hosts = ...]". It
+// returns why the line cannot be parsed, or "" on success.
+func parseSudoersRule(line, source string, policy *sudoersPolicy) string {
+	left, right, ok := strings.Cut(line, "=")
+	if !ok {
+		return "not a user specification"
+	}
+
+	fields := strings.Fields(sudoersListComma.ReplaceAllString(strings.TrimSpace(left), ","))
+	if len(fields) != 2 {
+		return "expected users and hosts before \"=\""
+	}
+	user := fields[0]
+	hosts := strings.Split(fields[1], ",")
+
+	// Further ": hosts =" parts apply other commands to other hosts
+	type hostCommands struct {
+		hosts    []string
+		commands string
+	}
+	var parts []hostCommands
+	for {
+		loc := sudoersHostSeparator.FindStringSubmatchIndex(right)
+		if loc == nil {
+			parts = append(parts, hostCommands{hosts, right})
+			break
+		}
+		parts = append(parts, hostCommands{hosts, right[:loc[0]]})
+		hosts = strings.Split(strings.ReplaceAll(right[loc[2]:loc[3]], " ", ""), ",")
+		right = right[loc[1]:]
+	}
+
+	for _, part := range parts {
+		// Run-as users and tags carry over to the following commands
+		var state sudoersCommands
+		var order []string
+		rules := make(map[string]*spec.SudoersRuleSpec)
+
+		for _, item := range splitSudoersList(part.commands) {
+			if strings.HasPrefix(item, "(") {
+				end := strings.Index(item, ")")
+				if end < 0 {
+					return "unterminated run-as list"
+				}
+				state.runAs = normalizeSudoersRunAs(item[1:end])
+				item = strings.TrimSpace(item[end+1:])
+			}
+			for {
+				m := sudoersTag.FindStringSubmatch(item)
+				if m == nil {
+					break
+				}
+				state.setTag(m[1] + m[2])
+				item = item[len(m[0]):]
+			}
+
+			command := strings.ReplaceAll(strings.TrimSpace(item), `\,`, ",")
+			if command == "" {
+				return "missing command"
+			}
+
+			key := fmt.Sprint(state.runAs, state.noPasswd, state.tags)
+			rule, ok := rules[key]
+			if !ok {
+				rule = &spec.SudoersRuleSpec{
+					User:     user,
+					Hosts:    part.hosts,
+					RunAs:    state.runAs,
+					NoPasswd: state.noPasswd,
+					Tags:     append([]string(nil), state.tags...),
+					Source:   source,
+				}
+				sort.Strings(rule.Tags)
+				rules[key] = rule
+				order = append(order, key)
+			}
+			rule.Commands = append(rule.Commands, command)
+		}
+
+		for _, key := range order {
+			rule := *rules[key]
+			rule.ID = spec.SudoersRuleID(rule.User, rule.Hosts, rule.RunAs, rule.Commands)
+			policy.rules[rule.ID] = rule
+		}
+	}
+
+	return ""
+}
+
+// setTag applies a tag, which replaces its opposite (NOEXEC and EXEC,
+// SETENV and NOSETENV, ...)
+func (c *sudoersCommands) setTag(tag string) {
+	switch tag {
+	case "NOPASSWD":
+		c.noPasswd = true
+		return
+	case "PASSWD":
+		c.noPasswd = false
+		return
+	}
+
+	opposite := "NO" + tag
+	if strings.HasPrefix(tag, "NO") {
+		opposite = tag[2:]
+	}
+	var tags []string
+	for _, t := range c.tags {
+		if t != tag && t != opposite {
+			tags = append(tags, t)
+		}
+	}
+	c.tags = append(tags, tag)
+}
+
+// normalizeSudoersRunAs spaces a run-as list consistently, e.g.
+// "ALL:ALL" and "ALL : ALL" both become "ALL : ALL"
+func normalizeSudoersRunAs(runAs string) string {
+	users, groups, hasGroups := strings.Cut(runAs, ":")
+	normalize := func(list string) string {
+		items := splitSudoersList(list)
+		if len(items) == 1 && items[0] == "" {
+			return ""
+		}
+		return strings.Join(items, ", ")
+	}
+	if !hasGroups {
+		return normalize(users)
+	}
+	return strings.TrimSpace(normalize(users) + " : " + normalize(groups))
+}
@@ -0,0 +1,231 @@
+package scanners
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/supabase/supascan/internal/spec"
+)
+
+const testSudoers = `#
+# This file MUST be edited with the 'visudo' command as root.
+#
+Defaults	env_reset
+Defaults	mail_badpass
+Defaults	secure_path="/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+Defaults	use_pty, !lecture
+Defaults:postgres	!requiretty
+Defaults	env_keep += "HOME"
+Defaults	env_keep += "PGDATA"
+
+Cmnd_Alias PG_SERVICE = /usr/bin/systemctl restart postgresql, \
+                        /usr/bin/systemctl reload postgresql
+
+# User privilege specification
+root	ALL=(ALL:ALL) ALL
+
+# Members of group sudo may execute any command
+%sudo	ALL=(ALL : ALL) ALL
+
+#1001 ALL = (root) /usr/bin/id
+
+@includedir /etc/sudoers.d
+`
+
+func testSudoersFS() fstest.MapFS {
+	return fstest.MapFS{
+		"etc/sudoers":                       {Data: []byte(testSudoers)},
+		"etc/sudoers.d/90-cloud-init-users": {Data: []byte("# Created by cloud-init\nubuntu ALL=(ALL) NOPASSWD:ALL\n")},
+		"etc/sudoers.d/postgres": {Data: []byte("postgres ALL = (root) NOPASSWD: PG_SERVICE, /usr/bin/id, PASSWD: /usr/bin/su, (postgres) /usr/bin/psql\n" +
+			"adminapi, gotrue localhost = (root) SETENV: NOPASSWD: /usr/bin/salt\\, pepper # comment\n" +
+			"backup ALL = NOPASSWD: /usr/bin/rsync : db1, db2 = /usr/bin/pg_dump\n" +
+			"this line is broken\n" +
+			"Defaults~oops nothing\n")},
+		"etc/sudoers.d/README":     {Data: []byte("# Files in this directory are read by sudo\n")},
+		"etc/sudoers.d/backup.bak": {Data: []byte("ignored ALL=(ALL) ALL\n")},
+		"etc/sudoers.d/old~":       {Data: []byte("ignored ALL=(ALL) ALL\n")},
+	}
+}
+
+func TestSudoersScanner_Rules(t *testing.T) {
+	writer := spec.NewTestWriter()
+	stats, err := (&SudoersScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: testSudoersFS(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	const postgresSource = "/etc/sudoers.d/postgres"
+	expected := []spec.SudoersRuleSpec{
+		{User: "root", Hosts: []string{"ALL"}, RunAs: "ALL : ALL", Commands: []string{"ALL"}, Source: "/etc/sudoers"},
+		{User: "%sudo", Hosts: []string{"ALL"}, RunAs: "ALL : ALL", Commands: []string{"ALL"}, Source: "/etc/sudoers"},
+		{User: "#1001", Hosts: []string{"ALL"}, RunAs: "root", Commands: []string{"/usr/bin/id"}, Source: "/etc/sudoers"},
+		{User: "ubuntu", Hosts: []string{"ALL"}, RunAs: "ALL", Commands: []string{"ALL"}, NoPasswd: true, Source: "/etc/sudoers.d/90-cloud-init-users"},
+		{User: "postgres", Hosts: []string{"ALL"}, RunAs: "root", Commands: []string{"PG_SERVICE", "/usr/bin/id"}, NoPasswd: true, Source: postgresSource},
+		{User: "postgres", Hosts: []string{"ALL"}, RunAs: "root", Commands: []string{"/usr/bin/su"}, Source: postgresSource},
+		{User: "postgres", Hosts: []string{"ALL"}, RunAs: "postgres", Commands: []string{"/usr/bin/psql"}, Source: postgresSource},
+		{User: "adminapi,gotrue", Hosts: []string{"localhost"}, RunAs: "root", Commands: []string{"/usr/bin/salt, pepper"},
+			NoPasswd: true, Tags: []string{"SETENV"}, Source: postgresSource},
+		{User: "backup", Hosts: []string{"ALL"}, Commands: []string{"/usr/bin/rsync"}, NoPasswd: true, Source: postgresSource},
+		{User: "backup", Hosts: []string{"db1", "db2"}, Commands: []string{"/usr/bin/pg_dump"}, Source: postgresSource},
+	}
+
+	results := writer.GetSudoersRuleResults()
+	if len(results) != len(expected) {
+		t.Errorf("Expected %d rules, got %d: %v", len(expected), len(results), results)
+	}
+	for _, want := range expected {
+		want.ID = spec.SudoersRuleID(want.User, want.Hosts, want.RunAs, want.Commands)
+		got, ok := results[want.ID]
+		if !ok {
+			t.Errorf("Missing rule %q", want.ID)
+			continue
+		}
+		if got.User != want.User || !slices.Equal(got.Hosts, want.Hosts) || got.RunAs != want.RunAs ||
+			!slices.Equal(got.Commands, want.Commands) || got.NoPasswd != want.NoPasswd ||
+			!slices.Equal(got.Tags, want.Tags) || got.Source != want.Source {
+			t.Errorf("Rule %q = %+v, want %+v", want.ID, got, want)
+		}
+	}
+
+	// The rule without "=" and the unknown Defaults type
+	if len(stats.Warnings) != 2 {
+		t.Errorf("Expected 2 warnings, got %v", stats.Warnings)
+	}
+}
+
+func TestSudoersScanner_Defaults(t *testing.T) {
+	writer := spec.NewTestWriter()
+	_, err := (&SudoersScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: testSudoersFS(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"Defaults env_reset":           "true",
+		"Defaults mail_badpass":        "true",
+		"Defaults secure_path":         "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"Defaults use_pty":             "true",
+		"Defaults lecture":             "false",
+		"Defaults:postgres requiretty": "false",
+		"Defaults env_keep":            "+= HOME\n+= PGDATA",
+	}
+	results := writer.GetSudoersDefaultResults()
+	if len(results) != len(expected) {
+		t.Errorf("Expected %d settings, got %v", len(expected), results)
+	}
+	for id, want := range expected {
+		if got := results[id]; got.Value != want || got.Source != "/etc/sudoers" {
+			t.Errorf("%s = %+v, want value %q", id, got, want)
+		}
+	}
+}
+
+func TestSudoersScanner_NotInstalled(t *testing.T) {
+	writer := spec.NewTestWriter()
+	stats, err := (&SudoersScanner{rootPath: t.TempDir()}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := writer.GetResourceCount(); n != 0 || len(stats.Warnings) != 0 {
+		t.Errorf("Expected no resources and no warnings, got %d and %v", n, stats.Warnings)
+	}
+}
+
+// unreadableFS fails to open one file with a permission error, as a file
+// only readable by root does when not scanning as root
+type unreadableFS struct {
+	fstest.MapFS
+	name string
+}
+
+func (u unreadableFS) Open(name string) (fs.File, error) {
+	if name == u.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return u.MapFS.Open(name)
+}
+
+func (u unreadableFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(struct{ fs.FS }{u}, name)
+}
+
+func TestSudoersScanner_UnreadableInclude(t *testing.T) {
+	fsys := unreadableFS{MapFS: testSudoersFS(), name: "etc/sudoers.d/90-cloud-init-users"}
+
+	writer := spec.NewTestWriter()
+	stats, err := (&SudoersScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: fsys,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	want := "/etc/sudoers.d/90-cloud-init-users: permission denied, skipped"
+	if !slices.Contains(stats.Warnings, want) {
+		t.Errorf("Warnings = %v, want %q among them", stats.Warnings, want)
+	}
+
+	_, err = (&SudoersScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: spec.NewTestWriter(),
+		Logger: testLogger(),
+		RootFS: fsys,
+		Strict: true,
+	})
+	if err == nil {
+		t.Error("Expected an error for an unreadable include in strict mode")
+	}
+}
+
+func TestSudoersScanner_LinkedDirInIncludeDir(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "etc", "sudoers.d", "real"), 0755)
+	os.WriteFile(filepath.Join(root, "etc", "sudoers"), []byte("@includedir /etc/sudoers.d\n"), 0440)
+	os.WriteFile(filepath.Join(root, "etc", "sudoers.d", "postgres"), []byte("postgres ALL=(root) /usr/bin/id\n"), 0440)
+	if err := os.Symlink("real", filepath.Join(root, "etc", "sudoers.d", "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	writer := spec.NewTestWriter()
+	_, err := (&SudoersScanner{rootPath: root}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := len(writer.GetSudoersRuleResults()); n != 1 {
+		t.Errorf("Expected the rule from postgres only, got %d", n)
+	}
+}
+
+func TestSudoersScanner_IncludeLoop(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/sudoers": {Data: []byte("#include /etc/sudoers\n")},
+	}
+
+	_, err := (&SudoersScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: spec.NewTestWriter(),
+		Logger: testLogger(),
+		RootFS: fsys,
+	})
+	if err == nil {
+		t.Error("Expected an error for a recursive include")
+	}
+}
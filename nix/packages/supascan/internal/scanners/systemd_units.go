@@ -0,0 +1,296 @@
+package scanners
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// execRuntimeFields matches the parts of an Exec* property that describe
+// the last run of the command rather than its configuration
+var execRuntimeFields = regexp.MustCompile(` ; (start_time|stop_time|pid|code|status)=[^ ;}]*( [^ ;}]+)*`)
+
+// unitShowProperties are read for every unit, before the selected ones
+var unitShowProperties = []string{"Id", "Names", "LoadState", "ActiveState", "UnitFileState", "DropInPaths", "TimersCalendar", "TimersMonotonic"}
+
+// SystemdUnitScanner scans systemd units of every type: services, timers,
+// sockets, paths, mounts and so on. Each unit is recorded with its type,
+// enablement, active state, drop-in files and, for timers, the schedule.
+// Units matching Config.ExcludedUnits are skipped, and the units listed in
+// Config.Units also get the properties selected by Config.UnitProperties.
+type SystemdUnitScanner struct {
+	mockListUnits     string // For testing (systemctl list-units --output=json)
+	mockListUnitFiles string // For testing (systemctl list-unit-files --output=json)
+	mockSystemctlShow string // For testing
+	stats             ScanStats
+}
+
+func (s *SystemdUnitScanner) Name() string {
+	return "systemd-units"
+}
+
+func (s *SystemdUnitScanner) IsDynamic() bool {
+	return false // Unit configuration is relatively static
+}
+
+func (s *SystemdUnitScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
+	opts.Logger.Info("Starting systemd unit scan")
+
+	writer, ok := opts.Writer.(Writer)
+	if !ok {
+		return s.stats, fmt.Errorf("writer does not implement Writer interface")
+	}
+
+	if err := writer.StartResource("systemd-unit"); err != nil {
+		return s.stats, err
+	}
+
+	cfg, ok := opts.Config.(*config.Config)
+	if !ok && opts.Config != nil {
+		return s.stats, fmt.Errorf("config is not of type *config.Config")
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	units, err := s.getUnits(ctx, opts, cfg)
+	if err != nil {
+		return s.stats, err
+	}
+
+	for name, unit := range units {
+		if err := writer.Add(unit); err != nil {
+			return s.stats, fmt.Errorf("failed to write systemd unit spec for %s: %w", name, err)
+		}
+	}
+
+	opts.Logger.Info("systemd unit scan complete", "units_found", len(units))
+
+	return s.stats, nil
+}
+
+// runSystemctl runs systemctl, or returns the mock output when testing
+func (s *SystemdUnitScanner) runSystemctl(ctx context.Context, systemctlPath, mock string, args ...string) ([]byte, error) {
+	if s.mockSystemctlShow != "" {
+		return []byte(mock), nil
+	}
+	output, err := exec.CommandContext(ctx, systemctlPath, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("systemctl %s failed: %w", args[0], err)
+	}
+	return output, nil
+}
+
+// getUnits lists the loaded units and the installed unit files, then reads
+// all of them with a single "systemctl show"
+func (s *SystemdUnitScanner) getUnits(ctx context.Context, opts ScanOptions, cfg *config.Config) (map[string]spec.SystemdUnitSpec, error) {
+	var systemctlPath string
+	if s.mockSystemctlShow == "" {
+		var err error
+		if systemctlPath, err = exec.LookPath("systemctl"); err != nil {
+			opts.Logger.Warn("systemctl not found, skipping systemd unit scan (not a systemd system?)")
+			return nil, nil
+		}
+	}
+
+	output, err := s.runSystemctl(ctx, systemctlPath, s.mockListUnits, "list-units", "--all", "--no-pager", "--output=json")
+	if err != nil {
+		return nil, err
+	}
+	var loaded []systemdUnit
+	if err := json.Unmarshal(output, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse systemctl list-units output: %w", err)
+	}
+
+	output, err = s.runSystemctl(ctx, systemctlPath, s.mockListUnitFiles, "list-unit-files", "--no-pager", "--output=json")
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseUnitFiles(output)
+	if err != nil {
+		return nil, err
+	}
+
+	// Templates such as getty@.service are not units themselves, their
+	// instances are listed as loaded units
+	seen := make(map[string]bool)
+	var names []string
+	addName := func(name string) {
+		if name == "" || seen[name] || strings.Contains(name, "@.") || cfg.IsUnitExcluded(name) {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, unit := range loaded {
+		addName(unit.Unit)
+	}
+	for name := range files {
+		addName(name)
+	}
+	selected := uniqueStrings(cfg.Units)
+	for _, name := range selected {
+		addName(name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	properties := uniqueStrings(append(append([]string{}, unitShowProperties...), cfg.UnitProperties...))
+	args := append([]string{"show", "--no-pager", "--property=" + strings.Join(properties, ",")}, names...)
+	output, err = s.runSystemctl(ctx, systemctlPath, s.mockSystemctlShow, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSystemctlShow(string(output), names, selected, uniqueStrings(cfg.UnitProperties), opts)
+}
+
+// parseSystemctlShow parses the "Key=Value" blocks of "systemctl show",
+// one per unit, separated by blank lines. Blocks are matched to the
+// requested units by their Id, or for an alias, which is shown as the unit
+// it points to, by one of their Names. Units that are not installed are
+// skipped. Only the selected units get their properties recorded.
+func parseSystemctlShow(output string, units, selected, properties []string, opts ScanOptions) (map[string]spec.SystemdUnitSpec, error) {
+	result := make(map[string]spec.SystemdUnitSpec)
+
+	isSelected := make(map[string]bool, len(selected))
+	for _, name := range selected {
+		isSelected[name] = true
+	}
+	wanted := make(map[string]bool, len(properties))
+	for _, p := range properties {
+		wanted[p] = true
+	}
+
+	var blocks []map[string]string
+	var current map[string]string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			current = nil
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if current == nil {
+			current = make(map[string]string)
+			blocks = append(blocks, current)
+		}
+		// Properties such as ExecStartPre are printed once per command
+		if existing, ok := current[key]; ok {
+			value = existing + "\n" + value
+		}
+		current[key] = value
+	}
+
+	requested := make(map[string]bool, len(units))
+	for _, name := range units {
+		requested[name] = true
+	}
+	matched := make(map[string]map[string]string, len(units))
+	for _, block := range blocks {
+		id := block["Id"]
+		if id == "" {
+			return nil, fmt.Errorf("unexpected systemctl show output: block without an Id")
+		}
+		name := ""
+		for _, candidate := range append([]string{id}, strings.Fields(block["Names"])...) {
+			if requested[candidate] && matched[candidate] == nil {
+				name = candidate
+				break
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("unexpected systemctl show output: unit %s was not requested", id)
+		}
+		matched[name] = block
+	}
+
+	for _, name := range units {
+		block, ok := matched[name]
+		if !ok {
+			return nil, fmt.Errorf("unexpected systemctl show output: no properties for unit %s", name)
+		}
+		if block["LoadState"] == "not-found" {
+			opts.Logger.Debug("systemd unit not found, skipping", "unit", name)
+			continue
+		}
+
+		unit := spec.SystemdUnitSpec{
+			Name:       name,
+			Type:       name[strings.LastIndex(name, ".")+1:],
+			Enablement: block["UnitFileState"],
+			Active:     block["ActiveState"],
+			Schedule:   timerSchedule(block),
+			DropIns:    strings.Fields(block["DropInPaths"]),
+		}
+		sort.Strings(unit.DropIns)
+
+		if isSelected[name] {
+			unit.Properties = make(map[string]string)
+			for key, value := range block {
+				if !wanted[key] {
+					continue
+				}
+				if strings.HasPrefix(key, "Exec") {
+					value = execRuntimeFields.ReplaceAllString(value, "")
+				}
+				unit.Properties[key] = value
+			}
+			if len(unit.Properties) == 0 {
+				unit.Properties = nil
+			}
+		}
+
+		result[name] = unit
+	}
+
+	return result, nil
+}
+
+// timerSchedule extracts the triggers of a timer, e.g.
+// "OnCalendar=*-*-* 06:00:00" from
+// "{ OnCalendar=*-*-* 06:00:00 ; next_elapse=Tue 2024-06-04 06:00:00 UTC }",
+// one per line. It is empty for other units.
+func timerSchedule(block map[string]string) string {
+	var triggers []string
+	for _, key := range []string{"TimersCalendar", "TimersMonotonic"} {
+		for _, entry := range strings.Split(block[key], "\n") {
+			entry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(entry), "{ "), " }")
+			trigger, _, _ := strings.Cut(entry, " ; ")
+			if trigger != "" {
+				triggers = append(triggers, trigger)
+			}
+		}
+	}
+	return strings.Join(triggers, "\n")
+}
+
+// uniqueStrings returns items without duplicates, in their first order
+func uniqueStrings(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	var unique []string
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,240 @@
+package scanners
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+const testListUnits = `[
+{"unit":"dev-sda.device","load":"loaded","active":"active","sub":"plugged","description":"disk"},
+{"unit":"logrotate.timer","load":"loaded","active":"active","sub":"waiting","description":"Daily rotation of log files"},
+{"unit":"postgresql.service","load":"loaded","active":"active","sub":"running","description":"PostgreSQL database server"},
+{"unit":"ssh.service","load":"loaded","active":"inactive","sub":"dead","description":"OpenBSD Secure Shell server"}
+]`
+
+const testListUnitFiles = `[
+{"unit_file":"getty@.service","state":"enabled","preset":"enabled"},
+{"unit_file":"logrotate.timer","state":"enabled","preset":"enabled"},
+{"unit_file":"masked.service","state":"masked","preset":null},
+{"unit_file":"postgresql.service","state":"enabled","preset":"enabled"},
+{"unit_file":"ssh.service","state":"disabled","preset":"enabled"}
+]`
+
+// Blocks are matched by Id, not by the order of the units
+const testSystemctlShow = `Id=logrotate.timer
+Names=logrotate.timer
+LoadState=loaded
+ActiveState=active
+UnitFileState=enabled
+DropInPaths=
+TimersCalendar={ OnCalendar=*-*-* 00:00:00 ; next_elapse=Tue 2024-06-04 00:00:00 UTC }
+TimersMonotonic={ OnBootUSec=15min ; next_elapse=0 }
+
+Id=masked.service
+Names=masked.service
+LoadState=masked
+ActiveState=inactive
+UnitFileState=masked
+DropInPaths=
+
+Id=ssh.service
+Names=ssh.service
+User=
+NoNewPrivileges=no
+ProtectSystem=no
+LoadState=loaded
+ActiveState=inactive
+UnitFileState=disabled
+DropInPaths=
+
+Id=missing.service
+Names=missing.service
+LoadState=not-found
+ActiveState=inactive
+UnitFileState=
+DropInPaths=
+User=
+
+Id=postgresql.service
+Names=postgresql.service
+ExecStart={ path=/usr/lib/postgresql/bin/postgres ; argv[]=/usr/lib/postgresql/bin/postgres -D /etc/postgresql ; ignore_errors=no ; start_time=[Mon 2024-06-03 10:00:00 UTC] ; stop_time=[n/a] ; pid=1234 ; code=(null) ; status=0/0 }
+ExecStartPre={ path=/usr/local/bin/postgres_prestart.sh ; argv[]=/usr/local/bin/postgres_prestart.sh ; ignore_errors=no ; start_time=[n/a] ; stop_time=[n/a] ; pid=0 ; code=(null) ; status=0/0 }
+ExecStartPre={ path=/usr/bin/mkdir ; argv[]=/usr/bin/mkdir -p /run/postgresql ; ignore_errors=yes ; start_time=[n/a] ; stop_time=[n/a] ; pid=0 ; code=(null) ; status=0/0 }
+User=postgres
+NoNewPrivileges=no
+ProtectSystem=full
+LoadState=loaded
+DropInPaths=/etc/systemd/system/postgresql.service.d/override.conf /etc/systemd/system/postgresql.service.d/10-limits.conf
+ActiveState=active
+UnitFileState=enabled
+`
+
+func testSystemdUnitScanner() *SystemdUnitScanner {
+	return &SystemdUnitScanner{
+		mockListUnits:     testListUnits,
+		mockListUnitFiles: testListUnitFiles,
+		mockSystemctlShow: testSystemctlShow,
+	}
+}
+
+func testUnitConfig() *config.Config {
+	return &config.Config{
+		Units:          []string{"postgresql.service", "ssh.service", "missing.service"},
+		UnitProperties: []string{"ExecStart", "ExecStartPre", "User", "NoNewPrivileges", "ProtectSystem"},
+		ExcludedUnits:  []string{"*.device"},
+	}
+}
+
+func TestSystemdUnitScanner_BasicScan(t *testing.T) {
+	writer := spec.NewTestWriter()
+
+	_, err := testSystemdUnitScanner().Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Config: testUnitConfig(),
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetSystemdUnitResults()
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 units, got %d: %v", len(results), results)
+	}
+
+	postgres := results["postgresql.service"]
+	if postgres.Type != "service" || postgres.Enablement != "enabled" || postgres.Active != "active" || postgres.Schedule != "" {
+		t.Errorf("Unexpected postgresql.service state: %+v", postgres)
+	}
+	expected := map[string]string{
+		"ExecStart": "{ path=/usr/lib/postgresql/bin/postgres ; argv[]=/usr/lib/postgresql/bin/postgres -D /etc/postgresql ; ignore_errors=no }",
+		"ExecStartPre": "{ path=/usr/local/bin/postgres_prestart.sh ; argv[]=/usr/local/bin/postgres_prestart.sh ; ignore_errors=no }\n" +
+			"{ path=/usr/bin/mkdir ; argv[]=/usr/bin/mkdir -p /run/postgresql ; ignore_errors=yes }",
+		"User":            "postgres",
+		"NoNewPrivileges": "no",
+		"ProtectSystem":   "full",
+	}
+	if len(postgres.Properties) != len(expected) {
+		t.Errorf("Expected %d properties, got %v", len(expected), postgres.Properties)
+	}
+	for key, want := range expected {
+		if got := postgres.Properties[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if len(postgres.DropIns) != 2 || postgres.DropIns[0] != "/etc/systemd/system/postgresql.service.d/10-limits.conf" {
+		t.Errorf("Unexpected drop-ins: %v", postgres.DropIns)
+	}
+
+	ssh := results["ssh.service"]
+	if ssh.DropIns == nil || len(ssh.DropIns) != 0 {
+		t.Errorf("Expected an empty, non-nil drop-in list, got %#v", ssh.DropIns)
+	}
+	if user, ok := ssh.Properties["User"]; !ok || user != "" {
+		t.Errorf("Expected an empty User property, got %q (set: %v)", user, ok)
+	}
+	if ssh.Enablement != "disabled" || ssh.Active != "inactive" {
+		t.Errorf("Unexpected ssh.service state: %+v", ssh)
+	}
+}
+
+func TestSystemdUnitScanner_AllUnitTypes(t *testing.T) {
+	writer := spec.NewTestWriter()
+
+	_, err := testSystemdUnitScanner().Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Config: testUnitConfig(),
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetSystemdUnitResults()
+
+	timer, ok := results["logrotate.timer"]
+	if !ok {
+		t.Fatal("Expected logrotate.timer to be recorded")
+	}
+	if timer.Type != "timer" || timer.Enablement != "enabled" || timer.Active != "active" {
+		t.Errorf("Unexpected timer state: %+v", timer)
+	}
+	if want := "OnCalendar=*-*-* 00:00:00\nOnBootUSec=15min"; timer.Schedule != want {
+		t.Errorf("Schedule = %q, want %q", timer.Schedule, want)
+	}
+	if timer.Properties != nil {
+		t.Errorf("Expected no properties for an unselected unit, got %v", timer.Properties)
+	}
+
+	masked := results["masked.service"]
+	if masked.Enablement != "masked" || masked.Active != "inactive" {
+		t.Errorf("Unexpected masked unit state: %+v", masked)
+	}
+
+	for _, name := range []string{"dev-sda.device", "getty@.service", "missing.service"} {
+		if _, ok := results[name]; ok {
+			t.Errorf("Did not expect %s to be recorded", name)
+		}
+	}
+}
+
+func TestSystemdUnitScanner_NoUnits(t *testing.T) {
+	scanner := &SystemdUnitScanner{mockListUnits: "[]", mockListUnitFiles: "[]", mockSystemctlShow: testSystemctlShow}
+	writer := spec.NewTestWriter()
+
+	_, err := scanner.Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if n := len(writer.GetSystemdUnitResults()); n != 0 {
+		t.Errorf("Expected no units when none are listed, got %d", n)
+	}
+}
+
+func TestSystemdUnitScanner_UnexpectedOutput(t *testing.T) {
+	// No block has the Id of dev-sda.device, so the units cannot be matched up
+	cfg := testUnitConfig()
+	cfg.ExcludedUnits = nil
+	_, err := testSystemdUnitScanner().Scan(context.Background(), ScanOptions{
+		Writer: spec.NewTestWriter(),
+		Config: cfg,
+		Logger: testLogger(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "dev-sda.device") {
+		t.Errorf("Expected an error naming dev-sda.device, got %v", err)
+	}
+}
+
+func TestSystemdUnitScanner_Aliases(t *testing.T) {
+	// An alias is shown as the unit it points to, in either order
+	scanner := &SystemdUnitScanner{
+		mockListUnits:     `[{"unit":"ssh.service","load":"loaded","active":"active","sub":"running","description":"ssh"}]`,
+		mockListUnitFiles: `[{"unit_file":"ssh.service","state":"enabled"},{"unit_file":"sshd.service","state":"alias"}]`,
+		mockSystemctlShow: "Id=ssh.service\nNames=ssh.service sshd.service\nLoadState=loaded\nActiveState=active\nUnitFileState=enabled\n\n" +
+			"Id=ssh.service\nNames=ssh.service sshd.service\nLoadState=loaded\nActiveState=active\nUnitFileState=alias\n",
+	}
+	writer := spec.NewTestWriter()
+
+	_, err := scanner.Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	results := writer.GetSystemdUnitResults()
+	if len(results) != 2 {
+		t.Fatalf("Expected ssh.service and its alias, got %v", results)
+	}
+	if results["ssh.service"].Name != "ssh.service" || results["sshd.service"].Name != "sshd.service" {
+		t.Errorf("Unexpected units: %v", results)
+	}
+}
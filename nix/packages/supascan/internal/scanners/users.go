@@ -4,16 +4,20 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/supabase/supascan/internal/spec"
 )
 
-// UserScanner scans all users from /etc/passwd.
+// UserScanner scans all users from /etc/passwd, with their group
+// membership from /etc/group.
 type UserScanner struct {
 	passwdPath string // For testing (default: "/etc/passwd")
+	groupPath  string // For testing (default: "/etc/group")
 	stats      ScanStats
 }
 
@@ -25,6 +29,10 @@ func (s *UserScanner) IsDynamic() bool {
 	return false // User accounts are relatively static
 }
 
+func (s *UserScanner) SupportsOffline() bool {
+	return true
+}
+
 func (s *UserScanner) Scan(ctx context.Context, opts ScanOptions) (ScanStats, error) {
 	opts.Logger.Info("Starting user scan")
 
@@ -64,7 +72,13 @@ func (s *UserScanner) getUsers(opts ScanOptions) (map[string]spec.UserSpec, erro
 		passwdPath = "/etc/passwd"
 	}
 
-	file, err := os.Open(passwdPath)
+	var file io.ReadCloser
+	var err error
+	if s.passwdPath != "" {
+		file, err = os.Open(passwdPath)
+	} else {
+		file, err = openSystemFile(opts, passwdPath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open %s: %w", passwdPath, err)
 	}
@@ -114,8 +128,8 @@ func (s *UserScanner) getUsers(opts ScanOptions) (map[string]spec.UserSpec, erro
 		users[username] = spec.UserSpec{
 			Username: username,
 			Exists:   true,
-			UID:      uid,
-			GID:      gid,
+			UID:      &uid,
+			GID:      &gid,
 			Home:     home,
 			Shell:    shell,
 		}
@@ -125,5 +139,52 @@ func (s *UserScanner) getUsers(opts ScanOptions) (map[string]spec.UserSpec, erro
 		return nil, fmt.Errorf("error reading %s: %w", passwdPath, err)
 	}
 
+	s.addGroups(users, opts)
+
 	return users, nil
 }
+
+// addGroups fills in the groups of each user as "id -Gn" reports them: the
+// primary group and every group listing the user as a member. Users are
+// recorded without groups if the group file cannot be read.
+func (s *UserScanner) addGroups(users map[string]spec.UserSpec, opts ScanOptions) {
+	groups, err := (&GroupScanner{groupPath: s.groupPath}).getGroups(opts)
+	if err != nil {
+		opts.Logger.Warn("Failed to read groups, recording users without them", "error", err)
+		return
+	}
+
+	// Several groups may share a GID, the first name in sorted order wins
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	primary := make(map[int]string)
+	memberOf := make(map[string][]string)
+	for _, name := range names {
+		group := groups[name]
+		if _, ok := primary[*group.GID]; !ok {
+			primary[*group.GID] = name
+		}
+		for _, member := range group.Members {
+			memberOf[member] = append(memberOf[member], name)
+		}
+	}
+
+	for username, user := range users {
+		var userGroups []string
+		if name, ok := primary[*user.GID]; ok {
+			userGroups = append(userGroups, name)
+		}
+		for _, name := range memberOf[username] {
+			if name != primary[*user.GID] {
+				userGroups = append(userGroups, name)
+			}
+		}
+		sort.Strings(userGroups)
+		user.Groups = userGroups
+		users[username] = user
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"testing/fstest"
 
 	"github.com/supabase/supascan/internal/spec"
 )
@@ -48,8 +50,8 @@ nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin
 	if !ok {
 		t.Fatalf("root user not found")
 	}
-	if root.UID != 0 {
-		t.Errorf("Expected root UID=0, got %d", root.UID)
+	if root.UID == nil || *root.UID != 0 {
+		t.Errorf("Expected root UID=0, got %v", root.UID)
 	}
 	if root.Home != "/root" {
 		t.Errorf("Expected root home=/root, got %s", root.Home)
@@ -63,8 +65,8 @@ nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin
 	if !ok {
 		t.Fatalf("ubuntu user not found")
 	}
-	if ubuntu.UID != 1000 {
-		t.Errorf("Expected ubuntu UID=1000, got %d", ubuntu.UID)
+	if ubuntu.UID == nil || *ubuntu.UID != 1000 {
+		t.Errorf("Expected ubuntu UID=1000, got %v", ubuntu.UID)
 	}
 
 	// Check stats
@@ -73,6 +75,46 @@ nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin
 	}
 }
 
+func TestUserScanner_Groups(t *testing.T) {
+	tmpDir := t.TempDir()
+	passwdFile := filepath.Join(tmpDir, "passwd")
+	groupFile := filepath.Join(tmpDir, "group")
+
+	os.WriteFile(passwdFile, []byte(`root:x:0:0:root:/root:/bin/bash
+ubuntu:x:1000:1000:Ubuntu User:/home/ubuntu:/bin/bash
+postgres:x:105:106::/var/lib/postgresql:/bin/bash
+orphan:x:2000:2000::/home/orphan:/bin/sh
+`), 0644)
+	os.WriteFile(groupFile, []byte(`root:x:0:
+adm:x:4:syslog,ubuntu
+sudo:x:27:ubuntu
+ssl-cert:x:110:postgres
+postgres:x:106:
+ubuntu:x:1000:ubuntu
+`), 0644)
+
+	writer := spec.NewTestWriter()
+	_, err := (&UserScanner{passwdPath: passwdFile, groupPath: groupFile}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	want := map[string][]string{
+		"root":     {"root"},
+		"ubuntu":   {"adm", "sudo", "ubuntu"}, // Primary group listed once
+		"postgres": {"postgres", "ssl-cert"},
+		"orphan":   nil, // Primary group has no entry
+	}
+	for username, groups := range want {
+		if got := writer.GetUserResults()[username].Groups; !reflect.DeepEqual(got, groups) {
+			t.Errorf("%s groups = %v, want %v", username, got, groups)
+		}
+	}
+}
+
 func TestUserScanner_Properties(t *testing.T) {
 	scanner := &UserScanner{}
 
@@ -120,3 +162,24 @@ ubuntu:x:1000:1000:Ubuntu:/home/ubuntu:/bin/bash
 		t.Errorf("Expected 3 valid users, got %d", len(results))
 	}
 }
+
+func TestUserScanner_OfflineRoot(t *testing.T) {
+	rootFS := fstest.MapFS{
+		"etc/passwd": &fstest.MapFile{Data: []byte("root:x:0:0:root:/root:/bin/bash\npostgres:x:105:106::/var/lib/postgresql:/bin/bash\n")},
+	}
+
+	writer := spec.NewTestWriter()
+	_, err := (&UserScanner{}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Logger: testLogger(),
+		RootFS: rootFS,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	users := writer.GetUserResults()
+	if len(users) != 2 || *users["postgres"].UID != 105 {
+		t.Errorf("Expected users from the image's passwd file, got %v", users)
+	}
+}
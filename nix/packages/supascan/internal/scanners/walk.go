@@ -0,0 +1,146 @@
+package scanners
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// queuedDir is a directory whose entries have yet to be read
+type queuedDir struct {
+	path  string
+	entry fs.DirEntry
+}
+
+// parallelWalker reads directories on a bounded pool of goroutines. It
+// calls fn with the same paths, entries and errors as fs.WalkDir, but not
+// in lexical order and from several goroutines at once.
+type parallelWalker struct {
+	fsys fs.FS
+	fn   fs.WalkDirFunc
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []queuedDir
+	pending int // Directories queued or being read
+	err     error
+}
+
+// walkDirParallel walks fsys from "." like fs.WalkDir, reading up to workers
+// directories concurrently. fn must be safe for concurrent use. SkipDir and
+// SkipAll are honoured as in WalkDir; any other error from fn stops the walk
+// and is returned.
+func walkDirParallel(fsys fs.FS, workers int, fn fs.WalkDirFunc) error {
+	const root = "."
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkRoot(fsys, root, fs.FileInfoToDirEntry(info), workers, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkRoot(fsys fs.FS, root string, d fs.DirEntry, workers int, fn fs.WalkDirFunc) error {
+	if err := fn(root, d, nil); err != nil || !d.IsDir() {
+		return err
+	}
+
+	w := &parallelWalker{fsys: fsys, fn: fn}
+	w.cond = sync.NewCond(&w.mu)
+	w.queue = []queuedDir{{path: root, entry: d}}
+	w.pending = 1
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+
+	return w.err
+}
+
+// work reads queued directories until the walk is finished or has failed
+func (w *parallelWalker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.pending > 0 && w.err == nil {
+			w.cond.Wait()
+		}
+		if w.pending == 0 || w.err != nil {
+			w.mu.Unlock()
+			return
+		}
+
+		// Depth-first keeps the queue short
+		dir := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		subdirs, err := w.readDir(dir)
+
+		w.mu.Lock()
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.queue = append(w.queue, subdirs...)
+		w.pending += len(subdirs) - 1
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// readDir visits the entries of one directory and returns the
+// subdirectories to descend into
+func (w *parallelWalker) readDir(dir queuedDir) ([]queuedDir, error) {
+	entries, err := fs.ReadDir(w.fsys, dir.path)
+	if err != nil {
+		// Report the read error against the directory, as WalkDir does
+		if err := w.fn(dir.path, dir.entry, err); err != nil {
+			if err == filepath.SkipDir {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	var subdirs []queuedDir
+	for _, entry := range entries {
+		if w.failed() {
+			return nil, nil
+		}
+
+		entryPath := path.Join(dir.path, entry.Name())
+		if err := w.fn(entryPath, entry, nil); err != nil {
+			if err == filepath.SkipDir {
+				if entry.IsDir() {
+					continue
+				}
+				// SkipDir on a file skips the rest of its directory
+				break
+			}
+			return nil, err
+		}
+
+		if entry.IsDir() {
+			subdirs = append(subdirs, queuedDir{path: entryPath, entry: entry})
+		}
+	}
+
+	return subdirs, nil
+}
+
+// failed reports whether another worker has stopped the walk
+func (w *parallelWalker) failed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err != nil
+}
@@ -0,0 +1,59 @@
+package scanners
+
+import "fmt"
+
+// ResourceWriter is implemented by writers that are safe for concurrent use.
+// AddResource names the resource type explicitly instead of relying on the
+// type set by the last StartResource call, which concurrent scanners would
+// overwrite for each other.
+type ResourceWriter interface {
+	Writer
+	AddResource(resourceType string, spec interface{}) error
+}
+
+// scopedWriter gives one scanner its own current resource type while
+// forwarding resources to a shared ResourceWriter
+type scopedWriter struct {
+	parent          ResourceWriter
+	currentResource string
+}
+
+func newScopedWriter(parent ResourceWriter) *scopedWriter {
+	return &scopedWriter{parent: parent}
+}
+
+// WriteHeader forwards the header to the shared writer
+func (w *scopedWriter) WriteHeader(comment string) error {
+	return w.parent.WriteHeader(comment)
+}
+
+// StartResource sets this scanner's current resource type. The shared
+// writer is told too, so it can create the section even if it stays empty.
+func (w *scopedWriter) StartResource(resourceType string) error {
+	w.currentResource = resourceType
+	return w.parent.StartResource(resourceType)
+}
+
+// Add forwards a spec to the shared writer under the current resource type
+func (w *scopedWriter) Add(spec interface{}) error {
+	if w.currentResource == "" {
+		return fmt.Errorf("no resource started, call StartResource first")
+	}
+	return w.parent.AddResource(w.currentResource, spec)
+}
+
+// AddResource forwards a spec to the shared writer
+func (w *scopedWriter) AddResource(resourceType string, spec interface{}) error {
+	return w.parent.AddResource(resourceType, spec)
+}
+
+// Flush forwards to the shared writer
+func (w *scopedWriter) Flush() error {
+	return w.parent.Flush()
+}
+
+// Close is a no-op, the shared writer is closed by its owner once every
+// scanner has finished
+func (w *scopedWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package scanners
+
+import (
+	"errors"
+	"syscall"
+)
+
+// getxattr reads an extended attribute of the file at path, following
+// symlinks. A missing attribute, or a filesystem without xattr support,
+// yields nil.
+func getxattr(path, attr string) ([]byte, error) {
+	for {
+		size, err := syscall.Getxattr(path, attr, nil)
+		if err != nil {
+			return nil, xattrError(err)
+		}
+		if size == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := syscall.Getxattr(path, attr, buf)
+		if errors.Is(err, syscall.ERANGE) {
+			// The attribute grew between the calls
+			continue
+		}
+		if err != nil {
+			return nil, xattrError(err)
+		}
+		return buf[:n], nil
+	}
+}
+
+func xattrError(err error) error {
+	if errors.Is(err, syscall.ENODATA) || errors.Is(err, syscall.ENOTSUP) {
+		return nil
+	}
+	return err
+}
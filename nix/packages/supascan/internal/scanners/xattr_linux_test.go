@@ -0,0 +1,58 @@
+package scanners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+func TestFileScanner_SpecialBitsAndCapabilities(t *testing.T) {
+	tmpDir := t.TempDir()
+	setuid := filepath.Join(tmpDir, "su")
+	ping := filepath.Join(tmpDir, "ping")
+	os.WriteFile(setuid, []byte("binary"), 0755)
+	os.WriteFile(ping, []byte("binary"), 0755)
+	if err := os.Chmod(setuid, 0755|os.ModeSetuid); err != nil {
+		t.Fatalf("Failed to set setuid bit: %v", err)
+	}
+
+	// cap_net_raw=ep, as set by "setcap cap_net_raw=ep"
+	hasCaps := syscall.Setxattr(ping, capabilityXattr, []byte{
+		0x01, 0x00, 0x00, 0x02, // VFS_CAP_REVISION_2 | VFS_CAP_FLAGS_EFFECTIVE
+		0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}, 0) == nil
+
+	writer := spec.NewTestWriter()
+	_, err := (&FileScanner{rootPath: tmpDir}).Scan(context.Background(), ScanOptions{
+		Writer: writer,
+		Config: &config.Config{Capabilities: true},
+		Logger: testLogger(),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	files := writer.GetFileResults()
+	if files[setuid].Mode != "4755" {
+		t.Errorf("Expected setuid mode 4755, got %s", files[setuid].Mode)
+	}
+	if files[setuid].Capabilities != "" {
+		t.Errorf("Expected no capabilities, got %s", files[setuid].Capabilities)
+	}
+
+	if !hasCaps {
+		t.Skip("Setting security.capability needs CAP_SETFCAP")
+	}
+	if files[ping].Capabilities != "cap_net_raw=ep" {
+		t.Errorf("Expected cap_net_raw=ep, got %q", files[ping].Capabilities)
+	}
+	if fileSpec, err := LookupFile(ping); err != nil || fileSpec.Capabilities != "cap_net_raw=ep" {
+		t.Errorf("LookupFile(ping) = %+v, %v", fileSpec, err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package scanners
+
+// getxattr reports no extended attributes where file capabilities do not
+// exist
+func getxattr(path, attr string) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,533 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ResourceTypes lists the resource sections a Baseline understands, in the
+// order they are reported
+var ResourceTypes = []string{
+	"file",
+	"package",
+	"service",
+	"systemd-unit",
+	"cron-job",
+	"user",
+	"group",
+	"shadow",
+	"sudoers-rule",
+	"sudoers-default",
+	"kernel-param",
+	"sshd-config",
+	"postgres-config",
+	"postgres-hba",
+	"postgres-ident",
+	"mount",
+	"port",
+	"process",
+	"command",
+	"finding",
+}
+
+// Baseline is a typed, in-memory view of a GOSS spec. It can be loaded from
+// a spec file with LoadBaseline or filled by scanners, since it also
+// implements the writer methods used by the scanners package. The writer
+// methods are safe for concurrent use.
+type Baseline struct {
+	Files           map[string]FileSpec           `yaml:"file,omitempty" json:"file,omitempty"`
+	Packages        map[string]PackageSpec        `yaml:"package,omitempty" json:"package,omitempty"`
+	Services        map[string]ServiceSpec        `yaml:"service,omitempty" json:"service,omitempty"`
+	SystemdUnits    map[string]SystemdUnitSpec    `yaml:"systemd-unit,omitempty" json:"systemd-unit,omitempty"`
+	CronJobs        map[string]CronJobSpec        `yaml:"cron-job,omitempty" json:"cron-job,omitempty"`
+	Users           map[string]UserSpec           `yaml:"user,omitempty" json:"user,omitempty"`
+	Groups          map[string]GroupSpec          `yaml:"group,omitempty" json:"group,omitempty"`
+	Shadow          map[string]ShadowSpec         `yaml:"shadow,omitempty" json:"shadow,omitempty"`
+	SudoersRules    map[string]SudoersRuleSpec    `yaml:"sudoers-rule,omitempty" json:"sudoers-rule,omitempty"`
+	SudoersDefaults map[string]SudoersDefaultSpec `yaml:"sudoers-default,omitempty" json:"sudoers-default,omitempty"`
+	KernelParams    map[string]KernelParamSpec    `yaml:"kernel-param,omitempty" json:"kernel-param,omitempty"`
+	SshdConfig      map[string]SshdConfigSpec     `yaml:"sshd-config,omitempty" json:"sshd-config,omitempty"`
+	PostgresConfig  map[string]PostgresConfigSpec `yaml:"postgres-config,omitempty" json:"postgres-config,omitempty"`
+	PostgresHba     map[string]PostgresHbaSpec    `yaml:"postgres-hba,omitempty" json:"postgres-hba,omitempty"`
+	PostgresIdent   map[string]PostgresIdentSpec  `yaml:"postgres-ident,omitempty" json:"postgres-ident,omitempty"`
+	Mounts          map[string]MountSpec          `yaml:"mount,omitempty" json:"mount,omitempty"`
+	Ports           map[string]PortSpec           `yaml:"port,omitempty" json:"port,omitempty"`
+	Processes       map[string]ProcessSpec        `yaml:"process,omitempty" json:"process,omitempty"`
+	Commands        map[string]CommandSpec        `yaml:"command,omitempty" json:"command,omitempty"`
+	Findings        map[string]FindingSpec        `yaml:"finding,omitempty" json:"finding,omitempty"`
+
+	// declared holds the resource types a loaded spec has a section for,
+	// even an empty one, which asserts that there are none of them
+	declared map[string]bool
+
+	// unknown holds the resource keys of the sections of a loaded spec that
+	// are not resource types a Baseline understands, e.g. goss's http
+	unknown map[string][]string
+
+	mu              sync.Mutex
+	currentResource string
+}
+
+// NewBaseline creates an empty baseline
+func NewBaseline() *Baseline {
+	b := &Baseline{}
+	b.init()
+	return b
+}
+
+// LoadBaseline reads a GOSS spec file into a Baseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+
+	b := &Baseline{}
+	if err := yaml.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse spec file %s: %w", path, err)
+	}
+	var sections map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("failed to parse spec file %s: %w", path, err)
+	}
+	b.init()
+	for resourceType, section := range sections {
+		b.declared[resourceType] = true
+		if !slices.Contains(ResourceTypes, resourceType) {
+			keys := []string{}
+			for i := 0; i+1 < len(section.Content); i += 2 {
+				keys = append(keys, section.Content[i].Value)
+			}
+			b.unknown[resourceType] = keys
+		}
+	}
+
+	// Resource keys are map keys in the spec, copy them into the specs
+	for k, v := range b.Files {
+		v.Path = k
+		b.Files[k] = v
+	}
+	for k, v := range b.Packages {
+		v.Name = k
+		b.Packages[k] = v
+	}
+	for k, v := range b.Services {
+		v.Name = k
+		b.Services[k] = v
+	}
+	for k, v := range b.SystemdUnits {
+		v.Name = k
+		b.SystemdUnits[k] = v
+	}
+	for k, v := range b.CronJobs {
+		v.ID = k
+		b.CronJobs[k] = v
+	}
+	for k, v := range b.Users {
+		v.Username = k
+		b.Users[k] = v
+	}
+	for k, v := range b.Groups {
+		v.Name = k
+		b.Groups[k] = v
+	}
+	for k, v := range b.Shadow {
+		v.Username = k
+		b.Shadow[k] = v
+	}
+	for k, v := range b.SudoersRules {
+		v.ID = k
+		b.SudoersRules[k] = v
+	}
+	for k, v := range b.SudoersDefaults {
+		v.ID = k
+		b.SudoersDefaults[k] = v
+	}
+	for k, v := range b.KernelParams {
+		v.Key = k
+		b.KernelParams[k] = v
+	}
+	for k, v := range b.SshdConfig {
+		v.Key = k
+		b.SshdConfig[k] = v
+	}
+	for k, v := range b.PostgresConfig {
+		v.Name = k
+		b.PostgresConfig[k] = v
+	}
+	for k, v := range b.PostgresHba {
+		v.ID = k
+		b.PostgresHba[k] = v
+	}
+	for k, v := range b.PostgresIdent {
+		v.ID = k
+		b.PostgresIdent[k] = v
+	}
+	for k, v := range b.Mounts {
+		v.Path = k
+		b.Mounts[k] = v
+	}
+	for k, v := range b.Ports {
+		v.Port = k
+		b.Ports[k] = v
+	}
+	for k, v := range b.Processes {
+		v.Comm = k
+		b.Processes[k] = v
+	}
+	for k, v := range b.Commands {
+		v.Command = k
+		b.Commands[k] = v
+	}
+	for k, v := range b.Findings {
+		v.ID = k
+		b.Findings[k] = v
+	}
+
+	return b, nil
+}
+
+// Load reads a baseline from either a monolithic spec file or a directory of
+// split spec files (as written by "supascan split")
+func Load(path string) (*Baseline, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("baseline not found: %w", err)
+	}
+	if !info.IsDir() {
+		return LoadBaseline(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline directory: %w", err)
+	}
+
+	merged := NewBaseline()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ext := filepath.Ext(name); ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+
+		b, err := LoadBaseline(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		merged.Merge(b)
+	}
+
+	return merged, nil
+}
+
+// Merge adds every resource from other, replacing resources with the same key
+func (b *Baseline) Merge(other *Baseline) {
+	b.init()
+	for resourceType := range other.declared {
+		b.declared[resourceType] = true
+	}
+	for resourceType, keys := range other.unknown {
+		b.unknown[resourceType] = append(b.unknown[resourceType], keys...)
+	}
+	for _, resourceType := range ResourceTypes {
+		for _, r := range other.Resources(resourceType) {
+			b.Add(r)
+		}
+	}
+}
+
+// Resources returns the resources of one type keyed by their identifier.
+// Unknown resource types return nil.
+func (b *Baseline) Resources(resourceType string) map[string]interface{} {
+	var resources map[string]interface{}
+	add := func(key string, spec interface{}) {
+		if resources == nil {
+			resources = make(map[string]interface{})
+		}
+		resources[key] = spec
+	}
+
+	switch resourceType {
+	case "file":
+		for k, v := range b.Files {
+			add(k, v)
+		}
+	case "package":
+		for k, v := range b.Packages {
+			add(k, v)
+		}
+	case "service":
+		for k, v := range b.Services {
+			add(k, v)
+		}
+	case "systemd-unit":
+		for k, v := range b.SystemdUnits {
+			add(k, v)
+		}
+	case "cron-job":
+		for k, v := range b.CronJobs {
+			add(k, v)
+		}
+	case "user":
+		for k, v := range b.Users {
+			add(k, v)
+		}
+	case "group":
+		for k, v := range b.Groups {
+			add(k, v)
+		}
+	case "shadow":
+		for k, v := range b.Shadow {
+			add(k, v)
+		}
+	case "sudoers-rule":
+		for k, v := range b.SudoersRules {
+			add(k, v)
+		}
+	case "sudoers-default":
+		for k, v := range b.SudoersDefaults {
+			add(k, v)
+		}
+	case "kernel-param":
+		for k, v := range b.KernelParams {
+			add(k, v)
+		}
+	case "sshd-config":
+		for k, v := range b.SshdConfig {
+			add(k, v)
+		}
+	case "postgres-config":
+		for k, v := range b.PostgresConfig {
+			add(k, v)
+		}
+	case "postgres-hba":
+		for k, v := range b.PostgresHba {
+			add(k, v)
+		}
+	case "postgres-ident":
+		for k, v := range b.PostgresIdent {
+			add(k, v)
+		}
+	case "mount":
+		for k, v := range b.Mounts {
+			add(k, v)
+		}
+	case "port":
+		for k, v := range b.Ports {
+			add(k, v)
+		}
+	case "process":
+		for k, v := range b.Processes {
+			add(k, v)
+		}
+	case "command":
+		for k, v := range b.Commands {
+			add(k, v)
+		}
+	case "finding":
+		for k, v := range b.Findings {
+			add(k, v)
+		}
+	}
+
+	return resources
+}
+
+// UnknownSections returns the resource keys of the sections of a loaded
+// spec that are not in ResourceTypes, by section. Loading drops them, so
+// callers report them rather than treat them as checked.
+func (b *Baseline) UnknownSections() map[string][]string {
+	return b.unknown
+}
+
+// Declares reports whether the baseline makes a claim about a resource
+// type: it lists some resources of the type, or was loaded from a spec with
+// a section for it, even an empty one
+func (b *Baseline) Declares(resourceType string) bool {
+	return b.declared[resourceType] || len(b.Resources(resourceType)) > 0
+}
+
+// Keys returns the sorted resource keys of one type
+func (b *Baseline) Keys(resourceType string) []string {
+	resources := b.Resources(resourceType)
+	keys := make([]string, 0, len(resources))
+	for k := range resources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// init allocates any nil resource maps
+func (b *Baseline) init() {
+	if b.declared == nil {
+		b.declared = make(map[string]bool)
+	}
+	if b.unknown == nil {
+		b.unknown = make(map[string][]string)
+	}
+	if b.Files == nil {
+		b.Files = make(map[string]FileSpec)
+	}
+	if b.Packages == nil {
+		b.Packages = make(map[string]PackageSpec)
+	}
+	if b.Services == nil {
+		b.Services = make(map[string]ServiceSpec)
+	}
+	if b.SystemdUnits == nil {
+		b.SystemdUnits = make(map[string]SystemdUnitSpec)
+	}
+	if b.CronJobs == nil {
+		b.CronJobs = make(map[string]CronJobSpec)
+	}
+	if b.Users == nil {
+		b.Users = make(map[string]UserSpec)
+	}
+	if b.Groups == nil {
+		b.Groups = make(map[string]GroupSpec)
+	}
+	if b.Shadow == nil {
+		b.Shadow = make(map[string]ShadowSpec)
+	}
+	if b.SudoersRules == nil {
+		b.SudoersRules = make(map[string]SudoersRuleSpec)
+	}
+	if b.SudoersDefaults == nil {
+		b.SudoersDefaults = make(map[string]SudoersDefaultSpec)
+	}
+	if b.KernelParams == nil {
+		b.KernelParams = make(map[string]KernelParamSpec)
+	}
+	if b.SshdConfig == nil {
+		b.SshdConfig = make(map[string]SshdConfigSpec)
+	}
+	if b.PostgresConfig == nil {
+		b.PostgresConfig = make(map[string]PostgresConfigSpec)
+	}
+	if b.PostgresHba == nil {
+		b.PostgresHba = make(map[string]PostgresHbaSpec)
+	}
+	if b.PostgresIdent == nil {
+		b.PostgresIdent = make(map[string]PostgresIdentSpec)
+	}
+	if b.Mounts == nil {
+		b.Mounts = make(map[string]MountSpec)
+	}
+	if b.Ports == nil {
+		b.Ports = make(map[string]PortSpec)
+	}
+	if b.Processes == nil {
+		b.Processes = make(map[string]ProcessSpec)
+	}
+	if b.Commands == nil {
+		b.Commands = make(map[string]CommandSpec)
+	}
+	if b.Findings == nil {
+		b.Findings = make(map[string]FindingSpec)
+	}
+}
+
+// WriteHeader is a no-op for in-memory baselines
+func (b *Baseline) WriteHeader(comment string) error {
+	return nil
+}
+
+// StartResource sets the current resource type
+func (b *Baseline) StartResource(resourceType string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.currentResource = resourceType
+	return nil
+}
+
+// AddResource stores a spec in the map for its type. The spec's own type
+// determines where it is stored, so resourceType is not needed.
+func (b *Baseline) AddResource(resourceType string, spec interface{}) error {
+	return b.Add(spec)
+}
+
+// Add stores a spec in the map for its type
+func (b *Baseline) Add(spec interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.init()
+
+	switch s := spec.(type) {
+	case FileSpec:
+		b.Files[s.Path] = s
+	case PackageSpec:
+		b.Packages[s.Name] = s
+	case ServiceSpec:
+		b.Services[s.Name] = s
+	case SystemdUnitSpec:
+		b.SystemdUnits[s.Name] = s
+	case CronJobSpec:
+		b.CronJobs[s.ID] = s
+	case UserSpec:
+		b.Users[s.Username] = s
+	case GroupSpec:
+		b.Groups[s.Name] = s
+	case ShadowSpec:
+		b.Shadow[s.Username] = s
+	case SudoersRuleSpec:
+		b.SudoersRules[s.ID] = s
+	case SudoersDefaultSpec:
+		b.SudoersDefaults[s.ID] = s
+	case KernelParamSpec:
+		b.KernelParams[s.Key] = s
+	case SshdConfigSpec:
+		b.SshdConfig[s.Key] = s
+	case PostgresConfigSpec:
+		b.PostgresConfig[s.Name] = s
+	case PostgresHbaSpec:
+		b.PostgresHba[s.ID] = s
+	case PostgresIdentSpec:
+		b.PostgresIdent[s.ID] = s
+	case MountSpec:
+		b.Mounts[s.Path] = s
+	case PortSpec:
+		b.Ports[s.Port] = s
+	case ProcessSpec:
+		b.Processes[s.Comm] = s
+	case CommandSpec:
+		b.Commands[s.Command] = s
+	case FindingSpec:
+		b.Findings[s.ID] = s
+	default:
+		return fmt.Errorf("unsupported spec type %T", spec)
+	}
+	return nil
+}
+
+// Flush is a no-op for in-memory baselines
+func (b *Baseline) Flush() error {
+	return nil
+}
+
+// Close is a no-op for in-memory baselines
+func (b *Baseline) Close() error {
+	return nil
+}
+
+// ResourceCount returns the total number of resources in the baseline
+func (b *Baseline) ResourceCount() int {
+	return len(b.Files) + len(b.Packages) + len(b.Services) + len(b.SystemdUnits) +
+		len(b.CronJobs) + len(b.Users) + len(b.Groups) + len(b.Shadow) +
+		len(b.SudoersRules) + len(b.SudoersDefaults) + len(b.KernelParams) +
+		len(b.SshdConfig) + len(b.PostgresConfig) + len(b.PostgresHba) +
+		len(b.PostgresIdent) + len(b.Mounts) + len(b.Ports) + len(b.Processes) +
+		len(b.Commands) + len(b.Findings)
+}
@@ -0,0 +1,174 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBaseline(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := filepath.Join(tmpDir, "spec.yml")
+
+	content := `# Test spec
+file:
+  /etc/passwd:
+    exists: true
+    mode: "0644"
+    owner: root
+package:
+  bash:
+    installed: true
+    versions:
+      - 5.1-6ubuntu1
+service:
+  cron:
+    enabled: true
+    running: true
+kernel-param:
+  net.ipv4.ip_forward:
+    value: "0"
+`
+	if err := os.WriteFile(specPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	b, err := LoadBaseline(specPath)
+	if err != nil {
+		t.Fatalf("LoadBaseline failed: %v", err)
+	}
+
+	passwd, ok := b.Files["/etc/passwd"]
+	if !ok {
+		t.Fatal("/etc/passwd not loaded")
+	}
+	if passwd.Path != "/etc/passwd" {
+		t.Errorf("Expected Path to be filled from key, got %q", passwd.Path)
+	}
+	if passwd.Mode != "0644" || passwd.Owner != "root" {
+		t.Errorf("Unexpected file spec: %+v", passwd)
+	}
+
+	if b.Packages["bash"].Name != "bash" {
+		t.Errorf("Expected package name to be filled from key")
+	}
+	if enabled := b.Services["cron"].Enabled; enabled == nil || !*enabled {
+		t.Errorf("Expected cron to be enabled")
+	}
+	if b.KernelParams["net.ipv4.ip_forward"].Value != "0" {
+		t.Errorf("Expected ip_forward value 0, got %q", b.KernelParams["net.ipv4.ip_forward"].Value)
+	}
+
+	// Sections missing from the file still get usable maps
+	if b.Users == nil || len(b.Users) != 0 {
+		t.Errorf("Expected empty user map, got %v", b.Users)
+	}
+	if b.ResourceCount() != 4 {
+		t.Errorf("Expected 4 resources, got %d", b.ResourceCount())
+	}
+}
+
+func TestBaseline_Add(t *testing.T) {
+	b := NewBaseline()
+
+	if err := b.StartResource("user"); err != nil {
+		t.Fatalf("StartResource failed: %v", err)
+	}
+	uid := 101
+	if err := b.Add(UserSpec{Username: "postgres", Exists: true, UID: &uid}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := b.Add("not a spec"); err == nil {
+		t.Error("Expected error for unsupported spec type")
+	}
+
+	if got := b.Users["postgres"].UID; got == nil || *got != 101 {
+		t.Errorf("Expected postgres UID=101, got %v", got)
+	}
+}
+
+func TestLoad_Directory(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"files-etc.yml": "file:\n  /etc/hosts:\n    exists: true\n",
+		"package.yml":   "package:\n  bash:\n    installed: true\n",
+		"notes.txt":     "not a spec",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	b, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if _, ok := b.Files["/etc/hosts"]; !ok {
+		t.Error("Expected /etc/hosts from files-etc.yml")
+	}
+	if _, ok := b.Packages["bash"]; !ok {
+		t.Error("Expected bash from package.yml")
+	}
+	if b.ResourceCount() != 2 {
+		t.Errorf("Expected 2 resources, got %d", b.ResourceCount())
+	}
+
+	if keys := b.Keys("file"); len(keys) != 1 || keys[0] != "/etc/hosts" {
+		t.Errorf("Unexpected file keys: %v", keys)
+	}
+	if b.Resources("unknown") != nil {
+		t.Error("Expected nil for unknown resource type")
+	}
+}
+
+func TestLoadBaseline_DeclaredSections(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	specs := map[string]string{
+		"none.yml":  "package:\n  bash:\n    installed: true\n",
+		"empty.yml": "finding: {}\ncron-job: {}\n",
+		"listed.yml": `finding:
+  setuid:/usr/local/bin/helper:
+    check: setuid
+    path: /usr/local/bin/helper
+    mode: "4755"
+`,
+	}
+	want := map[string]bool{"none.yml": false, "empty.yml": true, "listed.yml": true}
+
+	for name, content := range specs {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		b, err := LoadBaseline(path)
+		if err != nil {
+			t.Fatalf("LoadBaseline(%s) failed: %v", name, err)
+		}
+		if b.Declares("finding") != want[name] {
+			t.Errorf("%s: Declares(finding) = %v, want %v", name, b.Declares("finding"), want[name])
+		}
+		if b.Declares("cron-job") != (name == "empty.yml") {
+			t.Errorf("%s: Declares(cron-job) = %v", name, b.Declares("cron-job"))
+		}
+	}
+
+	b, _ := LoadBaseline(filepath.Join(tmpDir, "listed.yml"))
+	finding := b.Findings["setuid:/usr/local/bin/helper"]
+	if finding.ID != "setuid:/usr/local/bin/helper" || finding.Check != "setuid" || finding.Mode != "4755" {
+		t.Errorf("Unexpected finding: %+v", finding)
+	}
+
+	// An empty finding section survives loading a split directory
+	os.Remove(filepath.Join(tmpDir, "listed.yml"))
+	merged, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !merged.Declares("finding") {
+		t.Error("Expected merged baseline to declare findings")
+	}
+}
@@ -1,33 +1,56 @@
 package spec
 
-// TestWriter is an in-memory writer for testing
+import "sync"
+
+// TestWriter is an in-memory writer for testing, safe for concurrent use
 type TestWriter struct {
+	mu              sync.Mutex
 	files           map[string]FileSpec
 	packages        map[string]PackageSpec
 	services        map[string]ServiceSpec
+	systemdUnits    map[string]SystemdUnitSpec
+	cronJobs        map[string]CronJobSpec
 	users           map[string]UserSpec
 	groups          map[string]GroupSpec
+	shadow          map[string]ShadowSpec
+	sudoersRules    map[string]SudoersRuleSpec
+	sudoersDefaults map[string]SudoersDefaultSpec
 	kernelParams    map[string]KernelParamSpec
+	sshdConfig      map[string]SshdConfigSpec
+	postgresConfig  map[string]PostgresConfigSpec
+	postgresHba     map[string]PostgresHbaSpec
+	postgresIdent   map[string]PostgresIdentSpec
 	mounts          map[string]MountSpec
 	ports           map[string]PortSpec
 	processes       map[string]ProcessSpec
 	commands        map[string]CommandSpec
+	findings        map[string]FindingSpec
 	currentResource string
 }
 
 // NewTestWriter creates a new in-memory writer for testing
 func NewTestWriter() *TestWriter {
 	return &TestWriter{
-		files:        make(map[string]FileSpec),
-		packages:     make(map[string]PackageSpec),
-		services:     make(map[string]ServiceSpec),
-		users:        make(map[string]UserSpec),
-		groups:       make(map[string]GroupSpec),
-		kernelParams: make(map[string]KernelParamSpec),
-		mounts:       make(map[string]MountSpec),
-		ports:        make(map[string]PortSpec),
-		processes:    make(map[string]ProcessSpec),
-		commands:     make(map[string]CommandSpec),
+		files:           make(map[string]FileSpec),
+		packages:        make(map[string]PackageSpec),
+		services:        make(map[string]ServiceSpec),
+		systemdUnits:    make(map[string]SystemdUnitSpec),
+		cronJobs:        make(map[string]CronJobSpec),
+		users:           make(map[string]UserSpec),
+		groups:          make(map[string]GroupSpec),
+		shadow:          make(map[string]ShadowSpec),
+		sudoersRules:    make(map[string]SudoersRuleSpec),
+		sudoersDefaults: make(map[string]SudoersDefaultSpec),
+		kernelParams:    make(map[string]KernelParamSpec),
+		sshdConfig:      make(map[string]SshdConfigSpec),
+		postgresConfig:  make(map[string]PostgresConfigSpec),
+		postgresHba:     make(map[string]PostgresHbaSpec),
+		postgresIdent:   make(map[string]PostgresIdentSpec),
+		mounts:          make(map[string]MountSpec),
+		ports:           make(map[string]PortSpec),
+		processes:       make(map[string]ProcessSpec),
+		commands:        make(map[string]CommandSpec),
+		findings:        make(map[string]FindingSpec),
 	}
 }
 
@@ -38,12 +61,23 @@ func (w *TestWriter) WriteHeader(comment string) error {
 
 // StartResource sets the current resource type
 func (w *TestWriter) StartResource(resourceType string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	w.currentResource = resourceType
 	return nil
 }
 
+// AddResource stores a spec in the appropriate map
+func (w *TestWriter) AddResource(resourceType string, spec interface{}) error {
+	return w.Add(spec)
+}
+
 // Add stores a spec in the appropriate map
 func (w *TestWriter) Add(spec interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	switch s := spec.(type) {
 	case FileSpec:
 		w.files[s.Path] = s
@@ -51,12 +85,30 @@ func (w *TestWriter) Add(spec interface{}) error {
 		w.packages[s.Name] = s
 	case ServiceSpec:
 		w.services[s.Name] = s
+	case SystemdUnitSpec:
+		w.systemdUnits[s.Name] = s
+	case CronJobSpec:
+		w.cronJobs[s.ID] = s
 	case UserSpec:
 		w.users[s.Username] = s
 	case GroupSpec:
 		w.groups[s.Name] = s
+	case ShadowSpec:
+		w.shadow[s.Username] = s
+	case SudoersRuleSpec:
+		w.sudoersRules[s.ID] = s
+	case SudoersDefaultSpec:
+		w.sudoersDefaults[s.ID] = s
 	case KernelParamSpec:
 		w.kernelParams[s.Key] = s
+	case SshdConfigSpec:
+		w.sshdConfig[s.Key] = s
+	case PostgresConfigSpec:
+		w.postgresConfig[s.Name] = s
+	case PostgresHbaSpec:
+		w.postgresHba[s.ID] = s
+	case PostgresIdentSpec:
+		w.postgresIdent[s.ID] = s
 	case MountSpec:
 		w.mounts[s.Path] = s
 	case PortSpec:
@@ -65,6 +117,8 @@ func (w *TestWriter) Add(spec interface{}) error {
 		w.processes[s.Comm] = s
 	case CommandSpec:
 		w.commands[s.Command] = s
+	case FindingSpec:
+		w.findings[s.ID] = s
 	}
 	return nil
 }
@@ -94,6 +148,16 @@ func (w *TestWriter) GetServiceResults() map[string]ServiceSpec {
 	return w.services
 }
 
+// GetSystemdUnitResults returns all systemd unit specs
+func (w *TestWriter) GetSystemdUnitResults() map[string]SystemdUnitSpec {
+	return w.systemdUnits
+}
+
+// GetCronJobResults returns all cron job specs
+func (w *TestWriter) GetCronJobResults() map[string]CronJobSpec {
+	return w.cronJobs
+}
+
 // GetUserResults returns all user specs
 func (w *TestWriter) GetUserResults() map[string]UserSpec {
 	return w.users
@@ -104,11 +168,46 @@ func (w *TestWriter) GetGroupResults() map[string]GroupSpec {
 	return w.groups
 }
 
+// GetShadowResults returns all shadow specs
+func (w *TestWriter) GetShadowResults() map[string]ShadowSpec {
+	return w.shadow
+}
+
+// GetSudoersRuleResults returns all sudoers rule specs
+func (w *TestWriter) GetSudoersRuleResults() map[string]SudoersRuleSpec {
+	return w.sudoersRules
+}
+
+// GetSudoersDefaultResults returns all sudoers Defaults specs
+func (w *TestWriter) GetSudoersDefaultResults() map[string]SudoersDefaultSpec {
+	return w.sudoersDefaults
+}
+
 // GetKernelParamResults returns all kernel param specs
 func (w *TestWriter) GetKernelParamResults() map[string]KernelParamSpec {
 	return w.kernelParams
 }
 
+// GetSshdConfigResults returns all sshd config specs
+func (w *TestWriter) GetSshdConfigResults() map[string]SshdConfigSpec {
+	return w.sshdConfig
+}
+
+// GetPostgresConfigResults returns all PostgreSQL setting specs
+func (w *TestWriter) GetPostgresConfigResults() map[string]PostgresConfigSpec {
+	return w.postgresConfig
+}
+
+// GetPostgresHbaResults returns all pg_hba.conf rule specs
+func (w *TestWriter) GetPostgresHbaResults() map[string]PostgresHbaSpec {
+	return w.postgresHba
+}
+
+// GetPostgresIdentResults returns all pg_ident.conf mapping specs
+func (w *TestWriter) GetPostgresIdentResults() map[string]PostgresIdentSpec {
+	return w.postgresIdent
+}
+
 // GetMountResults returns all mount specs
 func (w *TestWriter) GetMountResults() map[string]MountSpec {
 	return w.mounts
@@ -129,10 +228,17 @@ func (w *TestWriter) GetCommandResults() map[string]CommandSpec {
 	return w.commands
 }
 
+// GetFindingResults returns all finding specs
+func (w *TestWriter) GetFindingResults() map[string]FindingSpec {
+	return w.findings
+}
+
 // GetResourceCount returns the total number of resources written
 func (w *TestWriter) GetResourceCount() int {
-	return len(w.files) + len(w.packages) + len(w.services) +
-		len(w.users) + len(w.groups) + len(w.kernelParams) +
-		len(w.mounts) + len(w.ports) + len(w.processes) +
-		len(w.commands)
+	return len(w.files) + len(w.packages) + len(w.services) + len(w.systemdUnits) +
+		len(w.cronJobs) + len(w.users) + len(w.groups) + len(w.shadow) +
+		len(w.sudoersRules) + len(w.sudoersDefaults) + len(w.kernelParams) +
+		len(w.sshdConfig) + len(w.postgresConfig) + len(w.postgresHba) +
+		len(w.postgresIdent) + len(w.mounts) + len(w.ports) + len(w.processes) +
+		len(w.commands) + len(w.findings)
 }
@@ -1,5 +1,14 @@
 package spec
 
+import "strings"
+
+// The specs named after a goss resource (file, package, service, user,
+// group, kernel-param, mount, port, process, command) follow the goss
+// format, plus a few fields of supascan's own: the file Capabilities and
+// TargetExists, the service Enablement and the group Members. The other
+// specs are supascan resource types. Only the native engine checks these;
+// the goss engine leaves them out of what it passes to goss.
+
 // FileSpec represents a GOSS file resource
 type FileSpec struct {
 	Path     string   `yaml:"-" json:"-"`
@@ -8,7 +17,16 @@ type FileSpec struct {
 	Owner    string   `yaml:"owner,omitempty" json:"owner,omitempty"`
 	Group    string   `yaml:"group,omitempty" json:"group,omitempty"`
 	Filetype string   `yaml:"filetype,omitempty" json:"filetype,omitempty"`
+	Sha256   string   `yaml:"sha256,omitempty" json:"sha256,omitempty"`
+	LinkedTo string   `yaml:"linked-to,omitempty" json:"linked-to,omitempty"`
 	Contains []string `yaml:"contains,omitempty" json:"contains,omitempty"`
+
+	// Capabilities lists the file capabilities in getcap notation, e.g.
+	// "cap_net_raw=ep"
+	Capabilities string `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+
+	// TargetExists records whether a symlink's target exists
+	TargetExists *bool `yaml:"target-exists,omitempty" json:"target-exists,omitempty"`
 }
 
 // PackageSpec represents a GOSS package resource
@@ -18,19 +36,62 @@ type PackageSpec struct {
 	Versions  []string `yaml:"versions,omitempty" json:"versions,omitempty"`
 }
 
-// ServiceSpec represents a GOSS service resource
+// ServiceSpec represents a GOSS service resource. Enabled follows
+// "systemctl is-enabled", so "generated" units from SysV scripts count as
+// enabled. Enablement is the unit file state ("enabled", "disabled",
+// "static", "masked", ...), which keeps apart the states that Enabled
+// reports as false. Scanners always set Enabled and Running; a spec that
+// leaves one out (nil) does not check it.
 type ServiceSpec struct {
-	Name    string `yaml:"-" json:"-"`
-	Enabled bool   `yaml:"enabled" json:"enabled"`
-	Running bool   `yaml:"running" json:"running"`
+	Name       string `yaml:"-" json:"-"`
+	Enabled    *bool  `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	Enablement string `yaml:"enablement,omitempty" json:"enablement,omitempty"`
+	Running    *bool  `yaml:"running,omitempty" json:"running,omitempty"`
+}
+
+// SystemdUnitSpec is a systemd unit of any type (service, timer, socket,
+// path, mount, ...) as reported by "systemctl show", keyed by the unit name
+// (e.g. "postgresql.service" or "logrotate.timer"). Enablement is the unit
+// file state ("enabled", "disabled", "static", "masked", ...), and Schedule
+// the OnCalendar and monotonic triggers of a timer. Properties holds the
+// properties selected for the unit, and DropIns the drop-in files
+// extending it, written even when empty so a new drop-in is caught.
+type SystemdUnitSpec struct {
+	Name       string            `yaml:"-" json:"-"`
+	Type       string            `yaml:"type" json:"type"`
+	Enablement string            `yaml:"enablement,omitempty" json:"enablement,omitempty"`
+	Active     string            `yaml:"active,omitempty" json:"active,omitempty"`
+	Schedule   string            `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	Properties map[string]string `yaml:"properties,omitempty" json:"properties,omitempty"`
+	DropIns    []string          `yaml:"drop-ins" json:"drop-ins"`
+}
+
+// CronJobSpec is a job run by cron, anacron or at, keyed by CronJobID, or
+// by the spool file of an at job. Schedule is the cron time specification
+// ("*/5 * * * *", "@reboot"), the anacron period and delay ("1 5"), the
+// period of a script in /etc/cron.{hourly,daily,weekly,monthly}
+// ("@daily") or the time of an at job ("2024-06-04 06:00 UTC"). Source is
+// the file or directory the job was read from.
+type CronJobSpec struct {
+	ID       string `yaml:"-" json:"-"`
+	Schedule string `yaml:"schedule" json:"schedule"`
+	User     string `yaml:"user,omitempty" json:"user,omitempty"`
+	Command  string `yaml:"command" json:"command"`
+	Source   string `yaml:"source" json:"source"`
+}
+
+// CronJobID returns the resource key of a cron job, e.g.
+// "/etc/cron.d/backup: 0 3 * * * /usr/local/bin/backup"
+func CronJobID(source, schedule, command string) string {
+	return source + ": " + schedule + " " + command
 }
 
 // UserSpec represents a GOSS user resource
 type UserSpec struct {
 	Username string   `yaml:"-" json:"-"`
 	Exists   bool     `yaml:"exists" json:"exists"`
-	UID      int      `yaml:"uid,omitempty" json:"uid,omitempty"`
-	GID      int      `yaml:"gid,omitempty" json:"gid,omitempty"`
+	UID      *int     `yaml:"uid,omitempty" json:"uid,omitempty"`
+	GID      *int     `yaml:"gid,omitempty" json:"gid,omitempty"`
 	Groups   []string `yaml:"groups,omitempty" json:"groups,omitempty"`
 	Home     string   `yaml:"home,omitempty" json:"home,omitempty"`
 	Shell    string   `yaml:"shell,omitempty" json:"shell,omitempty"`
@@ -40,7 +101,66 @@ type UserSpec struct {
 type GroupSpec struct {
 	Name   string `yaml:"-" json:"-"`
 	Exists bool   `yaml:"exists" json:"exists"`
-	GID    int    `yaml:"gid,omitempty" json:"gid,omitempty"`
+	GID    *int   `yaml:"gid,omitempty" json:"gid,omitempty"`
+
+	// Members lists the users named in the group's /etc/group entry. It is
+	// written even when empty, so a group gaining its first member is
+	// caught; a spec without it (nil) does not check members.
+	Members []string `yaml:"members" json:"members"`
+}
+
+// ShadowSpec is the password policy of an account from /etc/shadow, or the
+// defaults for new accounts from /etc/login.defs (keyed "/etc/login.defs").
+// The password hash itself is never recorded, only whether the password is
+// usable. Unset aging fields are nil.
+type ShadowSpec struct {
+	Username      string `yaml:"-" json:"-"`
+	Password      string `yaml:"password,omitempty" json:"password,omitempty"` // "usable", "locked" or "empty"
+	MinDays       *int   `yaml:"min-days,omitempty" json:"min-days,omitempty"`
+	MaxDays       *int   `yaml:"max-days,omitempty" json:"max-days,omitempty"`
+	WarnDays      *int   `yaml:"warn-days,omitempty" json:"warn-days,omitempty"`
+	InactiveDays  *int   `yaml:"inactive-days,omitempty" json:"inactive-days,omitempty"`
+	EncryptMethod string `yaml:"encrypt-method,omitempty" json:"encrypt-method,omitempty"` // login.defs only
+}
+
+// SudoersRuleSpec is one user specification of sudoers, keyed by
+// SudoersRuleID. User is the user, %group or alias list the rule applies to
+// as written, and RunAs the users and groups the commands may run as
+// ("root", "ALL : ALL"), empty for the default of root. A line granting
+// commands under different run-as users or tags is recorded as one rule
+// each. Tags holds the tags other than NOPASSWD and PASSWD, such as SETENV
+// or NOEXEC. Aliases are recorded by name, not expanded.
+type SudoersRuleSpec struct {
+	ID       string   `yaml:"-" json:"-"`
+	User     string   `yaml:"user" json:"user"`
+	Hosts    []string `yaml:"hosts" json:"hosts"`
+	RunAs    string   `yaml:"runas,omitempty" json:"runas,omitempty"`
+	Commands []string `yaml:"commands" json:"commands"`
+	NoPasswd bool     `yaml:"nopasswd" json:"nopasswd"`
+	Tags     []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Source   string   `yaml:"source" json:"source"`
+}
+
+// SudoersRuleID returns the resource key of a sudoers rule, e.g.
+// "%sudo ALL = (ALL : ALL) ALL" or
+// "postgres ALL = (root) /usr/bin/systemctl restart postgresql"
+func SudoersRuleID(user string, hosts []string, runAs string, commands []string) string {
+	id := user + " " + strings.Join(hosts, ", ") + " ="
+	if runAs != "" {
+		id += " (" + runAs + ")"
+	}
+	return id + " " + strings.Join(commands, ", ")
+}
+
+// SudoersDefaultSpec is one setting of a sudoers Defaults line, keyed by
+// "Defaults" with its scope and the setting name, e.g. "Defaults
+// secure_path" or "Defaults:postgres requiretty". Value is "true" or
+// "false" for flags, the value for assignments, and one "+= value" or
+// "-= value" line per list change.
+type SudoersDefaultSpec struct {
+	ID     string `yaml:"-" json:"-"`
+	Value  string `yaml:"value" json:"value"`
+	Source string `yaml:"source" json:"source"`
 }
 
 // KernelParamSpec represents a GOSS kernel-param resource
@@ -49,6 +169,69 @@ type KernelParamSpec struct {
 	Value string `yaml:"value" json:"value"`
 }
 
+// SshdConfigSpec is one directive of the effective sshd configuration,
+// keyed by its lowercase name as "sshd -T" prints it, e.g.
+// "permitrootlogin". Directives within a Match block are keyed with the
+// block's criteria, e.g. "[match user backup] passwordauthentication".
+// Repeated directives such as hostkey are joined with newlines. Source
+// tells how the value was read: SshdSourceEffective values come from
+// "sshd -T", which includes the compiled-in defaults and normalises values
+// ("logingracetime 120"), SshdSourceConfig ones are as written in
+// sshd_config and its Include files ("LoginGraceTime 2m").
+type SshdConfigSpec struct {
+	Key    string `yaml:"-" json:"-"`
+	Value  string `yaml:"value" json:"value"`
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+}
+
+// Sources of an sshd-config value
+const (
+	SshdSourceEffective = "sshd -T"
+	SshdSourceConfig    = "sshd_config"
+)
+
+// PostgresConfigSpec is one setting (GUC) of the effective PostgreSQL
+// configuration, keyed by its lowercase name, after postgresql.conf, its
+// includes and postgresql.auto.conf are applied.
+type PostgresConfigSpec struct {
+	Name  string `yaml:"-" json:"-"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// PostgresHbaSpec is one client authentication rule of pg_hba.conf, keyed
+// by PostgresHbaID. Order is the rule's position among the rules, since
+// the first matching rule applies.
+type PostgresHbaSpec struct {
+	ID       string `yaml:"-" json:"-"`
+	Order    int    `yaml:"order" json:"order"`
+	Type     string `yaml:"type" json:"type"` // "local", "host", "hostssl", ...
+	Database string `yaml:"database" json:"database"`
+	User     string `yaml:"user" json:"user"`
+	Address  string `yaml:"address,omitempty" json:"address,omitempty"` // Not set for local rules
+	Method   string `yaml:"method" json:"method"`
+	Options  string `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// PostgresHbaID returns the resource key of a pg_hba.conf rule, made of the
+// fields that select the connections it applies to, e.g.
+// "host all all 0.0.0.0/0" or "local all postgres"
+func PostgresHbaID(connType, database, user, address string) string {
+	id := connType + " " + database + " " + user
+	if address != "" {
+		id += " " + address
+	}
+	return id
+}
+
+// PostgresIdentSpec is one user name mapping of pg_ident.conf, keyed by
+// "map system-user database-user".
+type PostgresIdentSpec struct {
+	ID         string `yaml:"-" json:"-"`
+	Map        string `yaml:"map" json:"map"`
+	SystemUser string `yaml:"system-user" json:"system-user"`
+	PgUser     string `yaml:"pg-user" json:"pg-user"`
+}
+
 // MountSpec represents a GOSS mount resource
 type MountSpec struct {
 	Path       string   `yaml:"-" json:"-"`
@@ -72,6 +255,23 @@ type ProcessSpec struct {
 	Running bool   `yaml:"running" json:"running"`
 }
 
+// FindingSpec is a risky state flagged by the findings scanner, such as a
+// world-writable file. Findings are keyed by check and path, e.g.
+// "world-writable:/etc/foo".
+type FindingSpec struct {
+	ID    string `yaml:"-" json:"-"`
+	Check string `yaml:"check" json:"check"` // "world-writable", "unowned", "ungrouped", "setuid" or "setgid"
+	Path  string `yaml:"path" json:"path"`
+	Mode  string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Owner string `yaml:"owner,omitempty" json:"owner,omitempty"`
+	Group string `yaml:"group,omitempty" json:"group,omitempty"`
+}
+
+// FindingID returns the key of a finding for check at path
+func FindingID(check, path string) string {
+	return check + ":" + path
+}
+
 // CommandSpec represents a GOSS command resource
 type CommandSpec struct {
 	Command  string `yaml:"-" json:"-"`
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,8 +19,12 @@ const (
 	FormatJSON
 )
 
-// YAMLWriter handles writing GOSS spec files with chunked streaming
+// YAMLWriter handles writing GOSS spec files with chunked streaming. It is
+// safe for concurrent use; concurrent scanners should add resources with
+// AddResource rather than StartResource and Add, which share one current
+// resource type.
 type YAMLWriter struct {
+	mu              sync.Mutex
 	file            *os.File
 	buffer          map[string]map[string]interface{} // resourceType -> key -> spec
 	currentResource string
@@ -61,6 +66,9 @@ func (w *YAMLWriter) WriteHeader(comment string) error {
 
 // StartResource begins a new resource section (e.g., "file", "package")
 func (w *YAMLWriter) StartResource(resourceType string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	w.currentResource = resourceType
 	if w.buffer[resourceType] == nil {
 		w.buffer[resourceType] = make(map[string]interface{})
@@ -70,16 +78,33 @@ func (w *YAMLWriter) StartResource(resourceType string) error {
 
 // Add adds a spec to the current resource buffer
 func (w *YAMLWriter) Add(spec interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	if w.currentResource == "" {
 		return fmt.Errorf("no resource started, call StartResource first")
 	}
+	return w.add(w.currentResource, spec)
+}
+
+// AddResource adds a spec to the buffer for resourceType
+func (w *YAMLWriter) AddResource(resourceType string, spec interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
+	return w.add(resourceType, spec)
+}
+
+func (w *YAMLWriter) add(resourceType string, spec interface{}) error {
 	key := extractKey(spec)
 	if key == "" {
 		return fmt.Errorf("unable to extract key from spec type %T", spec)
 	}
 
-	w.buffer[w.currentResource][key] = spec
+	if w.buffer[resourceType] == nil {
+		w.buffer[resourceType] = make(map[string]interface{})
+	}
+	w.buffer[resourceType][key] = spec
 	return nil
 }
 
@@ -93,6 +118,9 @@ func (w *YAMLWriter) Flush() error {
 
 // Close finalizes the file by encoding all buffered data
 func (w *YAMLWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	if w.file == nil {
 		return nil
 	}
@@ -125,12 +153,30 @@ func extractKey(spec interface{}) string {
 		return s.Name
 	case ServiceSpec:
 		return s.Name
+	case SystemdUnitSpec:
+		return s.Name
+	case CronJobSpec:
+		return s.ID
 	case UserSpec:
 		return s.Username
 	case GroupSpec:
 		return s.Name
+	case ShadowSpec:
+		return s.Username
+	case SudoersRuleSpec:
+		return s.ID
+	case SudoersDefaultSpec:
+		return s.ID
 	case KernelParamSpec:
 		return s.Key
+	case SshdConfigSpec:
+		return s.Key
+	case PostgresConfigSpec:
+		return s.Name
+	case PostgresHbaSpec:
+		return s.ID
+	case PostgresIdentSpec:
+		return s.ID
 	case MountSpec:
 		return s.Path
 	case PortSpec:
@@ -139,6 +185,8 @@ func extractKey(spec interface{}) string {
 		return s.Comm
 	case CommandSpec:
 		return s.Command
+	case FindingSpec:
+		return s.ID
 	default:
 		return ""
 	}
@@ -0,0 +1,323 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// gossOutput is the document printed by "goss validate --format json"
+type gossOutput struct {
+	Results []gossResult `json:"results"`
+}
+
+// gossResult is a single test result from goss JSON output
+type gossResult struct {
+	ResourceType string          `json:"resource-type"`
+	ResourceID   string          `json:"resource-id"`
+	Property     string          `json:"property"`
+	Expected     json.RawMessage `json:"expected"`
+	Found        json.RawMessage `json:"found"`
+	Successful   bool            `json:"successful"`
+	Skipped      bool            `json:"skipped"`
+	Err          json.RawMessage `json:"err"`
+}
+
+func (v *Validator) findGoss() (string, error) {
+	// Check if gossPath is absolute or in PATH
+	if filepath.IsAbs(v.opts.GossPath) {
+		if _, err := os.Stat(v.opts.GossPath); err == nil {
+			return v.opts.GossPath, nil
+		}
+		return "", fmt.Errorf("goss not found at %s", v.opts.GossPath)
+	}
+
+	// Look in PATH
+	path, err := exec.LookPath(v.opts.GossPath)
+	if err == nil {
+		return path, nil
+	}
+
+	// Common locations
+	commonPaths := []string{
+		"/usr/local/bin/goss",
+		"/usr/bin/goss",
+		"/nix/var/nix/profiles/default/bin/goss",
+	}
+	for _, p := range commonPaths {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("goss not found in PATH or common locations")
+}
+
+// gossResourceTypes are the spec sections goss knows. The other sections
+// genspec writes are supascan resource types only the native engine checks.
+var gossResourceTypes = map[string]bool{
+	"addr": true, "command": true, "dns": true, "file": true, "gossfile": true,
+	"group": true, "http": true, "interface": true, "kernel-param": true,
+	"matching": true, "mount": true, "package": true, "port": true,
+	"process": true, "service": true, "user": true,
+}
+
+// supascanAttributes are the attributes supascan adds to goss resource
+// types, which goss does not know
+var supascanAttributes = map[string]map[string]bool{
+	"file":    {"capabilities": true, "target-exists": true},
+	"service": {"enablement": true},
+	"group":   {"members": true},
+}
+
+// gossSpec prepares a spec file for goss. Sections goss does not know are
+// removed, and their resources returned by resource type, and so are the
+// attributes supascan adds to the sections it knows. The returned path is
+// specPath itself when nothing was removed, otherwise a temporary file the
+// caller must remove; it is empty when no section is left to run.
+func gossSpec(specPath string) (string, map[string][]string, error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", nil, fmt.Errorf("failed to parse spec file %s: %w", specPath, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return specPath, nil, nil
+	}
+
+	// Mapping node content alternates keys and values
+	root := doc.Content[0]
+	unsupported := make(map[string][]string)
+	stripped := false
+	var kept []*yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, section := root.Content[i], root.Content[i+1]
+		if gossResourceTypes[key.Value] {
+			if stripAttributes(section, supascanAttributes[key.Value]) {
+				stripped = true
+			}
+			kept = append(kept, key, section)
+			continue
+		}
+		resources := []string{}
+		for j := 0; j+1 < len(section.Content); j += 2 {
+			resources = append(resources, section.Content[j].Value)
+		}
+		unsupported[key.Value] = resources
+	}
+
+	if len(unsupported) == 0 && !stripped {
+		return specPath, nil, nil
+	}
+	if len(kept) == 0 {
+		return "", unsupported, nil
+	}
+
+	root.Content = kept
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode goss spec: %w", err)
+	}
+	f, err := os.CreateTemp("", "supascan-goss-*.yml")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create goss spec: %w", err)
+	}
+	if _, err := f.Write(out); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", nil, fmt.Errorf("failed to write goss spec: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", nil, fmt.Errorf("failed to write goss spec: %w", err)
+	}
+	return f.Name(), unsupported, nil
+}
+
+// stripAttributes removes the given attributes from every resource of a
+// section and reports whether any were found
+func stripAttributes(section *yaml.Node, attributes map[string]bool) bool {
+	if len(attributes) == 0 {
+		return false
+	}
+
+	stripped := false
+	for j := 1; j < len(section.Content); j += 2 {
+		resource := section.Content[j]
+		if resource.Kind != yaml.MappingNode {
+			continue
+		}
+		var kept []*yaml.Node
+		for k := 0; k+1 < len(resource.Content); k += 2 {
+			if attributes[resource.Content[k].Value] {
+				stripped = true
+				continue
+			}
+			kept = append(kept, resource.Content[k], resource.Content[k+1])
+		}
+		resource.Content = kept
+	}
+	return stripped
+}
+
+// unsupportedChecks reports the resources of sections an engine does not
+// know as skipped checks, so they are not mistaken for passing ones
+func unsupportedChecks(unsupported map[string][]string, message string) []CheckResult {
+	var checks []CheckResult
+	for _, resourceType := range sortedKeys(unsupported) {
+		resources := append([]string(nil), unsupported[resourceType]...)
+		sort.Strings(resources)
+		for _, resource := range resources {
+			checks = append(checks, CheckResult{
+				ResourceType: resourceType,
+				Resource:     resource,
+				Property:     "engine",
+				Skipped:      true,
+				Message:      message,
+			})
+		}
+	}
+	return checks
+}
+
+// sudoCommand runs a command with sudo, since many goss checks require
+// root access
+func sudoCommand(args ...string) *exec.Cmd {
+	return exec.Command("sudo", args...)
+}
+
+// runGossSpec validates a spec file with goss and parses its JSON output.
+// Sections and attributes goss does not know are not passed to it: a spec
+// made only of such sections is skipped, and their resources in a mixed
+// spec are reported as skipped checks.
+func (v *Validator) runGossSpec(specPath string, result SpecResult) SpecResult {
+	gossFile, unsupported, err := gossSpec(specPath)
+	if err != nil {
+		result.Passed = false
+		result.Error = err
+		result.Output = err.Error()
+		return result
+	}
+	if len(unsupported) > 0 {
+		v.opts.Logger.Warn("Spec sections not supported by the goss engine are skipped",
+			"spec", result.File, "sections", strings.Join(sortedKeys(unsupported), ", "))
+	}
+	if gossFile == "" {
+		result.Skipped = true
+		result.Reason = "not supported by the goss engine"
+		return result
+	}
+	if gossFile != specPath {
+		defer os.Remove(gossFile)
+	}
+
+	// Build goss command
+	cmd := v.gossCommand(v.gossPath, "--gossfile", gossFile, "validate", "--format", "json")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	checks, parseErr := parseGossOutput(stdout.Bytes())
+	if parseErr != nil {
+		// Keep the raw output so the failure can still be diagnosed
+		result.Output = stdout.String() + stderr.String()
+		v.opts.Logger.Debug("Failed to parse goss output", "spec", specPath, "error", parseErr)
+	}
+	result.Checks = append(checks, unsupportedChecks(unsupported, "not supported by the goss engine, use --engine native")...)
+
+	if err != nil {
+		result.Passed = false
+		result.Error = err
+	} else {
+		result.Passed = true
+	}
+
+	return result
+}
+
+// parseGossOutput converts goss JSON output into check results
+func parseGossOutput(data []byte) ([]CheckResult, error) {
+	var out gossOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid goss JSON output: %w", err)
+	}
+
+	checks := make([]CheckResult, 0, len(out.Results))
+	for _, r := range out.Results {
+		check := CheckResult{
+			ResourceType: kebabCase(r.ResourceType),
+			Resource:     r.ResourceID,
+			Property:     r.Property,
+			Expected:     formatGossValue(r.Expected),
+			Actual:       formatGossValue(r.Found),
+			Passed:       r.Successful,
+			Skipped:      r.Skipped,
+		}
+		if msg := formatGossValue(r.Err); msg != "" {
+			check.Message = msg
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// formatGossValue renders a goss expected/found value. goss reports these as
+// lists of strings, but older versions and errors use other JSON types.
+func formatGossValue(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		// Values are JSON-quoted inside the list ("\"0644\"")
+		for i, item := range list {
+			if unquoted, err := strconv.Unquote(item); err == nil {
+				list[i] = unquoted
+			}
+		}
+		if len(list) == 1 {
+			return list[0]
+		}
+		return "[" + strings.Join(list, ", ") + "]"
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+
+	return string(raw)
+}
+
+// kebabCase converts goss resource types ("KernelParam") to spec keys ("kernel-param")
+func kebabCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,232 @@
+package validator
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/supabase/supascan/internal/scanners"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+func TestParseGossOutput(t *testing.T) {
+	output := `{
+  "results": [
+    {
+      "duration": 12345,
+      "err": null,
+      "expected": ["true"],
+      "found": ["true"],
+      "human": "",
+      "meta": null,
+      "property": "exists",
+      "resource-id": "/etc/passwd",
+      "resource-type": "File",
+      "result": 0,
+      "skipped": false,
+      "successful": true,
+      "summary-line": "File: /etc/passwd: exists: matches expectation: [true]",
+      "test-type": 0,
+      "title": ""
+    },
+    {
+      "err": null,
+      "expected": ["\"0644\""],
+      "found": ["\"0600\""],
+      "property": "mode",
+      "resource-id": "/etc/passwd",
+      "resource-type": "File",
+      "result": 1,
+      "skipped": false,
+      "successful": false
+    },
+    {
+      "err": null,
+      "expected": ["\"0\""],
+      "found": null,
+      "property": "value",
+      "resource-id": "net.ipv4.ip_forward",
+      "resource-type": "KernelParam",
+      "result": 2,
+      "skipped": true,
+      "successful": false
+    }
+  ],
+  "summary": {"failed-count": 1, "test-count": 3}
+}`
+
+	checks, err := parseGossOutput([]byte(output))
+	if err != nil {
+		t.Fatalf("parseGossOutput failed: %v", err)
+	}
+
+	if len(checks) != 3 {
+		t.Fatalf("Expected 3 checks, got %d", len(checks))
+	}
+
+	if !checks[0].Passed || checks[0].ResourceType != "file" || checks[0].Expected != "true" {
+		t.Errorf("Unexpected first check: %+v", checks[0])
+	}
+
+	mode := checks[1]
+	if mode.Passed || mode.Property != "mode" || mode.Expected != "0644" || mode.Actual != "0600" {
+		t.Errorf("Unexpected mode check: %+v", mode)
+	}
+
+	param := checks[2]
+	if param.ResourceType != "kernel-param" || !param.Skipped {
+		t.Errorf("Unexpected kernel-param check: %+v", param)
+	}
+
+	failed := SpecResult{Checks: checks}.FailedChecks()
+	if len(failed) != 1 || failed[0].Property != "mode" {
+		t.Errorf("Expected only the mode check to fail, got %v", failed)
+	}
+}
+
+func TestParseGossOutput_Invalid(t *testing.T) {
+	if _, err := parseGossOutput([]byte("1..3\nok 1 - File: /etc/passwd")); err == nil {
+		t.Error("Expected error for non-JSON output")
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	tests := map[string]string{
+		"File":        "file",
+		"KernelParam": "kernel-param",
+		"Package":     "package",
+	}
+	for in, want := range tests {
+		if got := kebabCase(in); got != want {
+			t.Errorf("kebabCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// fakeGoss writes a stand-in for goss that, like goss, fails on spec
+// sections and attributes it does not know, and otherwise reports one
+// passing check
+func fakeGoss(t *testing.T, dir string) string {
+	t.Helper()
+
+	script := `#!/bin/sh
+# Called as: goss --gossfile <spec> validate --format json
+if grep -qE '^(systemd-unit|cron-job|shadow|sudoers-rule|sudoers-default|sshd-config|postgres-config|postgres-hba|postgres-ident|finding):' "$2"; then
+  echo "Error: unknown resource type in $2" >&2
+  exit 1
+fi
+if grep -qE '^ +(capabilities|target-exists|enablement|members):' "$2"; then
+  echo "Error: unknown attribute in $2" >&2
+  exit 1
+fi
+echo '{"results": [{"resource-type": "File", "resource-id": "/etc/passwd", "property": "exists", "expected": ["true"], "found": ["true"], "successful": true}]}'
+`
+	path := filepath.Join(dir, "goss")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake goss: %v", err)
+	}
+	return path
+}
+
+// newGossValidator returns a validator running the fake goss without sudo
+func newGossValidator(t *testing.T, dir string) *Validator {
+	t.Helper()
+
+	v := New(Options{BaselinesDir: dir, Engine: EngineGoss})
+	v.gossPath = fakeGoss(t, t.TempDir())
+	v.gossCommand = func(args ...string) *exec.Cmd {
+		return exec.Command(args[0], args[1:]...)
+	}
+	return v
+}
+
+func TestRunGossSpec_UnsupportedSections(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	writeSpec("cron-job.yml", `cron-job:
+  "/etc/crontab: @daily /usr/local/bin/backup":
+    schedule: '@daily'
+    user: root
+    command: /usr/local/bin/backup
+    source: /etc/crontab
+`)
+	writeSpec("baseline.yml", `file:
+  /etc/passwd:
+    exists: true
+finding:
+  setuid:/usr/local/bin/helper:
+    check: setuid
+    path: /usr/local/bin/helper
+`)
+
+	v := newGossValidator(t, dir)
+
+	// A spec goss cannot run at all is skipped, not passed
+	r := v.runSpec("cron-job.yml", SeverityCritical)
+	if !r.Skipped || r.Passed || !strings.Contains(r.Reason, "goss") {
+		t.Errorf("Expected cron-job.yml to be skipped for goss, got %+v", r)
+	}
+
+	// goss runs the sections it knows; the others are skipped checks
+	r = v.runSpec("baseline.yml", SeverityCritical)
+	if !r.Passed || r.Skipped {
+		t.Fatalf("Expected baseline.yml to pass, got %+v", r)
+	}
+	if len(r.Checks) != 2 {
+		t.Fatalf("Expected 2 checks, got %v", r.Checks)
+	}
+	skipped := r.Checks[1]
+	if !skipped.Skipped || skipped.ResourceType != "finding" || skipped.Resource != "setuid:/usr/local/bin/helper" {
+		t.Errorf("Expected the finding to be a skipped check, got %+v", skipped)
+	}
+
+	// The filtered spec given to goss is removed afterwards
+	leftovers, _ := filepath.Glob(filepath.Join(os.TempDir(), "supascan-goss-*.yml"))
+	if len(leftovers) != 0 {
+		t.Errorf("Temporary goss specs left behind: %v", leftovers)
+	}
+}
+
+func TestRunGossSpec_GeneratedGroupSpec(t *testing.T) {
+	dir := t.TempDir()
+
+	// Write group.yml the way genspec does, members included
+	writer, err := spec.NewWriter(filepath.Join(dir, "group.yml"))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	_, err = (&scanners.GroupScanner{}).Scan(context.Background(), scanners.ScanOptions{
+		Writer: writer,
+		RootFS: fstest.MapFS{
+			"etc/group": {Data: []byte("root:x:0:\nsudo:x:27:ubuntu\n")},
+		},
+		Logger: log.New(io.Discard),
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "group.yml"))
+	if !strings.Contains(string(data), "members:") {
+		t.Fatalf("Expected the generated spec to list members:\n%s", data)
+	}
+
+	r := newGossValidator(t, dir).runSpec("group.yml", SeverityCritical)
+	if !r.Passed || r.Skipped {
+		t.Errorf("Expected group.yml to pass under goss, got %+v (%s)", r, r.Output)
+	}
+}
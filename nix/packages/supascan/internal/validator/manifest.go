@@ -0,0 +1,144 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the manifest looked up in the baselines directory when no
+// manifest is given explicitly
+const ManifestFile = "baselines.yaml"
+
+// Severities a manifest rule can assign to spec files
+const (
+	SeverityCritical = "critical"
+	SeverityAdvisory = "advisory"
+	SeverityIgnore   = "ignore"
+)
+
+// Rule assigns a severity to spec files whose name matches a glob pattern
+type Rule struct {
+	Pattern  string `yaml:"pattern"`
+	Severity string `yaml:"severity"`
+}
+
+// Manifest classifies the spec files in a baselines directory. Rules are
+// evaluated in order and the first matching rule wins.
+type Manifest struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// plannedSpec is a spec file with the severity the manifest assigned to it
+type plannedSpec struct {
+	File     string
+	Severity string
+	Missing  bool // Named by a rule but not present in the directory
+}
+
+// DefaultManifest builds a manifest from CriticalSpecs and AdvisorySpecs,
+// used when the baselines directory has no manifest
+func DefaultManifest() *Manifest {
+	m := &Manifest{}
+	for _, spec := range CriticalSpecs {
+		m.Rules = append(m.Rules, Rule{Pattern: spec, Severity: SeverityCritical})
+	}
+	for _, spec := range AdvisorySpecs {
+		m.Rules = append(m.Rules, Rule{Pattern: spec, Severity: SeverityAdvisory})
+	}
+	return m
+}
+
+// LoadManifest reads and validates a manifest file
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	for i, rule := range m.Rules {
+		switch rule.Severity {
+		case SeverityCritical, SeverityAdvisory, SeverityIgnore:
+		default:
+			return nil, fmt.Errorf("manifest %s: rule %d (%s): invalid severity %q (must be critical, advisory or ignore)",
+				path, i+1, rule.Pattern, rule.Severity)
+		}
+		if _, err := filepath.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("manifest %s: rule %d: invalid pattern %q: %w", path, i+1, rule.Pattern, err)
+		}
+	}
+
+	return &m, nil
+}
+
+// Classify returns the severity of the first rule matching a spec file name
+func (m *Manifest) Classify(specFile string) (string, bool) {
+	for _, rule := range m.Rules {
+		if matched, _ := filepath.Match(rule.Pattern, specFile); matched {
+			return rule.Severity, true
+		}
+	}
+	return "", false
+}
+
+// plan assigns a severity to every spec file in dir. Specs are ordered by
+// the rule that matched them, then by name. Rules without glob characters
+// that match no file are planned as missing so they are reported as
+// skipped. Spec files no rule matches are returned separately.
+func (m *Manifest) plan(dir string) ([]plannedSpec, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read baselines directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == ManifestFile || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ext := filepath.Ext(name); ext == ".yml" || ext == ".yaml" {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+
+	matched := make([][]string, len(m.Rules))
+	var unclassified []string
+	for _, file := range files {
+		ruleIndex := -1
+		for i, rule := range m.Rules {
+			if ok, _ := filepath.Match(rule.Pattern, file); ok {
+				ruleIndex = i
+				break
+			}
+		}
+		if ruleIndex < 0 {
+			unclassified = append(unclassified, file)
+			continue
+		}
+		matched[ruleIndex] = append(matched[ruleIndex], file)
+	}
+
+	var planned []plannedSpec
+	for i, rule := range m.Rules {
+		if len(matched[i]) == 0 && !strings.ContainsAny(rule.Pattern, "*?[") {
+			planned = append(planned, plannedSpec{File: rule.Pattern, Severity: rule.Severity, Missing: true})
+			continue
+		}
+		for _, file := range matched[i] {
+			planned = append(planned, plannedSpec{File: file, Severity: rule.Severity})
+		}
+	}
+
+	return planned, unclassified, nil
+}
@@ -0,0 +1,170 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"baselines.yaml": `rules:
+  - pattern: "service*.yml"
+    severity: critical
+  - pattern: "files-*.yml"
+    severity: advisory
+  - pattern: "scratch.yml"
+    severity: ignore
+`,
+		"bad-severity.yaml": `rules:
+  - pattern: "*.yml"
+    severity: fatal
+`,
+		"bad-pattern.yaml": `rules:
+  - pattern: "[.yml"
+    severity: critical
+`,
+	})
+
+	m, err := LoadManifest(filepath.Join(dir, "baselines.yaml"))
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(m.Rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(m.Rules))
+	}
+
+	tests := []struct {
+		file     string
+		severity string
+		matched  bool
+	}{
+		{"service.yml", SeverityCritical, true},
+		{"service-deployed.yml", SeverityCritical, true},
+		{"files-etc.yml", SeverityAdvisory, true},
+		{"scratch.yml", SeverityIgnore, true},
+		{"user.yml", "", false},
+	}
+	for _, tt := range tests {
+		severity, matched := m.Classify(tt.file)
+		if severity != tt.severity || matched != tt.matched {
+			t.Errorf("Classify(%q) = %q, %v, want %q, %v", tt.file, severity, matched, tt.severity, tt.matched)
+		}
+	}
+
+	if _, err := LoadManifest(filepath.Join(dir, "bad-severity.yaml")); err == nil {
+		t.Error("Expected error for invalid severity")
+	}
+	if _, err := LoadManifest(filepath.Join(dir, "bad-pattern.yaml")); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestDefaultManifest(t *testing.T) {
+	m := DefaultManifest()
+
+	for _, spec := range CriticalSpecs {
+		if severity, _ := m.Classify(spec); severity != SeverityCritical {
+			t.Errorf("Expected %s to be critical, got %q", spec, severity)
+		}
+	}
+	for _, spec := range AdvisorySpecs {
+		if severity, _ := m.Classify(spec); severity != SeverityAdvisory {
+			t.Errorf("Expected %s to be advisory, got %q", spec, severity)
+		}
+	}
+}
+
+func TestManifest_Plan(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"baselines.yaml":         "rules: []\n",
+		"service-deployed.yml":   "service: {}\n",
+		"files-var-deployed.yml": "file: {}\n",
+		"files-etc-deployed.yml": "file: {}\n",
+		"scratch.yml":            "file: {}\n",
+		"extra.yml":              "file: {}\n",
+		"notes.txt":              "not a spec\n",
+	})
+
+	m := &Manifest{Rules: []Rule{
+		{Pattern: "service-*.yml", Severity: SeverityCritical},
+		{Pattern: "user.yml", Severity: SeverityCritical},
+		{Pattern: "files-*.yml", Severity: SeverityAdvisory},
+		{Pattern: "scratch.yml", Severity: SeverityIgnore},
+	}}
+
+	planned, unclassified, err := m.plan(dir)
+	if err != nil {
+		t.Fatalf("plan failed: %v", err)
+	}
+
+	want := []plannedSpec{
+		{File: "service-deployed.yml", Severity: SeverityCritical},
+		{File: "user.yml", Severity: SeverityCritical, Missing: true},
+		{File: "files-etc-deployed.yml", Severity: SeverityAdvisory},
+		{File: "files-var-deployed.yml", Severity: SeverityAdvisory},
+		{File: "scratch.yml", Severity: SeverityIgnore},
+	}
+	if !reflect.DeepEqual(planned, want) {
+		t.Errorf("plan() = %+v, want %+v", planned, want)
+	}
+
+	if !reflect.DeepEqual(unclassified, []string{"extra.yml"}) {
+		t.Errorf("Expected extra.yml to be unclassified, got %v", unclassified)
+	}
+}
+
+func TestValidator_ManifestClassification(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"baselines.yaml": `rules:
+  - pattern: "critical-*.yml"
+    severity: critical
+  - pattern: "advisory-*.yml"
+    severity: advisory
+  - pattern: "ignored.yml"
+    severity: ignore
+`,
+		"critical-files.yml": "file:\n  " + dir + ":\n    exists: true\n    filetype: directory\n",
+		"advisory-files.yml": "file:\n  " + filepath.Join(dir, "missing") + ":\n    exists: true\n",
+		"ignored.yml":        "file: {}\n",
+		"stray.yml":          "file: {}\n",
+	})
+
+	v := New(Options{BaselinesDir: dir, Format: "json"})
+	result, err := v.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if result.CriticalPassed != 1 || result.CriticalFailed != 0 {
+		t.Errorf("Expected 1 critical pass, got %d passed, %d failed", result.CriticalPassed, result.CriticalFailed)
+	}
+	if result.AdvisoryFailed != 1 {
+		t.Errorf("Expected 1 advisory failure, got %d", result.AdvisoryFailed)
+	}
+	if !reflect.DeepEqual(result.Ignored, []string{"ignored.yml"}) {
+		t.Errorf("Expected ignored.yml to be ignored, got %v", result.Ignored)
+	}
+	if !reflect.DeepEqual(result.Unclassified, []string{"stray.yml"}) {
+		t.Errorf("Expected stray.yml to be unclassified, got %v", result.Unclassified)
+	}
+	for _, s := range result.Specs {
+		if strings.HasPrefix(s.Spec, "ignored") || strings.HasPrefix(s.Spec, "stray") {
+			t.Errorf("Spec %s should not have been run", s.Spec)
+		}
+	}
+}
